@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpressions(t *testing.T) {
+	device := map[string]interface{}{
+		"device-id": "mx960-1",
+		"snmp":      map[string]interface{}{"v2": map[string]interface{}{"community": "public"}},
+		"facts":     map[string]interface{}{"platform": "MX960", "release": "19.3R1.8"},
+		"groups": []interface{}{
+			map[string]interface{}{"device-group-name": "core", "playbooks": []interface{}{"chassis-kpis-playbook"}},
+			map[string]interface{}{"device-group-name": "edge", "playbooks": []interface{}{}},
+		},
+		"port": float64(830),
+	}
+	cases := map[string]bool{
+		`device-id == "mx960-1"`:                                              true,
+		`snmp.v2.community != "public"`:                                       false,
+		`facts.platform =~ "^MX"`:                                             true,
+		`facts.platform !~ "^MX"`:                                             false,
+		`any(groups, "chassis-kpis-playbook" in playbooks)`:                   true,
+		`all(groups, "chassis-kpis-playbook" in playbooks)`:                   false,
+		`count(groups, len(playbooks) == 0) == 1`:                             true,
+		`exists(snmp.v3)`:                                                     false,
+		`not exists(snmp.v3) and (port >= 830 or false)`:                      true,
+		`lower(facts.platform) in ["mx960", 'mx480']`:                         true,
+		`facts.release contains "19.3"`:                                       true,
+		`any(groups, device-group-name == "core" and device-id == "mx960-1")`: true,
+		`missing.field == null`:                                               true,
+	}
+	for source, expected := range cases {
+		e, err := Parse(source)
+		if !assert.Nil(t, err, source) {
+			continue
+		}
+		ok, err := e.Test(device)
+		assert.Nil(t, err, source)
+		assert.Equal(t, expected, ok, source)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, source := range []string{`device-id ==`, `"unterminated`, `a =~ "("`, `any(groups)`, `a == b c`, `a # b`} {
+		_, err := Parse(source)
+		assert.NotNil(t, err, source)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`
+rule:
+- name: mx-runs-chassis-kpis
+  description: every MX must be in a group running chassis-kpis-playbook
+  entity: device
+  when: facts.platform =~ "^MX"
+  require: any(groups, "chassis-kpis-playbook" in playbooks)
+- name: no-public-community
+  entity: device
+  require: snmp.v2.community != "public"
+- name: group-notifications
+  entity: device-group
+  require: exists(notification)
+- name: instance-playbook-enabled
+  entity: playbook-instance
+  require: playbook in group.playbooks
+`))
+	assert.Nil(t, err)
+
+	devices := []map[string]interface{}{
+		{"device-id": "mx960-1", "snmp": map[string]interface{}{"v2": map[string]interface{}{"community": "public"}}},
+		{"device-id": "mx960-3"},
+		{"device-id": "qfx-1"},
+	}
+	groups := []map[string]interface{}{
+		{"device-group-name": "core", "devices": []interface{}{"mx960-1"}, "playbooks": []interface{}{"chassis-kpis-playbook"},
+			"notification": map[string]interface{}{"major": []interface{}{"slack"}},
+			"variable":     []interface{}{map[string]interface{}{"instance-id": "i1", "playbook": "interface-kpis-playbook", "rule": "interface.statistics/check-interface-errors"}}},
+		{"device-group-name": "edge", "devices": []interface{}{"mx960-3", "qfx-1"}},
+	}
+	facts := map[string]interface{}{
+		"mx960-1": map[string]interface{}{"platform": "MX960"},
+		"mx960-3": map[string]interface{}{"platform": "MX960"},
+		"qfx-1":   map[string]interface{}{"platform": "QFX5100"},
+	}
+	violations, err := policy.Evaluate(Entities(devices, groups, facts))
+	assert.Nil(t, err)
+	assert.Equal(t, []Violation{
+		{Kind: Device, ID: "mx960-1", Rule: "no-public-community"},
+		{Kind: Device, ID: "mx960-3", Rule: "mx-runs-chassis-kpis", Description: "every MX must be in a group running chassis-kpis-playbook"},
+		{Kind: DeviceGroup, ID: "edge", Rule: "group-notifications"},
+		{Kind: PlaybookInstance, ID: "core/i1/interface.statistics/check-interface-errors", Rule: "instance-playbook-enabled"},
+	}, violations)
+}
+
+func TestPolicyErrors(t *testing.T) {
+	for _, policy := range []string{
+		"rule:\n- entity: device\n  require: true\n",
+		"rule:\n- name: a\n  entity: router\n  require: true\n",
+		"rule:\n- name: a\n  entity: device\n",
+		"rule:\n- name: a\n  entity: device\n  require: a ==\n",
+		"rule:\n- name: a\n  entity: device\n  require: 'true'\n- name: a\n  entity: device\n  require: 'true'\n",
+		"rules: []\n",
+	} {
+		_, err := ParsePolicy([]byte(policy))
+		assert.NotNil(t, err, policy)
+	}
+}
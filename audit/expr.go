@@ -0,0 +1,598 @@
+package audit
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expression - a parsed policy expression, evaluated against an entity
+//
+// The language is small:
+//
+//	literals    "string", 'string', 42, 1.5, true, false, null, ["a", "b"]
+//	paths       device-id, snmp.v2.community, facts.platform
+//	comparison  == != < <= > >= =~ (regexp match) !~
+//	membership  x in list, list contains x (substring for strings)
+//	logic       and, or, not, ( )
+//	functions   any(list, expr), all(list, expr), count(list, expr), len(x), exists(path), lower(x)
+//
+// Inside any, all and count, paths are resolved against each element of the list first.
+type Expression struct {
+	source string
+	root   node
+}
+
+// Scope - the variables visible to an expression
+type Scope struct {
+	Values map[string]interface{}
+	Parent *Scope
+}
+
+func (s *Scope) lookup(name string) interface{} {
+	for scope := s; scope != nil; scope = scope.Parent {
+		if v, ok := scope.Values[name]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// Parse - compiles an expression
+func Parse(source string) (*Expression, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at %d in %q", p.peek().text, p.peek().pos, source)
+	}
+	return &Expression{source: source, root: root}, nil
+}
+
+// String - the source of the expression
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Evaluate - the value of the expression for the entity
+func (e *Expression) Evaluate(entity map[string]interface{}) (interface{}, error) {
+	return e.root.eval(&Scope{Values: entity})
+}
+
+// Test - the truthiness of the expression for the entity
+func (e *Expression) Test(entity map[string]interface{}) (bool, error) {
+	v, err := e.Evaluate(entity)
+	return truthy(v), err
+}
+
+//
+// lexer
+//
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var operators = []string{"==", "!=", "=~", "!~", "<=", ">=", "<", ">"}
+
+func isIdentStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_'
+}
+
+func isIdentPart(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-'
+}
+
+func lex(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			j := i + 1
+			var sb strings.Builder
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				sb.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at %d in %q", i, source)
+			}
+			tokens = append(tokens, token{tokenString, sb.String(), i})
+			i = j + 1
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{tokenNumber, string(runes[i:j]), i})
+			i = j
+		case isIdentStart(r):
+			j := i
+			for j < len(runes) && isIdentPart(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, token{tokenIdent, string(runes[i:j]), i})
+			i = j
+		case strings.ContainsRune("()[],.", r):
+			tokens = append(tokens, token{tokenPunct, string(r), i})
+			i++
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, token{tokenOperator, op, i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected %q at %d in %q", r, i, source)
+			}
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(runes)}), nil
+}
+
+//
+// parser
+//
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isKeyword(word string) bool {
+	t := p.peek()
+	return t.kind == tokenIdent && t.text == word
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	t := p.next()
+	if t.kind != kind || t.text != text {
+		return fmt.Errorf("expected %q at %d, found %q", text, t.pos, t.text)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicNode{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = logicNode{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.isKeyword("not") {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	var op string
+	switch {
+	case t.kind == tokenOperator:
+		op = t.text
+	case p.isKeyword("in"), p.isKeyword("contains"):
+		op = t.text
+	default:
+		return left, nil
+	}
+	p.next()
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if op == "=~" || op == "!~" {
+		if l, ok := right.(literalNode); ok {
+			if s, ok := l.value.(string); ok {
+				re, err := regexp.Compile(s)
+				if err != nil {
+					return nil, err
+				}
+				return matchNode{negate: op == "!~", left: left, re: re}, nil
+			}
+		}
+		return nil, fmt.Errorf("%s expects a string pattern at %d", op, t.pos)
+	}
+	return compareNode{op: op, left: left, right: right}, nil
+}
+
+var functions = map[string]int{"any": 2, "all": 2, "count": 2, "len": 1, "exists": 1, "lower": 1}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return literalNode{t.text}, nil
+	case tokenNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at %d", t.text, t.pos)
+		}
+		return literalNode{f}, nil
+	case tokenPunct:
+		switch t.text {
+		case "(":
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return inner, p.expect(tokenPunct, ")")
+		case "[":
+			var items []node
+			for !(p.peek().kind == tokenPunct && p.peek().text == "]") {
+				item, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+				if p.peek().kind == tokenPunct && p.peek().text == "," {
+					p.next()
+				}
+			}
+			p.next()
+			return listNode{items}, nil
+		}
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return literalNode{true}, nil
+		case "false":
+			return literalNode{false}, nil
+		case "null":
+			return literalNode{nil}, nil
+		}
+		if arity, ok := functions[t.text]; ok && p.peek().kind == tokenPunct && p.peek().text == "(" {
+			p.next()
+			var args []node
+			for i := 0; i < arity; i++ {
+				if i > 0 {
+					if err := p.expect(tokenPunct, ","); err != nil {
+						return nil, err
+					}
+				}
+				arg, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+			}
+			if err := p.expect(tokenPunct, ")"); err != nil {
+				return nil, err
+			}
+			return callNode{name: t.text, args: args}, nil
+		}
+		path := []string{t.text}
+		for p.peek().kind == tokenPunct && p.peek().text == "." {
+			p.next()
+			segment := p.next()
+			if segment.kind != tokenIdent {
+				return nil, fmt.Errorf("expected a name after . at %d", segment.pos)
+			}
+			path = append(path, segment.text)
+		}
+		return pathNode{path}, nil
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+}
+
+//
+// evaluation
+//
+
+type node interface {
+	eval(scope *Scope) (interface{}, error)
+}
+
+type literalNode struct{ value interface{} }
+
+func (n literalNode) eval(*Scope) (interface{}, error) { return n.value, nil }
+
+type listNode struct{ items []node }
+
+func (n listNode) eval(scope *Scope) (interface{}, error) {
+	var values []interface{}
+	for _, item := range n.items {
+		v, err := item.eval(scope)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+type pathNode struct{ path []string }
+
+func (n pathNode) eval(scope *Scope) (interface{}, error) {
+	v := scope.lookup(n.path[0])
+	for _, segment := range n.path[1:] {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		v = m[segment]
+	}
+	return v, nil
+}
+
+type notNode struct{ operand node }
+
+func (n notNode) eval(scope *Scope) (interface{}, error) {
+	v, err := n.operand.eval(scope)
+	return !truthy(v), err
+}
+
+type logicNode struct {
+	op          string
+	left, right node
+}
+
+func (n logicNode) eval(scope *Scope) (interface{}, error) {
+	l, err := n.left.eval(scope)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "and" && !truthy(l) {
+		return false, nil
+	}
+	if n.op == "or" && truthy(l) {
+		return true, nil
+	}
+	r, err := n.right.eval(scope)
+	return truthy(r), err
+}
+
+type matchNode struct {
+	negate bool
+	left   node
+	re     *regexp.Regexp
+}
+
+func (n matchNode) eval(scope *Scope) (interface{}, error) {
+	v, err := n.left.eval(scope)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := v.(string)
+	return ok && n.re.MatchString(s) != n.negate, nil
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n compareNode) eval(scope *Scope) (interface{}, error) {
+	l, err := n.left.eval(scope)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.right.eval(scope)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	case "in":
+		return contains(r, l), nil
+	case "contains":
+		return contains(l, r), nil
+	}
+	lf, lok := number(l)
+	rf, rok := number(r)
+	if !lok || !rok {
+		return false, nil
+	}
+	switch n.op {
+	case "<":
+		return lf < rf, nil
+	case "<=":
+		return lf <= rf, nil
+	case ">":
+		return lf > rf, nil
+	default:
+		return lf >= rf, nil
+	}
+}
+
+type callNode struct {
+	name string
+	args []node
+}
+
+func (n callNode) eval(scope *Scope) (interface{}, error) {
+	if n.name == "exists" {
+		v, err := n.args[0].eval(scope)
+		return v != nil, err
+	}
+	first, err := n.args[0].eval(scope)
+	if err != nil {
+		return nil, err
+	}
+	switch n.name {
+	case "len":
+		switch v := first.(type) {
+		case string:
+			return float64(len(v)), nil
+		case []interface{}:
+			return float64(len(v)), nil
+		case map[string]interface{}:
+			return float64(len(v)), nil
+		}
+		return float64(0), nil
+	case "lower":
+		if s, ok := first.(string); ok {
+			return strings.ToLower(s), nil
+		}
+		return first, nil
+	}
+	list, _ := first.([]interface{})
+	matched := 0
+	for _, item := range list {
+		values, ok := item.(map[string]interface{})
+		if !ok {
+			values = map[string]interface{}{"it": item}
+		}
+		v, err := n.args[1].eval(&Scope{Values: values, Parent: scope})
+		if err != nil {
+			return nil, err
+		}
+		if truthy(v) {
+			matched++
+		}
+	}
+	switch n.name {
+	case "any":
+		return matched > 0, nil
+	case "all":
+		return matched == len(list), nil
+	default:
+		return float64(matched), nil
+	}
+}
+
+func truthy(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case string:
+		return t != ""
+	case float64:
+		return t != 0
+	case []interface{}:
+		return len(t) > 0
+	case map[string]interface{}:
+		return len(t) > 0
+	}
+	return true
+}
+
+func number(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case string:
+		f, err := strconv.ParseFloat(t, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func equal(l, r interface{}) bool {
+	if lf, ok := l.(float64); ok {
+		if rf, ok := number(r); ok {
+			return lf == rf
+		}
+	}
+	if rf, ok := r.(float64); ok {
+		if lf, ok := number(l); ok {
+			return lf == rf
+		}
+	}
+	return reflect.DeepEqual(l, r)
+}
+
+func contains(container, item interface{}) bool {
+	switch c := container.(type) {
+	case []interface{}:
+		for _, v := range c {
+			if equal(v, item) {
+				return true
+			}
+		}
+	case string:
+		if s, ok := item.(string); ok {
+			return strings.Contains(c, s)
+		}
+	case map[string]interface{}:
+		if s, ok := item.(string); ok {
+			_, found := c[s]
+			return found
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package audit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// The kinds of entity a Rule can be applied to
+const (
+	Device           = "device"
+	DeviceGroup      = "device-group"
+	PlaybookInstance = "playbook-instance"
+)
+
+// Rule - a check applied to every entity of a kind, entities for which When is false are skipped
+type Rule struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Entity      string `yaml:"entity"`
+	When        string `yaml:"when,omitempty"`
+	Require     string `yaml:"require"`
+
+	when, require *Expression
+}
+
+// Policy - a set of Rules
+type Policy struct {
+	Rule []*Rule `yaml:"rule"`
+}
+
+// Entity - the payload of a Device, Device Group or Playbook Instance, as seen by a Rule
+type Entity struct {
+	Kind   string
+	ID     string
+	Values map[string]interface{}
+}
+
+// Violation - an entity that does not satisfy a Rule
+type Violation struct {
+	Kind        string `json:"entity"`
+	ID          string `json:"id"`
+	Rule        string `json:"rule"`
+	Description string `json:"description,omitempty"`
+}
+
+// ParsePolicy - reads and compiles a Policy
+func ParsePolicy(data []byte) (*Policy, error) {
+	var policy Policy
+	if err := yaml.UnmarshalStrict(data, &policy); err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for _, rule := range policy.Rule {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rule is missing a name")
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("rule %s is defined more than once", rule.Name)
+		}
+		names[rule.Name] = true
+		switch rule.Entity {
+		case Device, DeviceGroup, PlaybookInstance:
+		default:
+			return nil, fmt.Errorf("rule %s has unknown entity %q, expected %s, %s or %s", rule.Name, rule.Entity, Device, DeviceGroup, PlaybookInstance)
+		}
+		if rule.Require == "" {
+			return nil, fmt.Errorf("rule %s is missing require", rule.Name)
+		}
+		var err error
+		if rule.require, err = Parse(rule.Require); err != nil {
+			return nil, fmt.Errorf("rule %s require: %v", rule.Name, err)
+		}
+		if rule.When != "" {
+			if rule.when, err = Parse(rule.When); err != nil {
+				return nil, fmt.Errorf("rule %s when: %v", rule.Name, err)
+			}
+		}
+	}
+	return &policy, nil
+}
+
+// LoadPolicy - reads and compiles a Policy file
+func LoadPolicy(filename string) (*Policy, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := ParsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("problem with %s %v", filename, err)
+	}
+	return policy, nil
+}
+
+// Evaluate - applies every Rule to the entities, violations are ordered by entity then rule
+func (p *Policy) Evaluate(entities []Entity) ([]Violation, error) {
+	var violations []Violation
+	for _, entity := range entities {
+		for _, rule := range p.Rule {
+			if rule.Entity != entity.Kind {
+				continue
+			}
+			if rule.when != nil {
+				applies, err := rule.when.Test(entity.Values)
+				if err != nil {
+					return nil, fmt.Errorf("rule %s on %s %s: %v", rule.Name, entity.Kind, entity.ID, err)
+				}
+				if !applies {
+					continue
+				}
+			}
+			ok, err := rule.require.Test(entity.Values)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s on %s %s: %v", rule.Name, entity.Kind, entity.ID, err)
+			}
+			if !ok {
+				violations = append(violations, Violation{Kind: entity.Kind, ID: entity.ID, Rule: rule.Name, Description: rule.Description})
+			}
+		}
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Kind != violations[j].Kind {
+			return violations[i].Kind < violations[j].Kind
+		}
+		return violations[i].ID < violations[j].ID
+	})
+	return violations, nil
+}
+
+// Entities - joins the Healthbot payloads into the entities Rules are evaluated against
+//
+// A device carries its facts and the groups it belongs to, a device-group carries its member devices
+// (with their facts) and a playbook-instance carries the device-group-name and group it is part of.
+func Entities(devices, deviceGroups []map[string]interface{}, facts map[string]interface{}) []Entity {
+	var entities []Entity
+	members := map[string][]interface{}{}
+	byID := map[string]map[string]interface{}{}
+	for _, device := range devices {
+		id, _ := device["device-id"].(string)
+		values := copyValues(device)
+		values["facts"] = facts[id]
+		byID[id] = values
+	}
+	for _, group := range deviceGroups {
+		ids, _ := group["devices"].([]interface{})
+		for _, id := range ids {
+			if s, ok := id.(string); ok {
+				members[s] = append(members[s], group)
+			}
+		}
+	}
+	for _, device := range devices {
+		id, _ := device["device-id"].(string)
+		values := copyValues(byID[id])
+		groups := members[id]
+		if groups == nil {
+			groups = []interface{}{}
+		}
+		values["groups"] = groups
+		entities = append(entities, Entity{Kind: Device, ID: id, Values: values})
+	}
+	for _, group := range deviceGroups {
+		name, _ := group["device-group-name"].(string)
+		values := copyValues(group)
+		var groupDevices []interface{}
+		ids, _ := group["devices"].([]interface{})
+		for _, id := range ids {
+			s, _ := id.(string)
+			if device, ok := byID[s]; ok {
+				groupDevices = append(groupDevices, device)
+			} else {
+				groupDevices = append(groupDevices, map[string]interface{}{"device-id": s})
+			}
+		}
+		values["members"] = groupDevices
+		entities = append(entities, Entity{Kind: DeviceGroup, ID: name, Values: values})
+
+		instances, _ := group["variable"].([]interface{})
+		for _, instance := range instances {
+			m, ok := instance.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			values := copyValues(m)
+			values["device-group-name"] = name
+			values["group"] = group
+			id := fmt.Sprintf("%s/%v/%v", name, m["instance-id"], m["rule"])
+			entities = append(entities, Entity{Kind: PlaybookInstance, ID: id, Values: values})
+		}
+	}
+	return entities
+}
+
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(values)+2)
+	for k, v := range values {
+		c[k] = v
+	}
+	return c
+}
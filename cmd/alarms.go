@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/damianoneill/hb/healthbot"
+	"github.com/damianoneill/hb/logging"
+	"github.com/spf13/cobra"
+)
+
+// alarmsCmd represents the alarms command
+var alarmsCmd = &cobra.Command{
+	Use:   "alarms",
+	Short: "List the recent Healthbot alarms.",
+	Long: `Lists the alarms raised by Healthbot, most recent last, coloured by severity.
+
+	The alarms can be filtered by --device, --group, --since and a minimum --severity
+	(critical, major, minor, warning, normal). With --follow, Healthbot is polled and new
+	alarms are printed as they arrive, -o json writes one alarm per line for scripts. With --servers
+	or --all-contexts the alarms of every server are merged, each prefixed with its server.`,
+	Run: func(c *cobra.Command, args []string) {
+		options, err := newAlarmOptions(c)
+		if err == nil {
+			err = alarms(NewServers(c), options)
+		}
+		if err != nil {
+			logging.Fatal(err)
+		}
+	},
+}
+
+// Alarm - an alert raised by a Rule, with the server it was raised on when alarms of several are merged
+type Alarm struct {
+	Server      string `json:"server,omitempty"`
+	ID          string `json:"id"`
+	DeviceID    string `json:"device-id"`
+	DeviceGroup string `json:"device-group"`
+	Severity    string `json:"severity"`
+	Topic       string `json:"topic,omitempty"`
+	Rule        string `json:"rule,omitempty"`
+	Trigger     string `json:"trigger,omitempty"`
+	Message     string `json:"message"`
+	Time        string `json:"time"`
+}
+
+// severities in increasing order
+var severities = []string{"normal", "warning", "minor", "major", "critical"}
+
+var severityColours = map[string]string{
+	"critical": "\033[1;31m",
+	"major":    "\033[31m",
+	"minor":    "\033[33m",
+	"warning":  "\033[36m",
+	"normal":   "\033[32m",
+}
+
+const resetColour = "\033[0m"
+
+func severityRank(severity string) int {
+	for i, s := range severities {
+		if strings.EqualFold(s, severity) {
+			return i
+		}
+	}
+	return -1
+}
+
+type alarmOptions struct {
+	Device   string
+	Group    string
+	Severity string
+	Since    time.Duration
+	Follow   bool
+	Interval time.Duration
+	Output   string
+	Colour   bool
+}
+
+func newAlarmOptions(c *cobra.Command) (options alarmOptions, err error) {
+	flags := c.Flags()
+	options.Device, _ = flags.GetString("device")
+	options.Group, _ = flags.GetString("group")
+	options.Severity, _ = flags.GetString("severity")
+	options.Since, _ = flags.GetDuration("since")
+	options.Follow, _ = flags.GetBool("follow")
+	options.Interval, _ = flags.GetDuration("interval")
+	options.Output, _ = flags.GetString("output")
+	noColour, _ := flags.GetBool("no-color")
+	options.Colour = !noColour && isTerminal(os.Stdout)
+	if options.Severity != "" && severityRank(options.Severity) < 0 {
+		return options, fmt.Errorf("unknown severity %s, expected one of %s", options.Severity, strings.Join(severities, ", "))
+	}
+	if options.Output != "table" && options.Output != "json" {
+		return options, fmt.Errorf("unknown output %s, expected table or json", options.Output)
+	}
+	if options.Follow && options.Interval <= 0 {
+		return options, fmt.Errorf("invalid interval %v, expected a positive duration when following", options.Interval)
+	}
+	return
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// matches - the alarm passes the filters and is at or after the start time
+func (o alarmOptions) matches(alarm Alarm, start time.Time) bool {
+	if o.Device != "" && alarm.DeviceID != o.Device {
+		return false
+	}
+	if o.Group != "" && alarm.DeviceGroup != o.Group {
+		return false
+	}
+	if o.Severity != "" && severityRank(alarm.Severity) < severityRank(o.Severity) {
+		return false
+	}
+	if t, ok := alarm.parsedTime(); ok && t.Before(start) {
+		return false
+	}
+	return true
+}
+
+// parsedTime - when the alarm was raised, false when Healthbot sent a time that can not be parsed
+func (alarm Alarm) parsedTime() (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339Nano, alarm.Time)
+	return t, err == nil
+}
+
+// sortAlarms - oldest first, by the time rather than its text as the offsets can differ
+func sortAlarms(alarms []Alarm) {
+	sort.SliceStable(alarms, func(i, j int) bool {
+		ti, _ := alarms[i].parsedTime()
+		tj, _ := alarms[j].parsedTime()
+		return ti.Before(tj)
+	})
+}
+
+// fetchAlarms - the alarms since the start time that match the options and have not been seen, oldest first
+func fetchAlarms(config Config, options alarmOptions, start time.Time, seen map[string]bool) ([]Alarm, error) {
+	client, err := config.Client()
+	if err != nil {
+		return nil, err
+	}
+	alerts, err := client.Alerts(context.Background(), healthbot.AlertFilter{Since: start, DeviceID: options.Device, DeviceGroup: options.Group})
+	if err != nil {
+		return nil, fmt.Errorf("problem retrieving alarms: %v", err)
+	}
+	var alarms []Alarm
+	for _, alert := range alerts {
+		alarm := Alarm{ID: alert.ID, DeviceID: alert.DeviceID, DeviceGroup: alert.DeviceGroup, Severity: alert.Severity,
+			Topic: alert.Topic, Rule: alert.Rule, Trigger: alert.Trigger, Message: alert.Message, Time: alert.Time}
+		if seen[alarm.ID] || !options.matches(alarm, start) {
+			continue
+		}
+		seen[alarm.ID] = true
+		alarms = append(alarms, alarm)
+	}
+	sortAlarms(alarms)
+	return alarms, nil
+}
+
+// fetchServerAlarms - the new alarms of every server fetched concurrently and merged oldest first, a
+// server that fails is left out so that the others are still reported. The start of each server moves
+// on to the latest alarm seen, so following only asks for the alarms since the last poll
+func fetchServerAlarms(servers []Server, options alarmOptions, starts []time.Time, seen []map[string]bool) ([]Alarm, error) {
+	found := make([][]Alarm, len(servers))
+	errs := parallel(servers, func(i int, server Server) (err error) {
+		found[i], err = fetchAlarms(server.Config, options, starts[i], seen[i])
+		for j := range found[i] {
+			if t, ok := found[i][j].parsedTime(); ok && t.After(starts[i]) {
+				starts[i] = t
+			}
+			if len(servers) > 1 {
+				found[i][j].Server = server.Name
+			}
+		}
+		return
+	})
+	var alarms []Alarm
+	for _, f := range found {
+		alarms = append(alarms, f...)
+	}
+	sortAlarms(alarms)
+	return alarms, failures(servers, errs)
+}
+
+func writeAlarms(w io.Writer, alarms []Alarm, options alarmOptions) {
+	for _, alarm := range alarms {
+		if options.Output == "json" {
+			data, _ := json.Marshal(alarm)
+			fmt.Fprintln(w, string(data))
+			continue
+		}
+		severity := fmt.Sprintf("%-8s", alarm.Severity)
+		if colour, ok := severityColours[strings.ToLower(alarm.Severity)]; ok && options.Colour {
+			severity = colour + severity + resetColour
+		}
+		if alarm.Server != "" {
+			fmt.Fprintf(w, "%-12s  ", alarm.Server)
+		}
+		fmt.Fprintf(w, "%s  %s  %-12s  %-16s  %s\n", alarm.Time, severity, alarm.DeviceID, alarm.DeviceGroup, alarm.Message)
+	}
+}
+
+func alarms(servers []Server, options alarmOptions) error {
+	start := time.Now().Add(-options.Since)
+	starts := make([]time.Time, len(servers))
+	seen := make([]map[string]bool, len(servers))
+	for i := range seen {
+		starts[i] = start
+		seen[i] = map[string]bool{}
+	}
+	found, err := fetchServerAlarms(servers, options, starts, seen)
+	writeAlarms(os.Stdout, found, options)
+	if err != nil || !options.Follow {
+		return err
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	ticker := time.NewTicker(options.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-interrupt:
+			return nil
+		case <-ticker.C:
+			found, err := fetchServerAlarms(servers, options, starts, seen)
+			writeAlarms(os.Stdout, found, options)
+			if err != nil {
+				logging.Warnf("%v", err)
+			}
+		}
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(alarmsCmd)
+
+	alarmsCmd.Flags().String("device", "", "only alarms for the Device")
+	alarmsCmd.Flags().String("group", "", "only alarms for the Device Group")
+	alarmsCmd.Flags().String("severity", "", "minimum severity e.g. major")
+	alarmsCmd.Flags().Duration("since", time.Hour, "how far back to list alarms")
+	alarmsCmd.Flags().BoolP("follow", "f", false, "poll for new alarms until interrupted")
+	alarmsCmd.Flags().Duration("interval", 10*time.Second, "how often to poll when following")
+	alarmsCmd.Flags().StringP("output", "o", "table", "output format table or json")
+	alarmsCmd.Flags().Bool("no-color", false, "disable colouring by severity")
+
+	_ = alarmsCmd.RegisterFlagCompletionFunc("device", completeNames(deviceNames))
+	_ = alarmsCmd.RegisterFlagCompletionFunc("group", completeNames(deviceGroupNames))
+}
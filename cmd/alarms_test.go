@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchAlarms(t *testing.T) {
+	server, config, stop := newTestServer(t)
+	defer stop()
+	now := time.Now().UTC()
+	server.AddAlert(Alarm{ID: "1", DeviceID: "mx960-1", DeviceGroup: "core", Severity: "major", Message: "fan failed", Time: now.Add(-10 * time.Minute).Format(time.RFC3339)})
+	server.AddAlert(Alarm{ID: "2", DeviceID: "mx960-3", DeviceGroup: "core", Severity: "minor", Message: "temperature high", Time: now.Add(-5 * time.Minute).Format(time.RFC3339)})
+	server.AddAlert(Alarm{ID: "3", DeviceID: "mx960-1", DeviceGroup: "core", Severity: "critical", Message: "old", Time: now.Add(-3 * time.Hour).Format(time.RFC3339)})
+
+	start := now.Add(-time.Hour)
+	seen := map[string]bool{}
+	alarms, err := fetchAlarms(config, alarmOptions{}, start, seen)
+	assert.Nil(t, err)
+	assert.Len(t, alarms, 2, "Expected alarms before --since to be dropped")
+	assert.Equal(t, "1", alarms[0].ID, "Expected oldest first")
+
+	alarms, _ = fetchAlarms(config, alarmOptions{}, start, seen)
+	assert.Empty(t, alarms, "Expected seen alarms to not be repeated when following")
+
+	server.AddAlert(Alarm{ID: "4", DeviceID: "mx960-3", DeviceGroup: "core", Severity: "critical", Message: "new", Time: now.Format(time.RFC3339)})
+	alarms, _ = fetchAlarms(config, alarmOptions{}, start, seen)
+	assert.Len(t, alarms, 1, "Expected new alarms when following")
+
+	alarms, _ = fetchAlarms(config, alarmOptions{Severity: "major", Device: "mx960-1"}, start, map[string]bool{})
+	assert.Len(t, alarms, 1, "Expected the severity to be a minimum and the device to filter")
+	assert.Equal(t, "1", alarms[0].ID)
+}
+
+func TestFetchServerAlarmsFollows(t *testing.T) {
+	server, config, stop := newTestServer(t)
+	defer stop()
+	now := time.Now().UTC().Truncate(time.Second)
+	server.AddAlert(Alarm{ID: "1", DeviceID: "mx960-1", Severity: "major", Message: "later", Time: now.Add(-5 * time.Minute).In(time.FixedZone("IST", 19800)).Format(time.RFC3339)})
+	server.AddAlert(Alarm{ID: "2", DeviceID: "mx960-1", Severity: "major", Message: "earlier", Time: now.Add(-10 * time.Minute).Format(time.RFC3339)})
+
+	start := now.Add(-time.Hour)
+	starts := []time.Time{start}
+	alarms, err := fetchServerAlarms([]Server{{Name: "lab", Config: config}}, alarmOptions{}, starts, []map[string]bool{{}})
+	assert.Nil(t, err)
+	assert.Equal(t, "2", alarms[0].ID, "Expected the alarms to be sorted by time, not by its text")
+	assert.True(t, starts[0].Equal(now.Add(-5*time.Minute)), "Expected the window to start at the latest alarm, not %v", starts[0])
+}
+
+func TestWriteAlarms(t *testing.T) {
+	alarms := []Alarm{{ID: "1", DeviceID: "mx960-1", DeviceGroup: "core", Severity: "major", Message: "fan failed", Time: "2019-11-01T18:00:00Z"}}
+	var out bytes.Buffer
+	writeAlarms(&out, alarms, alarmOptions{Output: "table", Colour: true})
+	assert.Contains(t, out.String(), severityColours["major"], "Expected major alarms to be coloured")
+
+	out.Reset()
+	writeAlarms(&out, alarms, alarmOptions{Output: "json"})
+	assert.Equal(t, `{"id":"1","device-id":"mx960-1","device-group":"core","severity":"major","message":"fan failed","time":"2019-11-01T18:00:00Z"}`+"\n", out.String())
+}
+
+func TestNewAlarmOptionsInterval(t *testing.T) {
+	flags := alarmsCmd.Flags()
+	defer func() {
+		_ = flags.Set("follow", "false")
+		_ = flags.Set("interval", "10s")
+	}()
+
+	assert.Nil(t, flags.Set("interval", "0s"))
+	_, err := newAlarmOptions(alarmsCmd)
+	assert.Nil(t, err, "Expected the interval to be ignored when not following")
+
+	assert.Nil(t, flags.Set("follow", "true"))
+	_, err = newAlarmOptions(alarmsCmd)
+	assert.EqualError(t, err, "invalid interval 0s, expected a positive duration when following")
+	assert.Nil(t, flags.Set("interval", "-5s"))
+	_, err = newAlarmOptions(alarmsCmd)
+	assert.NotNil(t, err, "Expected a negative interval to be rejected")
+
+	assert.Nil(t, flags.Set("interval", "5s"))
+	options, err := newAlarmOptions(alarmsCmd)
+	assert.Nil(t, err)
+	assert.Equal(t, 5*time.Second, options.Interval)
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/damianoneill/hb/audit"
+	"github.com/damianoneill/hb/logging"
+	"github.com/spf13/cobra"
+)
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Audit the Healthbot configuration against a compliance policy.",
+	Long: `Evaluates the rules of a policy file against the Devices, Device Groups, Playbook Instances and Device Facts
+	on the Healthbot server, and reports the entities that violate them. Exits non-zero if there are violations.
+
+	rule:
+	- name: mx-runs-chassis-kpis
+	  description: every MX must be in a group running chassis-kpis-playbook
+	  entity: device
+	  when: facts.platform =~ "^MX"
+	  require: any(groups, "chassis-kpis-playbook" in playbooks)
+	- name: no-public-community
+	  entity: device
+	  require: snmp.v2.community != "public"
+	- name: group-notifications
+	  entity: device-group
+	  require: exists(notification)
+
+	Expressions use the field names of the REST API payloads, a device also has facts and groups,
+	a device-group has members, and a playbook-instance has device-group-name and group.`,
+	Run: func(c *cobra.Command, args []string) {
+		filename, _ := c.Flags().GetString("policy")
+		output, _ := c.Flags().GetString("output")
+		policy, err := audit.LoadPolicy(filename)
+		if err != nil {
+			logging.Errorf("%v", err)
+			os.Exit(2)
+		}
+		violations, err := auditConfiguration(NewConfig(c), policy)
+		if err != nil {
+			logging.Errorf("%v", err)
+			os.Exit(2)
+		}
+		if err := writeViolations(os.Stdout, output, violations); err != nil {
+			logging.Errorf("%v", err)
+			os.Exit(2)
+		}
+		if len(violations) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// asJSON - the configuration as json values, as the policies can address any field
+func asJSON(configuration interface{}, v interface{}) error {
+	data, err := json.Marshal(configuration)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// auditConfiguration - evaluates the policy against the configuration on the server
+func auditConfiguration(config Config, policy *audit.Policy) ([]audit.Violation, error) {
+	client, err := config.Client()
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	devices, err := client.Devices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("problem retrieving Devices: %v", err)
+	}
+	deviceGroups, err := client.DeviceGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("problem retrieving Device Groups: %v", err)
+	}
+	deviceFacts, err := client.DeviceFacts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("problem retrieving Device Facts: %v", err)
+	}
+	var entities struct {
+		Devices      []map[string]interface{} `json:"device"`
+		DeviceGroups []map[string]interface{} `json:"device-group"`
+	}
+	if err := asJSON(devices, &entities); err != nil {
+		return nil, err
+	}
+	if err := asJSON(deviceGroups, &entities); err != nil {
+		return nil, err
+	}
+	var factsOf []struct {
+		DeviceID string                 `json:"device-id"`
+		Facts    map[string]interface{} `json:"facts"`
+	}
+	if err := asJSON(deviceFacts, &factsOf); err != nil {
+		return nil, err
+	}
+	facts := map[string]interface{}{}
+	for _, f := range factsOf {
+		facts[f.DeviceID] = f.Facts
+	}
+	return policy.Evaluate(audit.Entities(entities.Devices, entities.DeviceGroups, facts))
+}
+
+func writeViolations(w io.Writer, output string, violations []audit.Violation) error {
+	switch output {
+	case "json":
+		if violations == nil {
+			violations = []audit.Violation{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(violations)
+	case "table":
+		if len(violations) == 0 {
+			fmt.Fprintln(w, "No violations")
+			return nil
+		}
+		table := NewTableWriter(w)
+		table.SetHeader([]string{"Entity", "Id", "Rule", "Description"})
+		table.SetAutoMergeCells(true)
+		entities := map[string]bool{}
+		for _, v := range violations {
+			table.Append([]string{v.Kind, v.ID, v.Rule, v.Description})
+			entities[v.Kind+"/"+v.ID] = true
+		}
+		table.Render()
+		fmt.Fprintf(w, "\n%v violations across %v entities \n", len(violations), len(entities))
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q, expected table or json", output)
+}
+
+func init() {
+	RootCmd.AddCommand(auditCmd)
+
+	auditCmd.Flags().String("policy", "policy.yml", "Policy file")
+	auditCmd.Flags().StringP("output", "o", "table", "Output format, table or json")
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/damianoneill/hb/audit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudit(t *testing.T) {
+	_, config, stop := newTestServer(t)
+	defer stop()
+	policy, err := audit.ParsePolicy([]byte(`
+rule:
+- name: mx-runs-chassis-kpis
+  entity: device
+  when: facts.platform =~ "^MX"
+  require: any(groups, "chassis-kpis-playbook" in playbooks)
+- name: group-has-devices
+  entity: device-group
+  require: len(members) > 0
+`))
+	assert.Nil(t, err)
+
+	violations, err := auditConfiguration(config, policy)
+	assert.Nil(t, err)
+	assert.Equal(t, []audit.Violation{
+		{Kind: audit.Device, ID: "mx960-1", Rule: "mx-runs-chassis-kpis"},
+		{Kind: audit.DeviceGroup, ID: "empty", Rule: "group-has-devices"},
+	}, violations)
+
+	var out bytes.Buffer
+	assert.Nil(t, writeViolations(&out, "table", violations))
+	assert.Contains(t, out.String(), "2 violations across 2 entities")
+
+	out.Reset()
+	assert.Nil(t, writeViolations(&out, "json", nil))
+	var decoded []audit.Violation
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Empty(t, decoded)
+}
@@ -2,29 +2,46 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"time"
 
+	"github.com/damianoneill/hb/logging"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var completionTarget string
 
 // completionCmd represents the completion command
 var completionCmd = &cobra.Command{
-	Use:   "completion",
-	Short: "Generate shell completion script for " + RootCmd.Use,
-	Long: `Generates a shell completion script for ` + RootCmd.Use + `.
-	NOTE: The current version supports Bash only.
-		  This should work for *nix systems with Bash installed.
+	Use:       "completion [bash|zsh|fish|powershell]",
+	Short:     "Generate shell completion script for " + RootCmd.Use,
+	ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+	Args:      cobra.MaximumNArgs(1),
+	Long: `Generates a shell completion script for ` + RootCmd.Use + ` for Bash, Zsh, Fish or PowerShell.
 
-	By default, the file is written directly to /etc/bash_completion.d
+	Device Ids, Device Group names, Playbook names, Playbook instances and checkpoints are
+	completed with the names on the Healthbot in the config file or --resource. The names are
+	cached for completion-cache-ttl (default 1m) in the user cache directory, set it to 0 in
+	the config file to always ask Healthbot.
+
+	With a shell the script is written to stdout, e.g.
+
+		$ source <(` + RootCmd.Use + ` completion bash)
+		$ ` + RootCmd.Use + ` completion zsh > "${fpath[1]}/_` + RootCmd.Use + `"
+		$ ` + RootCmd.Use + ` completion fish > ~/.config/fish/completions/` + RootCmd.Use + `.fish
+		PS> ` + RootCmd.Use + ` completion powershell | Out-String | Invoke-Expression
+
+	Without a shell the Bash script is written directly to /etc/bash_completion.d
 	for convenience, and the command may need superuser rights, e.g.:
 
 		$ sudo ` + RootCmd.Use + ` completion
-	
+
 	Add ` + "`--completionfile=/path/to/file`" + ` flag to set alternative
 	file-path and name.
 
-	For e.g. on OSX with bash completion installed with brew you should 
+	For e.g. on OSX with bash completion installed with brew you should
 
 	$ ` + RootCmd.Use + ` completion --completionfile $(brew --prefix)/etc/bash_completion.d/` + RootCmd.Use + `.sh
 
@@ -32,9 +49,9 @@ var completionCmd = &cobra.Command{
 	or just source them directly:
 
 		$ . /etc/bash_completion
-		
+
 	or using if using brew
-	
+
 		$ . $(brew --prefix)/etc/bash_completion`,
 
 	Run: Completion,
@@ -43,17 +60,134 @@ var completionCmd = &cobra.Command{
 // Completion is a helper function to allow passing arguments to
 // other functions (so that they can be unit tested)
 func Completion(cmd *cobra.Command, args []string) {
-	err := cmd.Root().GenBashCompletionFile(completionTarget)
+	if len(args) == 0 {
+		err := cmd.Root().GenBashCompletionFile(completionTarget)
+		completion(err, args...)
+		return
+	}
+	if !cmd.Flags().Changed("completionfile") {
+		if err := genCompletion(cmd.Root(), args[0], os.Stdout); err != nil {
+			logging.Fatal(err)
+		}
+		return
+	}
+	f, err := os.Create(completionTarget)
+	if err == nil {
+		err = genCompletion(cmd.Root(), args[0], f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
 	completion(err, args...)
 }
 
 func completion(err error, args ...string) {
 	if err != nil {
-		fmt.Println(err)
+		logging.Errorf("%v", err)
 		return
 	}
-	fmt.Println("Bash completion file for "+RootCmd.Use+" saved to", completionTarget)
+	shell := "Bash"
+	if len(args) > 0 {
+		shell = args[0]
+	}
+	logging.Infof("%s completion file for %s saved to %s", shell, RootCmd.Use, completionTarget)
+}
+
+// genCompletion - writes the completion script for the shell, every script asks the hidden
+// __complete command for the completions so the names can come from Healthbot
+func genCompletion(root *cobra.Command, shell string, w io.Writer) error {
+	switch shell {
+	case "bash":
+		return root.GenBashCompletion(w)
+	case "fish":
+		return root.GenFishCompletion(w, true)
+	case "zsh":
+		_, err := fmt.Fprintf(w, zshCompletion, root.Name())
+		return err
+	case "powershell":
+		_, err := fmt.Fprintf(w, powerShellCompletion, root.Name())
+		return err
+	default:
+		return fmt.Errorf("unknown shell %s, expected bash, zsh, fish or powershell", shell)
+	}
+}
+
+// zshCompletion - the directive on the last line is a bit set, 1 error, 2 no space, 4 no file completion
+const zshCompletion = `#compdef %[1]s
+
+_%[1]s() {
+    local -a lines completions
+    local line directive
+    lines=("${(@f)$(${words[1]} __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    directive=${lines[-1]#:}
+    lines=("${(@)lines[1,-2]}")
+    (( directive & 1 )) && return 1
+
+    for line in $lines; do
+        [[ -z $line ]] && continue
+        if [[ $line == *$'\t'* ]]; then
+            completions+=("${${line%%$'\t'*}//:/\\:}:${line#*$'\t'}")
+        else
+            completions+=("${line//:/\\:}")
+        fi
+    done
+
+    if (( ${#completions} == 0 )); then
+        (( directive & 4 )) && return 1
+        _files
+        return
+    fi
+    if (( directive & 2 )); then
+        _describe 'completions' completions -S ''
+    else
+        _describe 'completions' completions
+    fi
+}
+
+if [ "$funcstack[1]" = "_%[1]s" ]; then
+    _%[1]s "$@"
+else
+    compdef _%[1]s %[1]s
+fi
+`
+
+// powerShellCompletion - the words are passed to __complete as arguments, so nothing typed is evaluated
+const powerShellCompletion = `# powershell completion for %[1]s
+
+Register-ArgumentCompleter -Native -CommandName '%[1]s' -ScriptBlock {
+    param($WordToComplete, $CommandAst, $CursorPosition)
+
+    $Words = @($CommandAst.CommandElements | Where-Object { $_.Extent.StartOffset -lt $CursorPosition } | ForEach-Object {
+        if ($_ -is [System.Management.Automation.Language.StringConstantExpressionAst]) { $_.Value } else { $_.ToString() }
+    })
+    $Program = $Words[0]
+    $Arguments = @('__complete') + @($Words | Select-Object -Skip 1)
+    if ($WordToComplete -eq '') {
+        # an empty argument so the next word is completed rather than the last one,
+        # Legacy argument passing drops an empty string so it has to be quoted
+        if ((Get-Variable PSNativeCommandArgumentPassing -ValueOnly -ErrorAction SilentlyContinue) -in @($null, 'Legacy')) {
+            $Arguments += '""'
+        } else {
+            $Arguments += ''
+        }
+    }
+    $Out = @(& $Program @Arguments 2>$null)
+    if ($Out.Count -eq 0) { return }
+
+    $Directive = 0
+    if ($Out[-1] -match '^:(\d+)$') {
+        $Directive = [int]$Matches[1]
+        $Out = @($Out | Select-Object -First ($Out.Count - 1))
+    }
+    if ($Directive -band 1) { return }
+
+    $Out | Where-Object { $_ -like "$WordToComplete*" } | ForEach-Object {
+        $Value, $Description = $_ -split [char]9, 2
+        if (-not $Description) { $Description = $Value }
+        [System.Management.Automation.CompletionResult]::new($Value, $Value, 'ParameterValue', $Description)
+    }
 }
+`
 
 func init() {
 	RootCmd.AddCommand(completionCmd)
@@ -61,4 +195,6 @@ func init() {
 	completionCmd.PersistentFlags().StringVarP(&completionTarget, "completionfile", "", "/etc/bash_completion.d/"+RootCmd.Use+".sh", "completion file")
 	// Required for bash-completion
 	_ = completionCmd.PersistentFlags().SetAnnotation("completionfile", cobra.BashCompFilenameExt, []string{}) // nolint : gosec
+
+	viper.SetDefault("completion-cache-ttl", time.Minute)
 }
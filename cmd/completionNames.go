@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// nameSource - a kind of name offered by shell completion and how to fetch them from Healthbot
+type nameSource struct {
+	Kind  string
+	Fetch func(config Config) ([]string, error)
+}
+
+var (
+	deviceNames           = nameSource{"devices", fetchDeviceNames}
+	deviceGroupNames      = nameSource{"device-groups", fetchDeviceGroupNames}
+	playbookNames         = nameSource{"playbooks", fetchPlaybookNames}
+	playbookInstanceNames = nameSource{"playbook-instances", fetchPlaybookInstanceNames}
+	checkpointNames       = nameSource{"checkpoints", fetchCheckpointNames}
+)
+
+// cachedNames - the on disk cache of the names fetched for a kind
+type cachedNames struct {
+	Names   []string  `json:"names"`
+	Fetched time.Time `json:"fetched"`
+}
+
+// uniqueNames - the names sorted and without duplicates or empty names
+func uniqueNames(names []string) []string {
+	unique := map[string]bool{}
+	for _, name := range names {
+		if name != "" {
+			unique[name] = true
+		}
+	}
+	sorted := make([]string, 0, len(unique))
+	for name := range unique {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func fetchDeviceNames(config Config) ([]string, error) {
+	client, err := config.Client()
+	if err != nil {
+		return nil, err
+	}
+	devices, err := client.Devices(context.Background())
+	var names []string
+	for _, d := range devices.Device {
+		names = append(names, d.DeviceID)
+	}
+	return uniqueNames(names), err
+}
+
+func fetchDeviceGroupNames(config Config) ([]string, error) {
+	client, err := config.Client()
+	if err != nil {
+		return nil, err
+	}
+	deviceGroups, err := client.DeviceGroups(context.Background())
+	var names []string
+	for _, dg := range deviceGroups.DeviceGroup {
+		names = append(names, dg.DeviceGroupName)
+	}
+	return uniqueNames(names), err
+}
+
+func fetchPlaybookNames(config Config) ([]string, error) {
+	client, err := config.Client()
+	if err != nil {
+		return nil, err
+	}
+	playbooks, err := client.Playbooks(context.Background())
+	var names []string
+	for _, p := range playbooks.Playbooks {
+		names = append(names, p.PlayBookName)
+	}
+	return uniqueNames(names), err
+}
+
+// fetchPlaybookInstanceNames - the instance ids of the Playbooks applied to every Device Group
+func fetchPlaybookInstanceNames(config Config) ([]string, error) {
+	client, err := config.Client()
+	if err != nil {
+		return nil, err
+	}
+	playbookInstances, err := client.PlaybookInstances(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, dg := range playbookInstances.DeviceGroup {
+		for _, v := range dg.Variable {
+			names = append(names, v.InstanceID)
+		}
+	}
+	return uniqueNames(names), nil
+}
+
+func fetchCheckpointNames(config Config) ([]string, error) {
+	checkpoints, err := getCheckpoints(config)
+	names := make([]string, 0, len(checkpoints))
+	for i := len(checkpoints) - 1; i >= 0; i-- {
+		names = append(names, checkpoints[i].Name)
+	}
+	return names, err
+}
+
+// namesCacheFile - the cache is kept per Healthbot and user, so switching --resource never offers stale names
+func namesCacheFile(dir string, config Config, source nameSource) string {
+	sum := sha256.Sum256([]byte(config.Resource + "\x00" + config.Username))
+	return filepath.Join(dir, fmt.Sprintf("%s-%x.json", source.Kind, sum[:8]))
+}
+
+// names - the names for the source, from the cache in dir if they were fetched within the ttl
+func names(config Config, source nameSource, dir string, ttl time.Duration) ([]string, error) {
+	filename := namesCacheFile(dir, config, source)
+	if data, err := ioutil.ReadFile(filename); err == nil && ttl > 0 {
+		var cached cachedNames
+		if json.Unmarshal(data, &cached) == nil && time.Since(cached.Fetched) < ttl {
+			return cached.Names, nil
+		}
+	}
+	fetched, err := source.Fetch(config)
+	if err != nil {
+		return nil, err
+	}
+	// a cache that can not be written only makes completion slower
+	if data, err := json.Marshal(cachedNames{Names: fetched, Fetched: time.Now()}); err == nil {
+		if os.MkdirAll(dir, 0700) == nil {
+			_ = ioutil.WriteFile(filename, data, 0600)
+		}
+	}
+	return fetched, nil
+}
+
+// completionCacheDir - where the names are cached, e.g. ~/.cache/hb/completion
+func completionCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, RootCmd.Use, "completion"), nil
+}
+
+// completeNames - completes a flag with the names from Healthbot that start with what has been typed
+func completeNames(source nameSource) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return completeNamesOn(source, "")
+}
+
+// CompleteDeviceNames - completes a flag with the Device Ids on the Healthbot named by the server flag e.g. --from,
+// or the configured Healthbot when the server flag is not set
+func CompleteDeviceNames(serverFlag string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return completeNamesOn(deviceNames, serverFlag)
+}
+
+// CompleteDeviceGroupNames - completes a flag with the Device Group names on the Healthbot named by the server flag
+func CompleteDeviceGroupNames(serverFlag string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return completeNamesOn(deviceGroupNames, serverFlag)
+}
+
+// CompleteContextNames - completes a flag with the names of the contexts in the config file
+func CompleteContextNames(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	contexts, err := serverContexts()
+	if err != nil {
+		cobra.CompErrorln(err.Error())
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var matches []string
+	for name := range contexts {
+		if strings.HasPrefix(name, toComplete) {
+			matches = append(matches, name)
+		}
+	}
+	sort.Strings(matches)
+	return matches, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completionConfig - the configured Healthbot, or the context or resource in the server flag when it is set
+func completionConfig(c *cobra.Command, serverFlag string) (Config, error) {
+	config := newConfig(c)
+	if serverFlag == "" {
+		return config, nil
+	}
+	name, _ := c.Flags().GetString(serverFlag)
+	if name == "" {
+		return config, nil
+	}
+	servers, err := selectServers(config, []string{name}, false)
+	if err != nil || len(servers) == 0 {
+		return config, err
+	}
+	return servers[0].Config, nil
+}
+
+func completeNamesOn(source nameSource, serverFlag string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		dir, err := completionCacheDir()
+		if err != nil {
+			cobra.CompErrorln(err.Error())
+			return nil, cobra.ShellCompDirectiveError
+		}
+		// completion can not prompt, the password must be configured or come from the credential helper
+		config, err := completionConfig(c, serverFlag)
+		if err == nil {
+			config.Password, err = lookupPassword(config, false)
+		}
+		var all []string
+		if err == nil {
+			all, err = names(config, source, dir, viper.GetDuration("completion-cache-ttl"))
+		}
+		if err != nil {
+			cobra.CompErrorln(err.Error())
+			return nil, cobra.ShellCompDirectiveError
+		}
+		var matches []string
+		for _, name := range all {
+			if strings.HasPrefix(name, toComplete) {
+				matches = append(matches, name)
+			}
+		}
+		return matches, cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/damianoneill/hb/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNames(t *testing.T) {
+	server, config, stop := newTestServer(t)
+	defer stop()
+	dir, err := ioutil.TempDir("", "completion")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	devices, err := names(config, deviceNames, dir, time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mx960-1", "mx960-3"}, devices)
+	groups, err := names(config, deviceGroupNames, dir, time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"core", "empty"}, groups)
+
+	assert.Nil(t, server.Seed(&types.Devices{Device: []types.Device{{DeviceID: "mx960-5", Host: "10.0.0.5"}}}))
+	devices, _ = names(config, deviceNames, dir, time.Minute)
+	assert.Equal(t, []string{"mx960-1", "mx960-3"}, devices, "Expected the cached names within the ttl")
+	devices, _ = names(config, deviceNames, dir, 0)
+	assert.Equal(t, []string{"mx960-1", "mx960-3", "mx960-5"}, devices, "Expected a ttl of 0 to skip the cache")
+
+	other := config
+	other.Username = "operator"
+	assert.NotEqual(t, namesCacheFile(dir, config, deviceNames), namesCacheFile(dir, other, deviceNames), "Expected a cache per user")
+
+	var instances types.PlaybookInstances
+	assert.Nil(t, instances.Parse([]byte(`
+device-group:
+  - device-group-name: edge
+    playbooks: [system-kpis]
+    variable:
+      - instance-id: edge-cpu
+        playbook: system-kpis
+        rule: system.cpu/check-cpu
+`)))
+	assert.Nil(t, server.Seed(&instances))
+	found, err := names(config, playbookInstanceNames, dir, time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"edge-cpu"}, found)
+
+	config.Resource = "localhost:1"
+	_, err = names(config, deviceNames, dir, 0)
+	assert.NotNil(t, err, "Expected an unreachable Healthbot to fail completion")
+}
+
+func TestGenCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		var out bytes.Buffer
+		assert.Nil(t, genCompletion(RootCmd, shell, &out))
+		assert.Contains(t, out.String(), "__complete", "Expected the %s script to ask hb for completions", shell)
+	}
+	var out bytes.Buffer
+	assert.Nil(t, genCompletion(RootCmd, "powershell", &out))
+	assert.NotContains(t, out.String(), "Invoke-Expression", "Expected the words to be passed as arguments, not evaluated")
+	assert.Contains(t, out.String(), "& $Program @Arguments")
+	out.Reset()
+	assert.NotNil(t, genCompletion(RootCmd, "tcsh", &out))
+}
+
+func TestCompleteOnServer(t *testing.T) {
+	_, config, stop := newTestServer(t)
+	defer stop()
+	viper.Set("contexts", map[string]interface{}{
+		"lab":  map[string]interface{}{"resource": config.Resource, "username": config.Username, "password": config.Password},
+		"prod": map[string]interface{}{"resource": "localhost:1"},
+	})
+	defer viper.Set("contexts", nil)
+
+	c := &cobra.Command{Use: "migrate"}
+	c.Flags().String("from", "", "")
+	names, directive := CompleteContextNames(c, nil, "")
+	assert.Equal(t, []string{"lab", "prod"}, names)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+
+	assert.Nil(t, c.Flags().Set("from", "lab"))
+	server, err := completionConfig(c, "from")
+	assert.Nil(t, err)
+	assert.Equal(t, config.Resource, server.Resource, "Expected the names to come from the --from Healthbot")
+	unset, err := completionConfig(c, "")
+	assert.Nil(t, err)
+	assert.NotEqual(t, config.Resource, unset.Resource)
+}
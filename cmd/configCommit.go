@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/damianoneill/hb/logging"
+	"github.com/spf13/cobra"
+)
+
+// configCommitCmd represents the config-commit command
+var configCommitCmd = &cobra.Command{
+	Use:   "config-commit",
+	Short: "Commit the candidate configuration.",
+	Long: `Commits the uncommitted changes in the Healthbot candidate configuration and waits for the commit job to finish.
+	Exits non-zero if the commit fails or does not finish within --timeout.`,
+	Run: func(c *cobra.Command, args []string) {
+		if err := CommitConfiguration(NewConfig(c), jobOptions(c)); err != nil {
+			logging.Fatal(err)
+		}
+		logging.Infof("Successfully committed configuration")
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(configCommitCmd)
+
+	addJobFlags(configCommitCmd)
+}
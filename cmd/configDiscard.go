@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/damianoneill/hb/logging"
+	"github.com/spf13/cobra"
+)
+
+// configDiscardCmd represents the config-discard command
+var configDiscardCmd = &cobra.Command{
+	Use:   "config-discard",
+	Short: "Discard the uncommitted changes in the candidate configuration.",
+	Long:  `Reverts the Healthbot candidate configuration to the running configuration, see config-show-candidate for what would be lost.`,
+	Run: func(c *cobra.Command, args []string) {
+		if yes, _ := c.Flags().GetBool("yes"); !yes {
+			if !AskForConfirmation("discard the uncommitted configuration?", 3, os.Stdin) {
+				return
+			}
+		}
+		if err := DiscardConfiguration(NewConfig(c)); err != nil {
+			logging.Fatal(err)
+		}
+		logging.Infof("Successfully discarded candidate configuration")
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(configDiscardCmd)
+
+	configDiscardCmd.Flags().BoolP("yes", "y", false, "Do not ask for confirmation")
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/damianoneill/hb/healthbot"
+	"github.com/damianoneill/hb/logging"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// configShowCandidateCmd represents the config-show-candidate command
+var configShowCandidateCmd = &cobra.Command{
+	Use:   "config-show-candidate",
+	Short: "Show the uncommitted changes in the candidate configuration.",
+	Long: `Compares the Healthbot candidate configuration with the running configuration and lists the entities
+	that would be added, modified or deleted by config-commit. With -o yaml the candidate payloads are shown too.`,
+	Run: func(c *cobra.Command, args []string) {
+		output, _ := c.Flags().GetString("output")
+		changes, err := candidateChanges(NewConfig(c))
+		if err != nil {
+			logging.Fatal(err)
+		}
+		if err := writeChanges(os.Stdout, output, changes); err != nil {
+			logging.Fatal(err)
+		}
+	},
+}
+
+// configurationKind - a part of the configuration, a list of named entities or a single document when listKey is empty
+type configurationKind struct {
+	kind    string
+	listKey string
+	idKey   string
+	read    func(ctx context.Context, client *healthbot.Client) (interface{}, error)
+}
+
+var configurationKinds = []configurationKind{
+	{"device", "device", "device-id", func(ctx context.Context, c *healthbot.Client) (interface{}, error) { return c.Devices(ctx) }},
+	{"device-group", "device-group", "device-group-name", func(ctx context.Context, c *healthbot.Client) (interface{}, error) { return c.DeviceGroups(ctx) }},
+	{"network-group", "network-group", "network-group-name", func(ctx context.Context, c *healthbot.Client) (interface{}, error) { return c.NetworkGroups(ctx) }},
+	{"playbook", "playbooks", "playbook-name", func(ctx context.Context, c *healthbot.Client) (interface{}, error) { return c.Playbooks(ctx) }},
+	{"retention-policy", "retention-policy", "retention-policy-name", func(ctx context.Context, c *healthbot.Client) (interface{}, error) { return c.RetentionPolicies(ctx) }},
+	{"topic", "topic", "topic-name", func(ctx context.Context, c *healthbot.Client) (interface{}, error) { return c.Topics(ctx) }},
+	{"scheduler", "scheduler", "name", func(ctx context.Context, c *healthbot.Client) (interface{}, error) { return c.Schedulers(ctx) }},
+	{"destination", "destination", "name", func(ctx context.Context, c *healthbot.Client) (interface{}, error) { return c.Destinations(ctx) }},
+	{"report", "report", "name", func(ctx context.Context, c *healthbot.Client) (interface{}, error) { return c.Reports(ctx) }},
+	{"frequency-profile", "frequency-profile", "name", func(ctx context.Context, c *healthbot.Client) (interface{}, error) { return c.FrequencyProfiles(ctx) }},
+	{"syslog", "", "", func(ctx context.Context, c *healthbot.Client) (interface{}, error) { return c.Syslog(ctx) }},
+	{"snmp-notification", "", "", func(ctx context.Context, c *healthbot.Client) (interface{}, error) { return c.SnmpNotification(ctx) }},
+}
+
+// Change - an entity that differs between the running and candidate configuration
+type Change struct {
+	Kind      string      `json:"kind" yaml:"kind"`
+	Name      string      `json:"name" yaml:"name"`
+	Change    string      `json:"change" yaml:"change"`
+	Candidate interface{} `json:"candidate,omitempty" yaml:"candidate,omitempty"`
+}
+
+// getEntities - the entities of a kind by name, from the candidate configuration for a Working client
+func getEntities(client *healthbot.Client, k configurationKind) (map[string]interface{}, error) {
+	configuration, err := k.read(context.Background(), client)
+	if healthbot.IsNotFound(err) {
+		return map[string]interface{}{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("problem retrieving %s: %v", k.kind, err)
+	}
+	// compared as json, so that the fields the types do not model are compared too
+	var body map[string]interface{}
+	if err := asJSON(configuration, &body); err != nil {
+		return nil, err
+	}
+	entities := map[string]interface{}{}
+	if k.listKey == "" {
+		if len(body) > 0 {
+			entities[k.kind] = body
+		}
+		return entities, nil
+	}
+	items, _ := body[k.listKey].([]interface{})
+	for _, item := range items {
+		if e, ok := item.(map[string]interface{}); ok {
+			entities[fmt.Sprintf("%v", e[k.idKey])] = e
+		}
+	}
+	return entities, nil
+}
+
+// candidateChanges - the differences between the running and candidate configuration, by kind then name
+func candidateChanges(config Config) ([]Change, error) {
+	client, err := config.Client()
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	for _, k := range configurationKinds {
+		running, err := getEntities(client, k)
+		if err != nil {
+			return nil, err
+		}
+		candidate, err := getEntities(client.Working(), k)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for name := range running {
+			names = append(names, name)
+		}
+		for name := range candidate {
+			if _, ok := running[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			r, inRunning := running[name]
+			c, inCandidate := candidate[name]
+			switch {
+			case !inRunning:
+				changes = append(changes, Change{Kind: k.kind, Name: name, Change: "added", Candidate: c})
+			case !inCandidate:
+				changes = append(changes, Change{Kind: k.kind, Name: name, Change: "deleted"})
+			case !reflect.DeepEqual(r, c):
+				changes = append(changes, Change{Kind: k.kind, Name: name, Change: "modified", Candidate: c})
+			}
+		}
+	}
+	return changes, nil
+}
+
+func writeChanges(w io.Writer, output string, changes []Change) error {
+	switch output {
+	case "table":
+		if len(changes) == 0 {
+			fmt.Fprintln(w, "No uncommitted changes")
+			return nil
+		}
+		table := NewTableWriter(w)
+		table.SetHeader([]string{"Kind", "Name", "Change"})
+		for _, c := range changes {
+			table.Append([]string{c.Kind, c.Name, c.Change})
+		}
+		table.Render()
+		return nil
+	case "yaml":
+		if changes == nil {
+			changes = []Change{}
+		}
+		data, err := yaml.Marshal(changes)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	return fmt.Errorf("unknown output format %q, expected table or yaml", output)
+}
+
+func init() {
+	RootCmd.AddCommand(configShowCandidateCmd)
+
+	configShowCandidateCmd.Flags().StringP("output", "o", "table", "Output format, table or yaml")
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/damianoneill/hb/healthbot"
+	"github.com/spf13/cobra"
+)
+
+// JobOptions - how long to wait for a configuration job and how often to poll it
+type JobOptions = healthbot.JobOptions
+
+// DefaultJobOptions - used by the commands that commit implicitly
+var DefaultJobOptions = healthbot.DefaultJobOptions
+
+// CommitConfiguration - commits the candidate configuration and waits for the commit job to finish
+func CommitConfiguration(config Config, options JobOptions) error {
+	client, err := config.Client()
+	if err != nil {
+		return err
+	}
+	return client.Commit(context.Background(), options)
+}
+
+// DiscardConfiguration - throws away the uncommitted changes in the candidate configuration
+func DiscardConfiguration(config Config) error {
+	client, err := config.Client()
+	if err != nil {
+		return err
+	}
+	return client.Discard(context.Background())
+}
+
+// addJobFlags - the flags of the commands that wait for a configuration job
+func addJobFlags(c *cobra.Command) {
+	c.Flags().Duration("timeout", DefaultJobOptions.Timeout, "How long to wait for the configuration job to finish")
+	c.Flags().Duration("interval", DefaultJobOptions.Interval, "How often to poll the configuration job")
+}
+
+func jobOptions(c *cobra.Command) JobOptions {
+	timeout, _ := c.Flags().GetDuration("timeout")
+	interval, _ := c.Flags().GetDuration("interval")
+	return JobOptions{Timeout: timeout, Interval: interval}
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/damianoneill/hb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var testJobOptions = JobOptions{Timeout: time.Second, Interval: time.Millisecond}
+
+func TestCommitAndShowCandidate(t *testing.T) {
+	server, config, stop := newTestServer(t)
+	defer stop()
+
+	changes, err := candidateChanges(config)
+	assert.Nil(t, err)
+	assert.Empty(t, changes, "Expected the seeded configuration to be committed")
+
+	resp, err := POST(types.Devices{Device: []types.Device{{DeviceID: "mx960-5", Host: "10.0.0.5"}}}, config.Resource, "/api/v1/devices/", config.Username, config.Password)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, resp.StatusCode())
+	_, err = DELETE(config.Resource, "/api/v1/device-group/empty/", config.Username, config.Password)
+	assert.Nil(t, err)
+
+	changes, err = candidateChanges(config)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, Change{Kind: "device", Name: "mx960-5", Change: "added", Candidate: map[string]interface{}{"device-id": "mx960-5", "host": "10.0.0.5"}}, changes[0])
+	assert.Equal(t, Change{Kind: "device-group", Name: "empty", Change: "deleted"}, changes[1])
+
+	var out bytes.Buffer
+	assert.Nil(t, writeChanges(&out, "table", changes))
+	assert.Contains(t, out.String(), "mx960-5")
+
+	server.SetJobPolls(2)
+	assert.Nil(t, CommitConfiguration(config, testJobOptions))
+	assert.Equal(t, 1, server.Commits())
+	assert.False(t, server.Uncommitted())
+}
+
+func TestCommitFailure(t *testing.T) {
+	server, config, stop := newTestServer(t)
+	defer stop()
+	server.FailJobs("device mx960-5 is unreachable")
+	err := CommitConfiguration(config, testJobOptions)
+	assert.EqualError(t, err, "configuration job job-1 failed: device mx960-5 is unreachable")
+
+	server.FailJobs("")
+	server.SetJobPolls(1000)
+	err = CommitConfiguration(config, JobOptions{Timeout: 10 * time.Millisecond, Interval: time.Millisecond})
+	assert.Contains(t, err.Error(), "is still in-progress")
+}
+
+func TestDiscardAndRollback(t *testing.T) {
+	server, config, stop := newTestServer(t)
+	defer stop()
+
+	_, err := rollback(config, "", testJobOptions)
+	assert.NotNil(t, err, "Expected no checkpoint to roll back to")
+
+	_, _ = POST(types.Devices{Device: []types.Device{{DeviceID: "mx960-5", Host: "10.0.0.5"}}}, config.Resource, "/api/v1/devices/", config.Username, config.Password)
+	assert.True(t, server.Uncommitted())
+	assert.Nil(t, DiscardConfiguration(config))
+	assert.False(t, server.Uncommitted())
+
+	assert.Nil(t, CommitConfiguration(config, testJobOptions))
+	_, _ = POST(types.Devices{Device: []types.Device{{DeviceID: "mx960-5", Host: "10.0.0.5"}}}, config.Resource, "/api/v1/devices/", config.Username, config.Password)
+	assert.Nil(t, CommitConfiguration(config, testJobOptions))
+	assert.Equal(t, []string{"mx960-1", "mx960-3", "mx960-5"}, server.Names("device"))
+
+	checkpoint, err := rollback(config, "", testJobOptions)
+	assert.Nil(t, err)
+	assert.Equal(t, "checkpoint-1", checkpoint)
+	assert.Equal(t, []string{"mx960-1", "mx960-3"}, server.Names("device"))
+
+	_, err = rollback(config, "checkpoint-9", testJobOptions)
+	assert.Contains(t, err.Error(), "checkpoint checkpoint-9 not found")
+
+	checkpoints, err := getCheckpoints(config)
+	assert.Nil(t, err)
+	assert.Len(t, checkpoints, 3)
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/damianoneill/hb/credential"
+	"github.com/damianoneill/hb/logging"
+	"github.com/damianoneill/hb/terminal"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// credentialCmd represents the credential command
+var credentialCmd = &cobra.Command{
+	Use:   "credential",
+	Short: "Store or erase the Healthbot password with the credential helper.",
+	Long: `Keeps the Healthbot password out of the config file and the command line, where -p shows up in ps.
+
+	When no password is configured hb asks the credential-helper named in the config file or
+	--credential-helper, and failing that prompts for the password on the terminal. The helpers are:
+
+		store      an encrypted file, credential-store in the config file (default ~/.hb-credentials),
+		           the passphrase is read from HB_CREDENTIAL_PASSPHRASE or prompted for
+		prompt     asks for the password every time
+		<command>  an executable, or hb-credential-<command> on the PATH, run with get, store or erase
+		           and given resource=<resource> and username=<username> lines on stdin, it writes
+		           password=<password> to stdout
+
+	e.g. to keep the password in the encrypted file
+
+		$ hb --credential-helper store -r hb-server:8080 credential store`,
+}
+
+// credentialStoreCmd represents the credential store command
+var credentialStoreCmd = &cobra.Command{
+	Use:   "store",
+	Short: "Prompt for the password of the resource and username and give it to the credential helper.",
+	Run: func(c *cobra.Command, args []string) {
+		request := credentialRequest(newConfig(c))
+		helper, err := credentialHelper()
+		if err == nil {
+			var password string
+			if password, err = promptSecret(fmt.Sprintf("Password for %s: ", request)); err == nil {
+				err = helper.Store(request, password)
+			}
+		}
+		if err != nil {
+			logging.Fatal(err)
+		}
+		logging.Infof("Successfully stored the password for %s", request)
+	},
+}
+
+// credentialEraseCmd represents the credential erase command
+var credentialEraseCmd = &cobra.Command{
+	Use:   "erase",
+	Short: "Ask the credential helper to forget the password of the resource and username.",
+	Run: func(c *cobra.Command, args []string) {
+		request := credentialRequest(newConfig(c))
+		helper, err := credentialHelper()
+		if err == nil {
+			err = helper.Erase(request)
+		}
+		if err != nil {
+			logging.Fatal(err)
+		}
+		logging.Infof("Successfully erased the password for %s", request)
+	},
+}
+
+// passwords - found during this run, so that nothing is asked for twice
+var passwords = map[credential.Request]string{}
+
+func credentialRequest(config Config) credential.Request {
+	return credential.Request{Resource: config.Resource, Username: config.Username}
+}
+
+// credentialHelper - the helper named by credential-helper, the encrypted store when none is set
+func credentialHelper() (credential.Helper, error) {
+	name := viper.GetString("credential-helper")
+	if name == "" {
+		name = credential.StoreHelper
+	}
+	return newCredentialHelper(name, true)
+}
+
+func newCredentialHelper(name string, interactive bool) (credential.Helper, error) {
+	storeFile := viper.GetString("credential-store")
+	if storeFile == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return nil, err
+		}
+		storeFile = filepath.Join(home, ".hb-credentials")
+	}
+	options := credential.Options{
+		StoreFile: storeFile,
+		Passphrase: func() (string, error) {
+			if passphrase, ok := os.LookupEnv("HB_CREDENTIAL_PASSPHRASE"); ok {
+				return passphrase, nil
+			}
+			if !interactive {
+				return "", errors.New("set HB_CREDENTIAL_PASSPHRASE to read the credential store")
+			}
+			return promptSecret(fmt.Sprintf("Passphrase for %s: ", storeFile))
+		},
+	}
+	if interactive {
+		options.Prompt = promptSecret
+	}
+	return credential.New(name, options)
+}
+
+// promptSecret - asks on stderr and reads the answer from the terminal without echoing it
+func promptSecret(prompt string) (string, error) {
+	if !isTerminal(os.Stdin) {
+		return "", errors.New("a terminal is required to prompt for a password")
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+	return terminal.ReadPassword(os.Stdin)
+}
+
+// lookupPassword - the password from -p or the config file, otherwise from the credential-helper,
+// otherwise prompted for when interactive
+func lookupPassword(config Config, interactive bool) (string, error) {
+	if config.Password != "" || replayFile != "" {
+		return config.Password, nil
+	}
+	request := credentialRequest(config)
+	if password, ok := passwords[request]; ok {
+		return password, nil
+	}
+	password, err := "", credential.ErrNotFound
+	if name := viper.GetString("credential-helper"); name != "" {
+		var helper credential.Helper
+		if helper, err = newCredentialHelper(name, interactive); err == nil {
+			password, err = helper.Get(request)
+		}
+		if err != nil && err != credential.ErrNotFound {
+			return "", err
+		}
+	}
+	if err == credential.ErrNotFound && interactive && isTerminal(os.Stdin) {
+		password, err = promptSecret(fmt.Sprintf("Password for %s: ", request))
+	}
+	if err == credential.ErrNotFound {
+		return "", fmt.Errorf("no password for %s, set a credential-helper or password in the config file, or use -p", request)
+	}
+	if err != nil {
+		return "", err
+	}
+	passwords[request] = password
+	return password, nil
+}
+
+func init() {
+	RootCmd.AddCommand(credentialCmd)
+	credentialCmd.AddCommand(credentialStoreCmd)
+	credentialCmd.AddCommand(credentialEraseCmd)
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/damianoneill/hb/credential"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupPassword(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credential")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	viper.Set("credential-store", filepath.Join(dir, "credentials"))
+	defer viper.Set("credential-store", "")
+	defer viper.Set("credential-helper", "")
+	defer func() { passwords = map[credential.Request]string{} }()
+	os.Setenv("HB_CREDENTIAL_PASSPHRASE", "correct horse")
+	defer os.Unsetenv("HB_CREDENTIAL_PASSPHRASE")
+	config := Config{Resource: "hb-1:8080", Username: "admin"}
+
+	password, err := lookupPassword(Config{Resource: "hb-1:8080", Username: "admin", Password: "from-flag"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "from-flag", password, "Expected -p or the config file to win over the helper")
+
+	_, err = lookupPassword(config, false)
+	assert.EqualError(t, err, "no password for admin@hb-1:8080, set a credential-helper or password in the config file, or use -p")
+
+	viper.Set("credential-helper", credential.StoreHelper)
+	_, err = lookupPassword(config, false)
+	assert.NotNil(t, err, "Expected an empty store to have no password")
+	store, err := newCredentialHelper(credential.StoreHelper, false)
+	assert.Nil(t, err)
+	assert.Nil(t, store.Store(credentialRequest(config), "from-store"))
+	password, err = lookupPassword(config, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "from-store", password)
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	helper := filepath.Join(dir, "helper")
+	assert.Nil(t, ioutil.WriteFile(helper, []byte("#!/bin/sh\ncat >/dev/null\necho password=from-helper\n"), 0700))
+	viper.Set("credential-helper", helper)
+	other := Config{Resource: "hb-2:8080", Username: "admin"}
+	password, err = lookupPassword(other, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "from-helper", password)
+	assert.Equal(t, "from-helper", passwords[credentialRequest(other)], "Expected the password to be asked for once")
+}
@@ -5,9 +5,7 @@ Copyright © 2019 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
-	"fmt"
-	"log"
-
+	"github.com/damianoneill/hb/logging"
 	"github.com/spf13/cobra"
 	"github.com/spf13/cobra/doc"
 )
@@ -15,7 +13,7 @@ import (
 func generateMarkdown() {
 	err := doc.GenMarkdownTree(RootCmd, "./docs/")
 	if err != nil {
-		log.Fatal(err)
+		logging.Fatal(err)
 	}
 }
 
@@ -25,7 +23,7 @@ var docsCmd = &cobra.Command{
 	Short: "Generate Markdown for the commands",
 	Long:  `For hb generate Markdown Documents for each of the commands and write them to a folder named ./docs`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Writing command descriptions to ./docs")
+		logging.Infof("Writing command descriptions to ./docs")
 		generateMarkdown()
 	},
 }
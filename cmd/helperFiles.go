@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/damianoneill/hb/healthbot"
+	"github.com/damianoneill/hb/logging"
+	"github.com/damianoneill/hb/types"
+	"github.com/spf13/cobra"
+)
+
+// helperFilesCmd represents the helper-files command
+var helperFilesCmd = &cobra.Command{
+	Use:   "helper-files",
+	Short: "Synchronise Helper Files between a directory and Healthbot.",
+	Long:  `Grouping for a set of commands that keep Helper Files in a local directory and Healthbot in step.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Usage()
+	},
+}
+
+// helperFilesSyncCmd represents the helper-files sync command
+var helperFilesSyncCmd = &cobra.Command{
+	Use:   "sync <directory>",
+	Short: "Upload the Helper Files that have changed.",
+	Long: `Compares the SHA-256 digest of each file in the directory (including subdirectories) against
+	the Healthbot listing and only uploads the files that are new or have changed.
+
+	With --delete, Helper Files in Healthbot that are not in the directory are removed.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("sync requires the name of the directory containing the Helper Files")
+		}
+		return nil
+	},
+	Run: func(c *cobra.Command, args []string) {
+		config := NewConfig(c)
+		config.Erase = c.Flag("delete").Value.String()
+		config.Directory = args[0]
+		if err := syncHelperFiles(config); err != nil {
+			logging.Fatal(err)
+		}
+	},
+}
+
+// helperFilesDownloadCmd represents the helper-files download command
+var helperFilesDownloadCmd = &cobra.Command{
+	Use:   "download <directory>",
+	Short: "Mirror the Helper Files in Healthbot to a directory.",
+	Long:  `Writes each Helper File in Healthbot to the directory, creating subdirectories as required.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("download requires the name of the directory to store the Helper Files")
+		}
+		return nil
+	},
+	Run: func(c *cobra.Command, args []string) {
+		config := NewConfig(c)
+		config.Directory = args[0]
+		if err := downloadHelperFiles(config); err != nil {
+			logging.Fatal(err)
+		}
+	},
+}
+
+// Checksum - the hex encoded SHA-256 digest of a file
+func Checksum(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// localHelperFiles - map of slash separated file name, relative to the directory, to checksum; hidden files are skipped
+func localHelperFiles(directory string) (map[string]string, error) {
+	checksums := map[string]string{}
+	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != directory && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		name, err := filepath.Rel(directory, path)
+		if err != nil {
+			return err
+		}
+		checksum, err := Checksum(path)
+		if err != nil {
+			return err
+		}
+		checksums[filepath.ToSlash(name)] = checksum
+		return nil
+	})
+	return checksums, err
+}
+
+// HelperFileNames - the directory of the Helper Files to upload and their slash separated names relative to it,
+// the Filename (a file or a directory) takes precedence over the Directory; subdirectories are only walked
+// when Recursive and hidden files are skipped. Helper Files are named by their file, so stdin is refused.
+func HelperFileNames(config Config) (directory string, names []string, err error) {
+	location := config.Filename
+	if location == "" {
+		location = config.Directory
+	}
+	if location == types.Stdin {
+		return "", nil, errors.New("helper files can not be read from stdin, they are uploaded with the name of their file")
+	}
+	logging.Infof("Using location: %s", location)
+	info, err := os.Stat(location)
+	if err != nil {
+		return "", nil, err
+	}
+	if !info.IsDir() {
+		names = []string{filepath.Base(location)}
+		logging.Infof("Using files: %s", names)
+		return filepath.Dir(location), names, nil
+	}
+	err = filepath.Walk(location, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == location {
+			return nil
+		}
+		if info.IsDir() {
+			if !config.Recursive || strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+		name, err := filepath.Rel(location, path)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(name))
+		return nil
+	})
+	logging.Infof("Using files: %s", names)
+	return location, names, err
+}
+
+func remoteHelperFiles(client *healthbot.Client) (types.HelperFiles, error) {
+	helperFiles, err := client.HelperFiles(context.Background())
+	if err != nil {
+		return helperFiles, fmt.Errorf("problem listing Helper Files: %v", err)
+	}
+	return helperFiles, nil
+}
+
+func sortedKeys(m map[string]string) (keys []string) {
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return
+}
+
+func uploadHelperFile(client *healthbot.Client, directory, name string) error {
+	f, err := os.Open(filepath.Join(directory, filepath.FromSlash(name)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := client.UploadHelperFile(context.Background(), name, f); err != nil {
+		return fmt.Errorf("problem uploading %s: %v", name, err)
+	}
+	return nil
+}
+
+func syncHelperFiles(config Config) error {
+	local, err := localHelperFiles(config.Directory)
+	if err != nil {
+		return err
+	}
+	client, err := config.Client()
+	if err != nil {
+		return err
+	}
+	helperFiles, err := remoteHelperFiles(client)
+	if err != nil {
+		return err
+	}
+	remote := helperFiles.Checksums()
+
+	uploaded, unchanged, deleted := 0, 0, 0
+	for _, name := range sortedKeys(local) {
+		if remote[name] == local[name] {
+			unchanged++
+			continue
+		}
+		if err := uploadHelperFile(client, config.Directory, name); err != nil {
+			return err
+		}
+		logging.Infof("Uploaded %s", name)
+		uploaded++
+	}
+
+	if config.Erase == "true" {
+		for _, name := range sortedKeys(remote) {
+			if _, ok := local[name]; ok {
+				continue
+			}
+			if err := client.DeleteHelperFile(context.Background(), name); err != nil {
+				return fmt.Errorf("problem deleting %s: %v", name, err)
+			}
+			logging.Infof("Deleted %s", name)
+			deleted++
+		}
+	}
+
+	logging.Infof("Successfully synchronised Helper Files, %v uploaded, %v unchanged, %v deleted", uploaded, unchanged, deleted)
+	return nil
+}
+
+func downloadHelperFiles(config Config) error {
+	client, err := config.Client()
+	if err != nil {
+		return err
+	}
+	helperFiles, err := remoteHelperFiles(client)
+	if err != nil {
+		return err
+	}
+	for _, hf := range helperFiles.HelperFile {
+		target := filepath.Join(config.Directory, filepath.FromSlash(hf.FileName))
+		// don't allow the listing to write outside of the directory
+		if rel, err := filepath.Rel(config.Directory, target); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("helper file %s is outside of %s", hf.FileName, config.Directory)
+		}
+		content, err := client.HelperFile(context.Background(), hf.FileName)
+		if err != nil {
+			return fmt.Errorf("problem downloading %s: %v", hf.FileName, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(target, content, 0644); err != nil {
+			return err
+		}
+		logging.Infof("Downloaded %s", hf.FileName)
+	}
+	logging.Infof("Successfully downloaded %v Files", len(helperFiles.HelperFile))
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(helperFilesCmd)
+	helperFilesCmd.AddCommand(helperFilesSyncCmd)
+	helperFilesCmd.AddCommand(helperFilesDownloadCmd)
+
+	helperFilesSyncCmd.Flags().Bool("delete", false, "delete Helper Files in Healthbot that are not in the directory")
+}
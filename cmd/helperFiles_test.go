@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/damianoneill/hb/hbtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncHelperFiles(t *testing.T) {
+	server := hbtest.NewServer()
+	server.SetHelperFile("unchanged.py", []byte("same"))
+	server.SetHelperFile("changed.py", []byte("old"))
+	server.SetHelperFile("removed.py", []byte("gone"))
+	ts := server.Start()
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "helper-files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "lib"), os.ModePerm))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "unchanged.py"), []byte("same"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "changed.py"), []byte("new"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "lib", "added.py"), []byte("added"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, ".DS_Store"), []byte("hidden"), 0644))
+
+	config := Config{Resource: hbtest.Resource(ts), Username: "admin", Password: "changeme", Directory: dir, Erase: "true"}
+	out := captureLog(t, func() { assert.Nil(t, syncHelperFiles(config)) })
+	assert.Contains(t, out, "2 uploaded, 1 unchanged, 1 deleted")
+
+	content, _ := server.HelperFile("changed.py")
+	assert.Equal(t, "new", string(content))
+	_, ok := server.HelperFile("lib/added.py")
+	assert.True(t, ok, "Expected files in subdirectories to be uploaded")
+	_, ok = server.HelperFile("removed.py")
+	assert.False(t, ok, "Expected files missing locally to be deleted")
+	_, ok = server.HelperFile(".DS_Store")
+	assert.False(t, ok, "Expected hidden files to be skipped")
+
+	download := filepath.Join(dir, "download")
+	config.Directory = download
+	captureLog(t, func() { assert.Nil(t, downloadHelperFiles(config)) })
+	content, err = ioutil.ReadFile(filepath.Join(download, "lib", "added.py"))
+	assert.Nil(t, err, "Expected download to create subdirectories")
+	assert.Equal(t, "added", string(content))
+}
+
+func TestDownloadHelperFilesStaysInDirectory(t *testing.T) {
+	server := hbtest.NewServer()
+	server.SetHelperFile("..foo.py", []byte("dots"))
+	ts := server.Start()
+	defer ts.Close()
+	dir, err := ioutil.TempDir("", "helper-files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := Config{Resource: hbtest.Resource(ts), Username: "admin", Password: "changeme", Directory: filepath.Join(dir, "download")}
+	captureLog(t, func() { assert.Nil(t, downloadHelperFiles(config)) })
+	content, err := ioutil.ReadFile(filepath.Join(dir, "download", "..foo.py"))
+	assert.Nil(t, err, "Expected a file name starting with .. to be downloaded")
+	assert.Equal(t, "dots", string(content))
+
+	server.SetHelperFile("../outside.py", []byte("escape"))
+	captureLog(t, func() {
+		assert.NotNil(t, downloadHelperFiles(config), "Expected a file outside of the directory to be refused")
+	})
+	_, err = os.Stat(filepath.Join(dir, "outside.py"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestHelperFileNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "helper-files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "lib"), os.ModePerm))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "a.rule"), []byte("a"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, ".hidden"), []byte("h"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "lib", "b.py"), []byte("b"), 0644))
+
+	directory, names, err := HelperFileNames(Config{Directory: dir})
+	assert.Nil(t, err)
+	assert.Equal(t, dir, directory)
+	assert.Equal(t, []string{"a.rule"}, names)
+
+	_, names, err = HelperFileNames(Config{Directory: "unused", Filename: dir, Recursive: true})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a.rule", "lib/b.py"}, names, "Expected the filename to be used and subdirectories walked")
+
+	directory, names, err = HelperFileNames(Config{Filename: filepath.Join(dir, "lib", "b.py")})
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, "lib"), directory)
+	assert.Equal(t, []string{"b.py"}, names)
+
+	_, _, err = HelperFileNames(Config{Filename: "-"})
+	assert.NotNil(t, err, "Expected stdin to be refused")
+	_, _, err = HelperFileNames(Config{Directory: filepath.Join(dir, "missing")})
+	assert.NotNil(t, err)
+}
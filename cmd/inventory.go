@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/damianoneill/hb/healthbot"
+	"github.com/damianoneill/hb/logging"
+	"github.com/spf13/cobra"
+)
+
+// inventoryCmd represents the inventory command
+var inventoryCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "Report the Device inventory from the Device Facts.",
+	Long: `Produces an inventory report for capacity planning from the facts Healthbot has collected:
+
+	- the number of Devices per platform, and per platform and release
+	- the Routing Engines of dual RE chassis, with model, mastership, up time and last reboot reason
+	- the Devices that Healthbot has no facts for
+	- the Devices with a Routing Engine rebooted within --recent
+
+	The report is written as Markdown, or as CSV with one block per section. With --servers or
+	--all-contexts the report covers every server, with a Server column.`,
+	Run: func(c *cobra.Command, args []string) {
+		output, _ := c.Flags().GetString("output")
+		recent, _ := c.Flags().GetDuration("recent")
+		if err := inventory(NewServers(c), os.Stdout, output, recent); err != nil {
+			logging.Fatal(err)
+		}
+	},
+}
+
+// section - a titled table in a report
+type section struct {
+	Title  string
+	Header []string
+	Rows   [][]string
+}
+
+// GetDeviceFacts - retrieves the facts for every Device
+func GetDeviceFacts(config Config) (healthbot.DeviceFacts, error) {
+	client, err := config.Client()
+	if err != nil {
+		return nil, err
+	}
+	deviceFacts, err := client.DeviceFacts(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("problem retrieving Device Facts: %v", err)
+	}
+	return deviceFacts, nil
+}
+
+var upTimePart = regexp.MustCompile(`(\d+)\s*(week|day|hour|minute|min|second|sec)s?`)
+var upTimeClock = regexp.MustCompile(`(\d+):(\d{2})(?::(\d{2}))?`)
+
+// parseUpTime - understands Junos style up times e.g. "2 days, 4 hours, 35 minutes, 2 seconds",
+// "12 days, 3:04" or a number of seconds
+func parseUpTime(upTime string) (time.Duration, bool) {
+	upTime = strings.TrimSpace(upTime)
+	if upTime == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(upTime); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	units := map[string]time.Duration{"week": 7 * 24 * time.Hour, "day": 24 * time.Hour, "hour": time.Hour, "minute": time.Minute, "min": time.Minute, "second": time.Second, "sec": time.Second}
+	var d time.Duration
+	found := false
+	for _, m := range upTimePart.FindAllStringSubmatch(upTime, -1) {
+		n, _ := strconv.Atoi(m[1])
+		d += time.Duration(n) * units[m[2]]
+		found = true
+	}
+	if m := upTimeClock.FindStringSubmatch(upTime); m != nil {
+		h, _ := strconv.Atoi(m[1])
+		min, _ := strconv.Atoi(m[2])
+		sec, _ := strconv.Atoi(m[3])
+		d += time.Duration(h)*time.Hour + time.Duration(min)*time.Minute + time.Duration(sec)*time.Second
+		found = true
+	}
+	return d, found
+}
+
+func histogram(title string, header []string, counts map[string]int) section {
+	s := section{Title: title, Header: header}
+	var keys []string
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s.Rows = append(s.Rows, append(strings.Split(k, "\x00"), strconv.Itoa(counts[k])))
+	}
+	return s
+}
+
+// inventorySections - builds the report from the facts
+func inventorySections(deviceFacts healthbot.DeviceFacts, recent time.Duration) []section {
+	platforms := map[string]int{}
+	releases := map[string]int{}
+	routingEngines := section{Title: "Dual Routing Engine Chassis", Header: []string{"Device Id", "Routing Engine", "Model", "Status", "Mastership", "Up Time", "Last Reboot Reason"}}
+	missing := section{Title: "Devices Missing Facts", Header: []string{"Device Id"}}
+	rebooted := section{Title: "Recently Rebooted Devices", Header: []string{"Device Id", "Routing Engine", "Up Time", "Last Reboot Reason"}}
+
+	for _, fact := range deviceFacts {
+		if fact.Facts.Platform == "" && fact.Facts.Release == "" && len(fact.Facts.JunosInfo) == 0 {
+			missing.Rows = append(missing.Rows, []string{fact.DeviceID})
+			continue
+		}
+		platforms[fact.Facts.Platform]++
+		releases[fact.Facts.Platform+"\x00"+fact.Facts.Release]++
+		for _, re := range fact.Facts.JunosInfo {
+			if len(fact.Facts.JunosInfo) > 1 {
+				routingEngines.Rows = append(routingEngines.Rows, []string{fact.DeviceID, re.Name, re.Model, re.Status, re.MastershipState, re.UpTime, re.LastRebootReason})
+			}
+			if upTime, ok := parseUpTime(re.UpTime); ok && upTime < recent {
+				rebooted.Rows = append(rebooted.Rows, []string{fact.DeviceID, re.Name, re.UpTime, re.LastRebootReason})
+			}
+		}
+	}
+	return []section{
+		histogram("Devices per Platform", []string{"Platform", "No of Devices"}, platforms),
+		histogram("Devices per Release", []string{"Platform", "Release", "No of Devices"}, releases),
+		routingEngines,
+		missing,
+		rebooted,
+	}
+}
+
+func writeMarkdown(w io.Writer, sections []section) {
+	escape := strings.NewReplacer("|", "\\|")
+	for _, s := range sections {
+		fmt.Fprintf(w, "## %s\n\n", s.Title)
+		if len(s.Rows) == 0 {
+			fmt.Fprintf(w, "None\n\n")
+			continue
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(s.Header, " | "))
+		fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(s.Header)))
+		for _, row := range s.Rows {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = escape.Replace(cell)
+			}
+			fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+		}
+		fmt.Fprintln(w, "")
+	}
+}
+
+func writeCSV(w io.Writer, sections []section) error {
+	writer := csv.NewWriter(w)
+	for i, s := range sections {
+		if i > 0 {
+			writer.Flush()
+			fmt.Fprintln(w, "")
+		}
+		_ = writer.Write(append([]string{"Section"}, s.Header...))
+		for _, row := range s.Rows {
+			_ = writer.Write(append([]string{s.Title}, row...))
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func inventory(servers []Server, w io.Writer, output string, recent time.Duration) error {
+	if output != "csv" && output != "markdown" {
+		return fmt.Errorf("unknown output %s, expected markdown or csv", output)
+	}
+	sections, err := fanOut(servers, func(config Config) ([]section, error) {
+		deviceFacts, err := GetDeviceFacts(config)
+		if err != nil {
+			return nil, err
+		}
+		return inventorySections(deviceFacts, recent), nil
+	})
+	if output == "csv" {
+		if werr := writeCSV(w, sections); err == nil {
+			err = werr
+		}
+		return err
+	}
+	writeMarkdown(w, sections)
+	return err
+}
+
+func init() {
+	RootCmd.AddCommand(inventoryCmd)
+
+	inventoryCmd.Flags().StringP("output", "o", "markdown", "output format markdown or csv")
+	inventoryCmd.Flags().Duration("recent", 7*24*time.Hour, "a Routing Engine with a shorter up time was recently rebooted")
+}
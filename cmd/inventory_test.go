@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUpTime(t *testing.T) {
+	d, ok := parseUpTime("2 days, 4 hours, 35 minutes, 2 seconds")
+	assert.True(t, ok)
+	assert.Equal(t, 52*time.Hour+35*time.Minute+2*time.Second, d)
+	d, _ = parseUpTime("12 days, 3:04")
+	assert.Equal(t, 12*24*time.Hour+3*time.Hour+4*time.Minute, d)
+	d, _ = parseUpTime("3600")
+	assert.Equal(t, time.Hour, d)
+	_, ok = parseUpTime("")
+	assert.False(t, ok)
+}
+
+func TestInventory(t *testing.T) {
+	server, config, stop := newTestServer(t)
+	defer stop()
+	server.SetFacts("mx960-1", map[string]interface{}{
+		"platform": "MX960", "release": "19.3R1.8",
+		"junos-info": []map[string]string{
+			{"name": "re0", "model": "RE-S-2X00x6", "mastership-state": "master", "status": "OK", "up-time": "40 days, 2 hours", "last-reboot-reason": "Router rebooted after a normal shutdown."},
+			{"name": "re1", "model": "RE-S-2X00x6", "mastership-state": "backup", "status": "OK", "up-time": "3 hours, 2 minutes", "last-reboot-reason": "power cycle/failure"},
+		},
+	})
+
+	var out bytes.Buffer
+	assert.Nil(t, inventory(single(config), &out, "markdown", 24*time.Hour))
+	report := out.String()
+	assert.Contains(t, report, "| MX960 | 1 |")
+	assert.Contains(t, report, "| MX960 | 19.3R1.8 | 1 |")
+	assert.Contains(t, report, "| mx960-1 | re1 | RE-S-2X00x6 | OK | backup | 3 hours, 2 minutes | power cycle/failure |")
+	assert.Contains(t, report, "## Devices Missing Facts\n\n| Device Id |\n| --- |\n| mx960-3 |")
+	assert.Contains(t, report, "## Recently Rebooted Devices\n\n| Device Id | Routing Engine | Up Time | Last Reboot Reason |\n| --- | --- | --- | --- |\n| mx960-1 | re1 |")
+
+	out.Reset()
+	assert.Nil(t, inventory(single(config), &out, "csv", 24*time.Hour))
+	assert.True(t, strings.HasPrefix(out.String(), "Section,Platform,No of Devices\nDevices per Platform,MX960,1\n"))
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+
+	"github.com/damianoneill/hb/hbtest"
+	"github.com/damianoneill/hb/logging"
+	"github.com/spf13/cobra"
+)
+
+// mockServerCmd represents the mock-server command
+var mockServerCmd = &cobra.Command{
+	Use:   "mock-server",
+	Short: "Run an in-memory Healthbot for testing.",
+	Long: `Serves the subset of the Healthbot REST API used by hb over TLS, with the state held in memory.
+
+	The server can be seeded from a directory written by scaffold, and a yaml script of faults
+	can be used to make requests fail, e.g.
+
+	fault:
+	  - method: POST
+	    path: /api/v1/device-groups/
+	    status: 500
+	    body: "simulated failure"
+	    times: 1
+
+	Point hb at the server with --resource, the password is not checked.`,
+	Run: func(c *cobra.Command, args []string) {
+		if err := mockServer(c.Flag("listen").Value.String(), c.Flag("seed").Value.String(), c.Flag("faults").Value.String()); err != nil {
+			logging.Fatal(err)
+		}
+	},
+}
+
+func mockServer(address, seed, faults string) error {
+	server := hbtest.NewServer()
+	if seed != "" {
+		if err := server.LoadScaffold(seed); err != nil {
+			return err
+		}
+		logging.Infof("Seeded from: %s", seed)
+	}
+	if faults != "" {
+		f, err := hbtest.LoadFaults(faults)
+		if err != nil {
+			return err
+		}
+		server.Inject(f...)
+		logging.Infof("Injected %v Faults", len(f))
+	}
+	ts, err := server.Listen(address)
+	if err != nil {
+		return err
+	}
+	defer ts.Close()
+	logging.Infof("Mock Healthbot listening, use --resource %s", hbtest.Resource(ts))
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(mockServerCmd)
+
+	mockServerCmd.Flags().String("listen", "localhost:8443", "address to listen on")
+	mockServerCmd.Flags().String("seed", "", "scaffold directory to load the initial state from")
+	mockServerCmd.Flags().String("faults", "", "yaml file of faults to inject")
+}
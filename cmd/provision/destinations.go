@@ -0,0 +1,84 @@
+package provision
+
+import (
+	"fmt"
+
+	"github.com/damianoneill/hb/cmd"
+	"github.com/damianoneill/hb/logging"
+	"github.com/damianoneill/hb/types"
+	"github.com/spf13/cobra"
+)
+
+// destinationsCmd represents the destinations command
+var destinationsCmd = &cobra.Command{
+	Use:   "destinations",
+	Short: "Provision a set of Destinations from configuration files.",
+	Long:  `The Destinations can be defined in YAML or JSON and conform to the payload definitions for the REST API.`,
+	Run: func(c *cobra.Command, args []string) {
+		rollout(c, func(config cmd.Config) error {
+			config.Directory = c.Flag("directory").Value.String()
+			config.Erase = c.Flag("erase").Value.String()
+			filenames, err := cmd.ConfigFiles(config)
+			if err != nil {
+				return err
+			}
+			return provisionDestinations(config, filenames)
+		})
+	},
+}
+
+func deleteDestinations(tx *transaction, destinations types.Destinations) error {
+	for _, d := range destinations.Destination {
+		resp, err := tx.delete(destinationsResource, d.Name)
+		if err != nil {
+			return fmt.Errorf("problem posting to Destinations %v", err)
+		}
+		if resp.StatusCode() != 204 {
+			return fmt.Errorf("problem updating Destination %v: %v", d.Name, resp.String())
+		}
+	}
+	logging.Infof("Successfully updated %v Destinations", len(destinations.Destination))
+	return nil
+}
+
+func createDestinations(tx *transaction, destinations types.Destinations) error {
+	resp, err := tx.post(destinations, destinationsResource)
+	if err != nil {
+		return fmt.Errorf("problem posting to Destinations %v", err)
+	}
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("problem updating Destinations: %v", resp.String())
+	}
+	logging.Infof("Successfully updated %v Destinations", len(destinations.Destination))
+	return nil
+}
+
+func provisionDestinations(config cmd.Config, filenames []string) error {
+	return provisionDocuments(config, filenames, func(tx *transaction, document []byte) error {
+		var destinations types.Destinations
+		if err := destinations.Parse(document); err != nil {
+			return err
+		}
+		if tx.config.Erase == "true" {
+			return deleteDestinations(tx, destinations)
+		}
+		return createDestinations(tx, destinations)
+	})
+}
+
+func init() {
+	provisionCmd.AddCommand(destinationsCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// destinationsCmd.PersistentFlags().String("foo", "", "A help for foo")
+	destinationsCmd.PersistentFlags().StringP("directory", "d", "destinations", "Default file location")
+
+	destinationsCmd.PersistentFlags().BoolP("erase", "e", false, "to erase this configuration")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// destinationsCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
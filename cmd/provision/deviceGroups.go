@@ -1,14 +1,13 @@
 package provision
 
 import (
+	"context"
 	"fmt"
-	"log"
 
 	"github.com/damianoneill/hb/cmd"
+	"github.com/damianoneill/hb/logging"
 	"github.com/damianoneill/hb/types"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
-	"gopkg.in/resty.v1"
 )
 
 // deviceGroupsCmd represents the deviceGroups command
@@ -16,64 +15,91 @@ var deviceGroupsCmd = &cobra.Command{
 	Use:   "device-groups",
 	Short: "Provision a set of Device Groups from configuration files.",
 	Long:  `The Device groups can be defined in YAML or JSON and conform to the payload definitions for the REST API.`,
-	PreRun: func(cmd *cobra.Command, args []string) {
-		if viper.GetString("debug") == "true" {
-			resty.SetDebug(true)
-		}
-	},
 	Run: func(c *cobra.Command, args []string) {
-		config := cmd.NewConfig(c)
-		config.Directory = c.Flag("directory").Value.String()
-		config.Erase = c.Flag("erase").Value.String()
-		filenames := cmd.FilesInDirectory(config.Directory)
-		provisionDeviceGroups(config, filenames)
+		rollout(c, func(config cmd.Config) error {
+			config.Directory = c.Flag("directory").Value.String()
+			config.Erase = c.Flag("erase").Value.String()
+			filenames, err := cmd.ConfigFiles(config)
+			if err != nil {
+				return err
+			}
+			return provisionDeviceGroups(config, filenames)
+		})
 	},
 }
 
-func deleteDeviceGroups(config cmd.Config, deviceGroups types.DeviceGroups) {
-	noFailures := true
+func deleteDeviceGroups(tx *transaction, deviceGroups types.DeviceGroups) error {
 	for _, dg := range deviceGroups.DeviceGroup {
-		resp, err := cmd.DELETE(config.Resource, "/api/v1/device-group/"+dg.DeviceGroupName+"/", config.Username, config.Password)
+		resp, err := tx.delete(deviceGroupsResource, dg.DeviceGroupName)
 		if err != nil {
-			fmt.Printf("Problem posting to DeviceGroups %v", err)
-			return
+			return fmt.Errorf("problem posting to DeviceGroups %v", err)
 		}
 		if resp.StatusCode() != 204 {
-			fmt.Printf("Problem updating Device Group %v: %v \n", dg.DeviceGroupName, resp.String())
-			noFailures = false
+			return fmt.Errorf("problem updating Device Group %v: %v", dg.DeviceGroupName, resp.String())
 		}
 	}
-	if noFailures {
-		fmt.Printf("Successfully updated %v %s", len(deviceGroups.DeviceGroup), "Device Groups \n")
-	}
+	logging.Infof("Successfully updated %v Device Groups", len(deviceGroups.DeviceGroup))
+	return nil
 }
 
-func createDeviceGroups(config cmd.Config, deviceGroups types.DeviceGroups) {
-	resp, err := cmd.POST(deviceGroups, config.Resource, "/api/v1/device-groups/", config.Username, config.Password)
+func createDeviceGroups(tx *transaction, deviceGroups types.DeviceGroups) error {
+	resp, err := tx.post(deviceGroups, deviceGroupsResource)
 	if err != nil {
-		fmt.Printf("Problem posting to DeviceGroups %v", err)
-		return
+		return fmt.Errorf("problem posting to DeviceGroups %v", err)
 	}
-	switch resp.StatusCode() {
-	case 200:
-		fmt.Printf("Successfully updated %v %s", len(deviceGroups.DeviceGroup), "Device Groups \n")
-	default:
-		fmt.Printf("Problem updating Device Groups: %v \n", resp.String())
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("problem updating Device Groups: %v", resp.String())
 	}
+	logging.Infof("Successfully updated %v Device Groups", len(deviceGroups.DeviceGroup))
+	return nil
 }
 
-func provisionDeviceGroups(config cmd.Config, filenames []string) {
-	for _, filename := range filenames {
-		var deviceGroups types.DeviceGroups
-		if err := types.LoadConfiguration(config.Directory+"/"+filename, &deviceGroups); err != nil {
-			log.Fatal("Problem with "+filename+" ", err)
+func validateDeviceGroups(config cmd.Config, deviceGroups types.DeviceGroups) error {
+	if err := deviceGroups.Validate(); err != nil {
+		return err
+	}
+	var retentionPolicyNames, reportNames, frequencyProfileNames []string
+	for _, dg := range deviceGroups.DeviceGroup {
+		if dg.RetentionPolicy != nil {
+			retentionPolicyNames = append(retentionPolicyNames, *dg.RetentionPolicy)
+		}
+		if dg.Reports != nil {
+			reportNames = append(reportNames, *dg.Reports...)
 		}
-		if config.Erase == "true" {
-			deleteDeviceGroups(config, deviceGroups)
-		} else {
-			createDeviceGroups(config, deviceGroups)
+		if dg.IngestFrequency != nil {
+			frequencyProfileNames = append(frequencyProfileNames, *dg.IngestFrequency...)
 		}
 	}
+	if len(frequencyProfileNames) > 0 {
+		client, err := workingClient(config)
+		if err != nil {
+			return err
+		}
+		frequencyProfiles, err := client.FrequencyProfiles(context.Background())
+		if err != nil {
+			return fmt.Errorf("problem retrieving Frequency Profiles: %v", err)
+		}
+		if err := checkReferences("Frequency Profile", frequencyProfileNames, frequencyProfiles.Names()); err != nil {
+			return err
+		}
+	}
+	return checkGroupReferences(config, retentionPolicyNames, reportNames)
+}
+
+func provisionDeviceGroups(config cmd.Config, filenames []string) error {
+	return provisionDocuments(config, filenames, func(tx *transaction, document []byte) error {
+		var deviceGroups types.DeviceGroups
+		if err := deviceGroups.Parse(document); err != nil {
+			return err
+		}
+		if tx.config.Erase == "true" {
+			return deleteDeviceGroups(tx, deviceGroups)
+		}
+		if err := validateDeviceGroups(tx.config, deviceGroups); err != nil {
+			return err
+		}
+		return createDeviceGroups(tx, deviceGroups)
+	})
 }
 
 func init() {
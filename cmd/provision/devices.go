@@ -2,13 +2,11 @@ package provision
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/damianoneill/hb/cmd"
+	"github.com/damianoneill/hb/logging"
 	"github.com/damianoneill/hb/types"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
-	"gopkg.in/resty.v1"
 )
 
 // devicesCmd represents the devices command
@@ -16,64 +14,59 @@ var devicesCmd = &cobra.Command{
 	Use:   "devices",
 	Short: "Provision a set of Devices from configuration files.",
 	Long:  `The Devices can be defined in YAML or JSON and conform to the payload definitions for the REST API.`,
-	PreRun: func(cmd *cobra.Command, args []string) {
-		if viper.GetString("debug") == "true" {
-			resty.SetDebug(true)
-		}
-	},
 	Run: func(c *cobra.Command, args []string) {
-		config := cmd.NewConfig(c)
-		config.Erase = c.Flag("erase").Value.String()
-		config.Directory = c.Flag("directory").Value.String()
-		filenames := cmd.FilesInDirectory(config.Directory)
-		provisionDevices(config, filenames)
+		rollout(c, func(config cmd.Config) error {
+			config.Erase = c.Flag("erase").Value.String()
+			config.Directory = c.Flag("directory").Value.String()
+			filenames, err := cmd.ConfigFiles(config)
+			if err != nil {
+				return err
+			}
+			return provisionDevices(config, filenames)
+		})
 	},
 }
 
-func deleteDevices(config cmd.Config, devices types.Devices) {
-	noFailures := true
+func deleteDevices(tx *transaction, devices types.Devices) error {
 	for _, device := range devices.Device {
-		resp, err := cmd.DELETE(config.Resource, "/api/v1/device/"+device.DeviceID+"/", config.Username, config.Password)
+		resp, err := tx.delete(devicesResource, device.DeviceID)
 		if err != nil {
-			fmt.Printf("Problem posting to Devices %v", err)
-			return
+			return fmt.Errorf("problem posting to Devices %v", err)
 		}
 		if resp.StatusCode() != 204 {
-			fmt.Printf("Problem updating Device %v: %v \n", device.DeviceID, resp.String())
-			noFailures = false
+			return fmt.Errorf("problem updating Device %v: %v", device.DeviceID, resp.String())
 		}
 	}
-	if noFailures {
-		fmt.Printf("Successfully updated %v %s", len(devices.Device), "Devices \n")
-	}
+	logging.Infof("Successfully updated %v Devices", len(devices.Device))
+	return nil
 }
 
-func createDevices(config cmd.Config, devices types.Devices) {
-	resp, err := cmd.POST(devices, config.Resource, "/api/v1/devices/", config.Username, config.Password)
+func createDevices(tx *transaction, devices types.Devices) error {
+	resp, err := tx.post(devices, devicesResource)
 	if err != nil {
-		fmt.Printf("Problem posting to Devices %v", err)
-		return
+		return fmt.Errorf("problem posting to Devices %v", err)
 	}
-	switch resp.StatusCode() {
-	case 200:
-		fmt.Printf("Successfully updated %v %s", len(devices.Device), "Devices \n")
-	default:
-		fmt.Printf("Problem updating Devices: %v \n", resp.String())
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("problem updating Devices: %v", resp.String())
 	}
+	logging.Infof("Successfully updated %v Devices", len(devices.Device))
+	return nil
 }
 
-func provisionDevices(config cmd.Config, filenames []string) {
-	for _, filename := range filenames {
+func provisionDevices(config cmd.Config, filenames []string) error {
+	return provisionDocuments(config, filenames, func(tx *transaction, document []byte) error {
 		var devices types.Devices
-		if err := types.LoadConfiguration(config.Directory+"/"+filename, &devices); err != nil {
-			log.Fatal("Problem with "+filename+" ", err)
+		if err := devices.Parse(document); err != nil {
+			return err
 		}
-		if config.Erase == "true" {
-			deleteDevices(config, devices)
-		} else {
-			createDevices(config, devices)
+		if tx.config.Erase == "true" {
+			return deleteDevices(tx, devices)
 		}
-	}
+		if err := devices.Validate(); err != nil {
+			return err
+		}
+		return createDevices(tx, devices)
+	})
 }
 
 func init() {
@@ -0,0 +1,84 @@
+package provision
+
+import (
+	"fmt"
+
+	"github.com/damianoneill/hb/cmd"
+	"github.com/damianoneill/hb/logging"
+	"github.com/damianoneill/hb/types"
+	"github.com/spf13/cobra"
+)
+
+// frequencyProfilesCmd represents the frequencyProfiles command
+var frequencyProfilesCmd = &cobra.Command{
+	Use:   "frequency-profiles",
+	Short: "Provision a set of Frequency Profiles from configuration files.",
+	Long:  `The Frequency Profiles can be defined in YAML or JSON and conform to the payload definitions for the REST API.`,
+	Run: func(c *cobra.Command, args []string) {
+		rollout(c, func(config cmd.Config) error {
+			config.Directory = c.Flag("directory").Value.String()
+			config.Erase = c.Flag("erase").Value.String()
+			filenames, err := cmd.ConfigFiles(config)
+			if err != nil {
+				return err
+			}
+			return provisionFrequencyProfiles(config, filenames)
+		})
+	},
+}
+
+func deleteFrequencyProfiles(tx *transaction, frequencyProfiles types.FrequencyProfiles) error {
+	for _, fp := range frequencyProfiles.FrequencyProfile {
+		resp, err := tx.delete(frequencyProfilesResource, fp.Name)
+		if err != nil {
+			return fmt.Errorf("problem posting to Frequency Profiles %v", err)
+		}
+		if resp.StatusCode() != 204 {
+			return fmt.Errorf("problem updating Frequency Profile %v: %v", fp.Name, resp.String())
+		}
+	}
+	logging.Infof("Successfully updated %v Frequency Profiles", len(frequencyProfiles.FrequencyProfile))
+	return nil
+}
+
+func createFrequencyProfiles(tx *transaction, frequencyProfiles types.FrequencyProfiles) error {
+	resp, err := tx.post(frequencyProfiles, frequencyProfilesResource)
+	if err != nil {
+		return fmt.Errorf("problem posting to Frequency Profiles %v", err)
+	}
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("problem updating Frequency Profiles: %v", resp.String())
+	}
+	logging.Infof("Successfully updated %v Frequency Profiles", len(frequencyProfiles.FrequencyProfile))
+	return nil
+}
+
+func provisionFrequencyProfiles(config cmd.Config, filenames []string) error {
+	return provisionDocuments(config, filenames, func(tx *transaction, document []byte) error {
+		var frequencyProfiles types.FrequencyProfiles
+		if err := frequencyProfiles.Parse(document); err != nil {
+			return err
+		}
+		if tx.config.Erase == "true" {
+			return deleteFrequencyProfiles(tx, frequencyProfiles)
+		}
+		return createFrequencyProfiles(tx, frequencyProfiles)
+	})
+}
+
+func init() {
+	provisionCmd.AddCommand(frequencyProfilesCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// frequencyProfilesCmd.PersistentFlags().String("foo", "", "A help for foo")
+	frequencyProfilesCmd.PersistentFlags().StringP("directory", "d", "frequency-profiles", "Default file location")
+
+	frequencyProfilesCmd.PersistentFlags().BoolP("erase", "e", false, "to erase this configuration")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// frequencyProfilesCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
@@ -1,57 +1,58 @@
 package provision
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/damianoneill/hb/cmd"
+	"github.com/damianoneill/hb/logging"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
-	"gopkg.in/resty.v1"
 )
 
 // helperFilesCmd represents the helperFiles command
 var helperFilesCmd = &cobra.Command{
 	Use:   "helper-files",
 	Short: "Upload Helper Files to Healthbot.",
-	Long:  `Helper files for e.g. Playbook, Rules, Python files can be uploaded to Healthbot with this command.`,
-	PreRun: func(cmd *cobra.Command, args []string) {
-		if viper.GetString("debug") == "true" {
-			resty.SetDebug(true)
-		}
-	},
+	Long: `Helper files for e.g. Playbook, Rules, Python files can be uploaded to Healthbot with this command.
+
+	The files are taken from --filename, a file or a directory, or the --directory; with --recursive the
+	files in subdirectories are uploaded with their path relative to the directory as their name.`,
 	Run: func(c *cobra.Command, args []string) {
-		config := cmd.NewConfig(c)
-		config.Directory = c.Flag("directory").Value.String()
-		filenames := cmd.FilesInDirectory(config.Directory)
-		provisionHelperFiles(config, filenames)
+		rollout(c, func(config cmd.Config) error {
+			config.Directory = c.Flag("directory").Value.String()
+			directory, names, err := cmd.HelperFileNames(config)
+			if err != nil {
+				return err
+			}
+			return provisionHelperFiles(config, directory, names)
+		})
 	},
 }
 
-func provisionHelperFiles(config cmd.Config, filenames []string) {
+func provisionHelperFiles(config cmd.Config, directory string, filenames []string) error {
+	client, err := config.Client()
+	if err != nil {
+		return err
+	}
+	failures := 0
 	for _, filename := range filenames {
-		f, err := os.Open(config.Directory + "/" + filename)
-		if err != nil {
-			panic(err)
+		f, err := os.Open(filepath.Join(directory, filepath.FromSlash(filename)))
+		if err == nil {
+			err = client.UploadHelperFile(context.Background(), filename, f)
+			f.Close()
 		}
-		defer f.Close()
-
-		resp, err := resty.R().
-			SetBasicAuth(config.Username, config.Password).
-			SetFileReader("up_file", filename, f).
-			Post("https://" + config.Resource + "/api/v1/files/helper-files/" + filename + "/")
-
 		if err != nil {
-			fmt.Printf("Problem posting to Helper Files %v", err)
-		}
-
-		switch resp.StatusCode() {
-		case 200:
-			fmt.Printf("Successfully uploaded %v %s", len(filenames), "Files \n")
-		default:
-			fmt.Printf("Problem uploading Files: %v \n", resp.String())
+			logging.Errorf("Problem uploading File %v: %v", filename, err)
+			failures++
 		}
 	}
+	if failures > 0 {
+		return fmt.Errorf("problem uploading %v of %v Files", failures, len(filenames))
+	}
+	logging.Infof("Successfully uploaded %v Files", len(filenames))
+	return nil
 }
 
 func init() {
@@ -0,0 +1,526 @@
+package provision
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/damianoneill/hb/cmd"
+	"github.com/damianoneill/hb/logging"
+	"github.com/damianoneill/hb/redact"
+	"github.com/damianoneill/hb/types"
+	"github.com/spf13/cobra"
+)
+
+// migrateCmd represents the migrate command
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Copy the configuration of one Healthbot to another.",
+	Long: `Copies the Helper Files, Playbooks, Devices, Device Groups and Playbook Instances from the --from
+	Healthbot to the --to Healthbot, each a context in the config file or a resource.
+
+	--group copies only the Device Groups named, with their Devices, and --device only the Devices named.
+	The Playbooks used by the Device Groups are copied with them, the Helper Files are always copied.
+
+	Usernames, passwords and SNMP communities are copied as they are, --map-credential sets those that
+	match a field or a path e.g. password=NEW_PASSWORD or device/mx960-*/password=MX_PASSWORD from
+	an environment variable. Secrets that are masked on the source must be mapped.
+
+	An entity that is on the target with a different configuration is a conflict, and nothing is
+	copied unless --on-conflict is skip or overwrite. The plan is printed before it is applied, with
+	--dry-run it is only printed. The Helper Files are uploaded first, the rest of the configuration is
+	committed together and rolled back on failure.`,
+	Run: func(c *cobra.Command, args []string) {
+		options, err := newMigrateOptions(c)
+		if err != nil {
+			logging.Fatal(err)
+		}
+		from, _ := c.Flags().GetString("from")
+		to, _ := c.Flags().GetString("to")
+		source, target := cmd.NewServer(c, from), cmd.NewServer(c, to)
+		if err := migrate(source.Config, target.Config, options, os.Stdout); err != nil {
+			logging.Fatal(err)
+		}
+	},
+}
+
+// Actions planned for an entity on the target
+const (
+	createAction    = "create"
+	updateAction    = "update"
+	unchangedAction = "unchanged"
+	skipAction      = "skip"
+	conflictAction  = "conflict"
+)
+
+// credentialMapping - sets the secrets matching a field, or a path pattern, from an environment variable
+type credentialMapping struct {
+	Pattern string
+	Env     string
+}
+
+func (m credentialMapping) matches(secret types.Secret) bool {
+	if !strings.Contains(m.Pattern, "/") {
+		return secret.Field == m.Pattern
+	}
+	matched, _ := path.Match(m.Pattern, secret.Path)
+	return matched
+}
+
+type migrateOptions struct {
+	Groups      []string
+	Devices     []string
+	Credentials []credentialMapping
+	OnConflict  string
+	DryRun      bool
+}
+
+func newMigrateOptions(c *cobra.Command) (options migrateOptions, err error) {
+	flags := c.Flags()
+	options.Groups, _ = flags.GetStringSlice("group")
+	options.Devices, _ = flags.GetStringSlice("device")
+	options.OnConflict, _ = flags.GetString("on-conflict")
+	options.DryRun, _ = flags.GetBool("dry-run")
+	mappings, _ := flags.GetStringSlice("map-credential")
+	for _, m := range mappings {
+		parts := strings.SplitN(m, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return options, fmt.Errorf("credential mapping %s is not <field or path>=<environment variable>", m)
+		}
+		options.Credentials = append(options.Credentials, credentialMapping{Pattern: parts[0], Env: parts[1]})
+	}
+	switch options.OnConflict {
+	case "fail", "skip", "overwrite":
+	default:
+		return options, fmt.Errorf("unknown on-conflict %s, expected fail, skip or overwrite", options.OnConflict)
+	}
+	return
+}
+
+// migrationStep - what is planned for an entity on the target
+type migrationStep struct {
+	Kind   string
+	Name   string
+	Action string
+}
+
+// migration - the configuration read from the source and the plan for copying it to the target
+type migration struct {
+	helperFiles       []types.HelperFile
+	playbooks         types.Playbooks
+	devices           types.Devices
+	deviceGroups      types.DeviceGroups
+	playbookInstances types.PlaybookInstances
+	steps             []migrationStep
+}
+
+// readSource - the configuration to copy, filtered to the Device Groups and Devices in the options
+func readSource(source cmd.Config, options migrateOptions) (m migration, err error) {
+	client, err := source.Client()
+	if err != nil {
+		return
+	}
+	ctx := context.Background()
+	helperFiles, err := client.HelperFiles(ctx)
+	if err != nil {
+		return m, fmt.Errorf("problem retrieving Helper Files: %v", err)
+	}
+	m.helperFiles = helperFiles.HelperFile
+	playbooks, err := client.Playbooks(ctx)
+	if err != nil {
+		return m, fmt.Errorf("problem retrieving Playbooks: %v", err)
+	}
+	devices, err := client.Devices(ctx)
+	if err != nil {
+		return m, fmt.Errorf("problem retrieving Devices: %v", err)
+	}
+	deviceGroups, err := client.DeviceGroups(ctx)
+	if err != nil {
+		return m, fmt.Errorf("problem retrieving Device Groups: %v", err)
+	}
+	playbookInstances, err := client.PlaybookInstances(ctx)
+	if err != nil {
+		return m, fmt.Errorf("problem retrieving Playbook Instances: %v", err)
+	}
+
+	filtered := len(options.Groups) > 0 || len(options.Devices) > 0
+	groupNames := names(options.Groups)
+	deviceNames := names(options.Devices)
+	playbookNames := map[string]bool{}
+	foundGroups, foundDevices := map[string]bool{}, map[string]bool{}
+	for _, dg := range deviceGroups.DeviceGroup {
+		if filtered && !groupNames[dg.DeviceGroupName] {
+			continue
+		}
+		foundGroups[dg.DeviceGroupName] = true
+		m.deviceGroups.DeviceGroup = append(m.deviceGroups.DeviceGroup, dg)
+		if dg.Devices != nil {
+			for _, d := range *dg.Devices {
+				deviceNames[d] = true
+			}
+		}
+		if dg.Playbooks != nil {
+			for _, p := range *dg.Playbooks {
+				playbookNames[p] = true
+			}
+		}
+	}
+	if err = notOnSource("Device Groups", options.Groups, foundGroups); err != nil {
+		return
+	}
+	for _, pi := range playbookInstances.DeviceGroup {
+		if len(pi.Variable) == 0 || (filtered && !groupNames[pi.DeviceGroupName]) {
+			continue
+		}
+		m.playbookInstances.DeviceGroup = append(m.playbookInstances.DeviceGroup, pi)
+		for _, v := range pi.Variable {
+			playbookNames[v.Playbook] = true
+		}
+	}
+	for _, d := range devices.Device {
+		if filtered && !deviceNames[d.DeviceID] {
+			continue
+		}
+		foundDevices[d.DeviceID] = true
+		m.devices.Device = append(m.devices.Device, d)
+	}
+	if err = notOnSource("Devices", options.Devices, foundDevices); err != nil {
+		return
+	}
+	for _, p := range playbooks.Playbooks {
+		if filtered && !playbookNames[p.PlayBookName] {
+			continue
+		}
+		m.playbooks.Playbooks = append(m.playbooks.Playbooks, p)
+	}
+	return m, nil
+}
+
+func names(list []string) map[string]bool {
+	set := map[string]bool{}
+	for _, name := range list {
+		set[name] = true
+	}
+	return set
+}
+
+// notOnSource - an error naming the entities asked for that the source does not have
+func notOnSource(kind string, asked []string, found map[string]bool) error {
+	var missing []string
+	for _, name := range asked {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s are not on the source: %s", kind, strings.Join(missing, ", "))
+}
+
+// mapCredentials - replaces the secrets from the environment, a secret the source masked must be mapped
+func mapCredentials(holders []types.SecretHolder, mappings []credentialMapping) error {
+	var masked []string
+	for _, holder := range holders {
+		for _, secret := range holder.Secrets() {
+			var mapping *credentialMapping
+			for i := range mappings {
+				if mappings[i].matches(secret) {
+					mapping = &mappings[i]
+				}
+			}
+			if mapping == nil {
+				if secret.Value == redact.Mask {
+					masked = append(masked, secret.Path)
+				}
+				continue
+			}
+			value, ok := os.LookupEnv(mapping.Env)
+			if !ok {
+				return fmt.Errorf("environment variable %s for %s is not set", mapping.Env, secret.Path)
+			}
+			secret.Set(&value)
+		}
+	}
+	if len(masked) > 0 {
+		return fmt.Errorf("secrets are masked on the source, set them with --map-credential: %s", strings.Join(masked, ", "))
+	}
+	return nil
+}
+
+// withoutSecrets - the entity as JSON without its secrets, which each Healthbot holds encrypted with its own key
+func withoutSecrets(entity interface{}, fresh types.SecretHolder) string {
+	data, _ := json.Marshal(entity)
+	if fresh != nil {
+		_ = json.Unmarshal(data, fresh)
+		for _, secret := range fresh.Secrets() {
+			secret.Set(nil)
+		}
+		data, _ = json.Marshal(fresh)
+	}
+	return string(data)
+}
+
+// plan - the action for an entity given whether the target has it and if it is the same
+func (m *migration) plan(kind, name string, existing bool, same bool, onConflict string) bool {
+	action := createAction
+	switch {
+	case existing && same:
+		action = unchangedAction
+	case existing && onConflict == "overwrite":
+		action = updateAction
+	case existing && onConflict == "skip":
+		action = skipAction
+	case existing:
+		action = conflictAction
+	}
+	m.steps = append(m.steps, migrationStep{Kind: kind, Name: name, Action: action})
+	return action == createAction || action == updateAction
+}
+
+// planTarget - compares the source configuration with the target, keeping only what is to be created or updated
+func (m *migration) planTarget(target cmd.Config, onConflict string) error {
+	client, err := target.Client()
+	if err != nil {
+		return err
+	}
+	working, ctx := client.Working(), context.Background()
+	helperFiles, err := working.HelperFiles(ctx)
+	if err != nil {
+		return fmt.Errorf("problem retrieving Helper Files: %v", err)
+	}
+	playbooks, err := working.Playbooks(ctx)
+	if err != nil {
+		return fmt.Errorf("problem retrieving Playbooks: %v", err)
+	}
+	devices, err := working.Devices(ctx)
+	if err != nil {
+		return fmt.Errorf("problem retrieving Devices: %v", err)
+	}
+	deviceGroups, err := working.DeviceGroups(ctx)
+	if err != nil {
+		return fmt.Errorf("problem retrieving Device Groups: %v", err)
+	}
+	playbookInstances, err := working.PlaybookInstances(ctx)
+	if err != nil {
+		return fmt.Errorf("problem retrieving Playbook Instances: %v", err)
+	}
+	m.steps = nil
+
+	targetFiles := helperFiles.Checksums()
+	var files []types.HelperFile
+	for _, hf := range m.helperFiles {
+		checksum, existing := targetFiles[hf.FileName]
+		if m.plan("Helper File", hf.FileName, existing, checksum == hf.Checksum, onConflict) {
+			files = append(files, hf)
+		}
+	}
+	m.helperFiles = files
+
+	existingPlaybooks := map[string]string{}
+	for i, p := range playbooks.Playbooks {
+		existingPlaybooks[p.PlayBookName] = withoutSecrets(types.Playbooks{Playbooks: playbooks.Playbooks[i : i+1]}, nil)
+	}
+	kept := m.playbooks.Playbooks[:0]
+	for i, p := range m.playbooks.Playbooks {
+		existing, ok := existingPlaybooks[p.PlayBookName]
+		// wrapped in Playbooks so that the fields hb does not model are compared too
+		same := existing == withoutSecrets(types.Playbooks{Playbooks: m.playbooks.Playbooks[i : i+1]}, nil)
+		if m.plan("Playbook", p.PlayBookName, ok, same, onConflict) {
+			kept = append(kept, p)
+		}
+	}
+	m.playbooks.Playbooks = kept
+
+	existingDevices := map[string]string{}
+	for _, d := range devices.Device {
+		existingDevices[d.DeviceID] = withoutSecrets(types.Devices{Device: []types.Device{d}}, &types.Devices{})
+	}
+	var keptDevices []types.Device
+	for _, d := range m.devices.Device {
+		existing, ok := existingDevices[d.DeviceID]
+		if m.plan("Device", d.DeviceID, ok, existing == withoutSecrets(types.Devices{Device: []types.Device{d}}, &types.Devices{}), onConflict) {
+			keptDevices = append(keptDevices, d)
+		}
+	}
+
+	existingGroups := map[string]string{}
+	for _, dg := range deviceGroups.DeviceGroup {
+		existingGroups[dg.DeviceGroupName] = withoutSecrets(types.DeviceGroups{DeviceGroup: []types.DeviceGroup{dg}}, &types.DeviceGroups{})
+	}
+	var keptGroups []types.DeviceGroup
+	for _, dg := range m.deviceGroups.DeviceGroup {
+		existing, ok := existingGroups[dg.DeviceGroupName]
+		if m.plan("Device Group", dg.DeviceGroupName, ok, existing == withoutSecrets(types.DeviceGroups{DeviceGroup: []types.DeviceGroup{dg}}, &types.DeviceGroups{}), onConflict) {
+			keptGroups = append(keptGroups, dg)
+		}
+	}
+
+	existingInstances := map[string]string{}
+	for _, pi := range playbookInstances.DeviceGroup {
+		if len(pi.Variable) > 0 {
+			existingInstances[pi.DeviceGroupName] = withoutSecrets(pi.Variable, nil)
+		}
+	}
+	instances := m.playbookInstances.DeviceGroup[:0]
+	for _, pi := range m.playbookInstances.DeviceGroup {
+		existing, ok := existingInstances[pi.DeviceGroupName]
+		if m.plan("Playbook Instances", pi.DeviceGroupName, ok, existing == withoutSecrets(pi.Variable, nil), onConflict) {
+			instances = append(instances, pi)
+		}
+	}
+	m.playbookInstances.DeviceGroup = instances
+
+	// the Devices of a copied Device Group must be on the target, or be copied to it
+	onTarget := map[string]bool{}
+	for _, d := range devices.Device {
+		onTarget[d.DeviceID] = true
+	}
+	for _, d := range m.devices.Device {
+		onTarget[d.DeviceID] = true
+	}
+	for _, dg := range keptGroups {
+		if dg.Devices == nil {
+			continue
+		}
+		for _, d := range *dg.Devices {
+			if !onTarget[d] {
+				return fmt.Errorf("the Device Group %s uses the Device %s, which is neither copied nor on the target", dg.DeviceGroupName, d)
+			}
+		}
+	}
+	m.devices.Device = keptDevices
+	m.deviceGroups.DeviceGroup = keptGroups
+	if err := validateDeviceGroups(target, m.deviceGroups); err != nil {
+		return fmt.Errorf("the target is missing configuration the Device Groups use: %v", err)
+	}
+	return nil
+}
+
+// conflicts - the number of steps that are in conflict
+func (m *migration) conflicts() (n int) {
+	for _, s := range m.steps {
+		if s.Action == conflictAction {
+			n++
+		}
+	}
+	return
+}
+
+func writeMigrationPlan(w io.Writer, steps []migrationStep) {
+	if len(steps) == 0 {
+		fmt.Fprintln(w, "Nothing to migrate")
+		return
+	}
+	table := cmd.NewTableWriter(w)
+	table.SetHeader([]string{"Kind", "Name", "Action"})
+	for _, s := range steps {
+		table.Append([]string{s.Kind, s.Name, s.Action})
+	}
+	table.Render()
+}
+
+// copyHelperFile - downloads the Helper File from the source and uploads it to the target
+func copyHelperFile(source, target cmd.Config, name string) error {
+	from, err := source.Client()
+	if err != nil {
+		return err
+	}
+	to, err := target.Client()
+	if err != nil {
+		return err
+	}
+	content, err := from.HelperFile(context.Background(), name)
+	if err != nil {
+		return fmt.Errorf("problem downloading %s: %v", name, err)
+	}
+	if err := to.UploadHelperFile(context.Background(), name, bytes.NewReader(content)); err != nil {
+		return fmt.Errorf("problem uploading %s: %v", name, err)
+	}
+	return nil
+}
+
+// migrate - copies the configuration from the source to the target, printing the plan first
+func migrate(source, target cmd.Config, options migrateOptions, w io.Writer) error {
+	if source.Resource == target.Resource {
+		return fmt.Errorf("the source and target are both %s", source.Resource)
+	}
+	logging.Infof("Migrating from %s to %s", source.Resource, target.Resource)
+	m, err := readSource(source, options)
+	if err != nil {
+		return err
+	}
+	if err := mapCredentials([]types.SecretHolder{&m.devices, &m.deviceGroups}, options.Credentials); err != nil {
+		return err
+	}
+	if err := m.planTarget(target, options.OnConflict); err != nil {
+		return err
+	}
+	writeMigrationPlan(w, m.steps)
+	if n := m.conflicts(); n > 0 {
+		return fmt.Errorf("%v entities conflict with the target, use --on-conflict skip or overwrite", n)
+	}
+	if options.DryRun {
+		logging.Infof("Dry run, nothing was changed on %s", target.Resource)
+		return nil
+	}
+
+	for _, hf := range m.helperFiles {
+		if err := copyHelperFile(source, target, hf.FileName); err != nil {
+			return err
+		}
+	}
+	if len(m.helperFiles) > 0 {
+		logging.Infof("Successfully copied %v Helper Files", len(m.helperFiles))
+	}
+	if len(m.playbooks.Playbooks)+len(m.devices.Device)+len(m.deviceGroups.DeviceGroup)+len(m.playbookInstances.DeviceGroup) == 0 {
+		return nil
+	}
+	return transact(target, func(tx *transaction) error {
+		if len(m.playbooks.Playbooks) > 0 {
+			if err := createPlaybooks(tx, m.playbooks); err != nil {
+				return err
+			}
+		}
+		if len(m.devices.Device) > 0 {
+			if err := createDevices(tx, m.devices); err != nil {
+				return err
+			}
+		}
+		if len(m.deviceGroups.DeviceGroup) > 0 {
+			if err := createDeviceGroups(tx, m.deviceGroups); err != nil {
+				return err
+			}
+		}
+		if len(m.playbookInstances.DeviceGroup) > 0 {
+			if err := createPlaybookInstances(tx, m.playbookInstances); err != nil {
+				return err
+			}
+		}
+		tx.requestCommit()
+		return nil
+	})
+}
+
+func init() {
+	cmd.RootCmd.AddCommand(migrateCmd)
+
+	migrateCmd.Flags().String("from", "", "the context or resource to copy from")
+	migrateCmd.Flags().String("to", "", "the context or resource to copy to")
+	migrateCmd.Flags().StringSlice("group", nil, "copy only these Device Groups, with their Devices and Playbooks")
+	migrateCmd.Flags().StringSlice("device", nil, "copy only these Devices")
+	migrateCmd.Flags().StringSlice("map-credential", nil, "set the secrets matching a field or path from an environment variable e.g. password=NEW_PASSWORD")
+	migrateCmd.Flags().String("on-conflict", "fail", "what to do with an entity that differs on the target: fail, skip or overwrite")
+	migrateCmd.Flags().Bool("dry-run", false, "only print what would be created or updated on the target")
+	_ = migrateCmd.MarkFlagRequired("from")
+	_ = migrateCmd.MarkFlagRequired("to")
+	_ = migrateCmd.RegisterFlagCompletionFunc("from", cmd.CompleteContextNames)
+	_ = migrateCmd.RegisterFlagCompletionFunc("to", cmd.CompleteContextNames)
+	_ = migrateCmd.RegisterFlagCompletionFunc("group", cmd.CompleteDeviceGroupNames("from"))
+	_ = migrateCmd.RegisterFlagCompletionFunc("device", cmd.CompleteDeviceNames("from"))
+}
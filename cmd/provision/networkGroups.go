@@ -0,0 +1,100 @@
+package provision
+
+import (
+	"fmt"
+
+	"github.com/damianoneill/hb/cmd"
+	"github.com/damianoneill/hb/logging"
+	"github.com/damianoneill/hb/types"
+	"github.com/spf13/cobra"
+)
+
+// networkGroupsCmd represents the networkGroups command
+var networkGroupsCmd = &cobra.Command{
+	Use:   "network-groups",
+	Short: "Provision a set of Network Groups from configuration files.",
+	Long:  `The Network groups can be defined in YAML or JSON and conform to the payload definitions for the REST API.`,
+	Run: func(c *cobra.Command, args []string) {
+		rollout(c, func(config cmd.Config) error {
+			config.Directory = c.Flag("directory").Value.String()
+			config.Erase = c.Flag("erase").Value.String()
+			filenames, err := cmd.ConfigFiles(config)
+			if err != nil {
+				return err
+			}
+			return provisionNetworkGroups(config, filenames)
+		})
+	},
+}
+
+func deleteNetworkGroups(tx *transaction, networkGroups types.NetworkGroups) error {
+	for _, ng := range networkGroups.NetworkGroup {
+		resp, err := tx.delete(networkGroupsResource, ng.NetworkGroupName)
+		if err != nil {
+			return fmt.Errorf("problem posting to NetworkGroups %v", err)
+		}
+		if resp.StatusCode() != 204 {
+			return fmt.Errorf("problem updating Network Group %v: %v", ng.NetworkGroupName, resp.String())
+		}
+	}
+	logging.Infof("Successfully updated %v Network Groups", len(networkGroups.NetworkGroup))
+	return nil
+}
+
+func createNetworkGroups(tx *transaction, networkGroups types.NetworkGroups) error {
+	resp, err := tx.post(networkGroups, networkGroupsResource)
+	if err != nil {
+		return fmt.Errorf("problem posting to NetworkGroups %v", err)
+	}
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("problem updating Network Groups: %v", resp.String())
+	}
+	logging.Infof("Successfully updated %v Network Groups", len(networkGroups.NetworkGroup))
+	return nil
+}
+
+func validateNetworkGroups(config cmd.Config, networkGroups types.NetworkGroups) error {
+	var retentionPolicyNames, reportNames []string
+	for _, ng := range networkGroups.NetworkGroup {
+		if ng.RetentionPolicy != nil {
+			retentionPolicyNames = append(retentionPolicyNames, *ng.RetentionPolicy)
+		}
+		if ng.Reports != nil {
+			reportNames = append(reportNames, *ng.Reports...)
+		}
+	}
+	return checkGroupReferences(config, retentionPolicyNames, reportNames)
+}
+
+func provisionNetworkGroups(config cmd.Config, filenames []string) error {
+	return provisionDocuments(config, filenames, func(tx *transaction, document []byte) error {
+		var networkGroups types.NetworkGroups
+		if err := networkGroups.Parse(document); err != nil {
+			return err
+		}
+		if tx.config.Erase == "true" {
+			return deleteNetworkGroups(tx, networkGroups)
+		}
+		if err := validateNetworkGroups(tx.config, networkGroups); err != nil {
+			return err
+		}
+		return createNetworkGroups(tx, networkGroups)
+	})
+}
+
+func init() {
+	provisionCmd.AddCommand(networkGroupsCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// networkGroupsCmd.PersistentFlags().String("foo", "", "A help for foo")
+	networkGroupsCmd.PersistentFlags().StringP("directory", "d", "network-groups", "Default file location")
+
+	networkGroupsCmd.PersistentFlags().BoolP("erase", "e", false, "to erase this configuration")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// networkGroupsCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
@@ -6,13 +6,11 @@ package provision
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/damianoneill/hb/cmd"
+	"github.com/damianoneill/hb/logging"
 	"github.com/damianoneill/hb/types"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
-	"gopkg.in/resty.v1"
 )
 
 // playbookInstancesCmd represents the playbookInstances command
@@ -20,63 +18,47 @@ var playbookInstancesCmd = &cobra.Command{
 	Use:   "playbook-instances",
 	Short: "Provision Playbook Instances from configuration files.",
 	Long:  `The Playbook Instances can be defined in YAML or JSON and conform to the payload definitions for the REST API.`,
-	PreRun: func(cmd *cobra.Command, args []string) {
-		if viper.GetString("debug") == "true" {
-			resty.SetDebug(true)
-		}
-	},
 	Run: func(c *cobra.Command, args []string) {
-		config := cmd.NewConfig(c)
-		config.Directory = c.Flag("directory").Value.String()
-		config.Erase = c.Flag("erase").Value.String()
-		filenames := cmd.FilesInDirectory(config.Directory)
-		provisionPlaybookInstances(config, filenames)
+		rollout(c, func(config cmd.Config) error {
+			config.Directory = c.Flag("directory").Value.String()
+			config.Erase = c.Flag("erase").Value.String()
+			filenames, err := cmd.ConfigFiles(config)
+			if err != nil {
+				return err
+			}
+			return provisionPlaybookInstances(config, filenames)
+		})
 	},
 }
 
-func deletePlaybookInstances(config cmd.Config, playbookInstances types.PlaybookInstances) {
+func deletePlaybookInstances(tx *transaction, playbookInstances types.PlaybookInstances) error {
+	return nil
 }
 
-func createPlaybookInstances(config cmd.Config, playbookInstances types.PlaybookInstances) {
-	resp, err := cmd.POST(playbookInstances, config.Resource, "/api/v1/device-groups/", config.Username, config.Password)
-	if err != nil {
-		fmt.Printf("Problem posting to DeviceGroups %v", err)
-		return
-	}
-	switch resp.StatusCode() {
-	case 200:
-		fmt.Printf("Successfully updated %v %s", len(playbookInstances.DeviceGroup), "Device Groups \n")
-	default:
-		fmt.Printf("Problem updating Device Groups: %v \n", resp.String())
-		return
-	}
-
-	resp, err = cmd.POST(nil, config.Resource, "/api/v1/configuration/", config.Username, config.Password)
+func createPlaybookInstances(tx *transaction, playbookInstances types.PlaybookInstances) error {
+	resp, err := tx.post(playbookInstances, deviceGroupsResource)
 	if err != nil {
-		fmt.Printf("Problem posting to Configuration %v", err)
-		return
+		return fmt.Errorf("problem posting to Playbook Instances %v", err)
 	}
-	switch resp.StatusCode() {
-	case 200:
-		fmt.Printf("Successfully committed Playbook Instances configuration \n")
-	default:
-		fmt.Printf("Problem committing Playbook Instances configuration: %v \n", resp.String())
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("problem updating Playbook Instances: %v", resp.String())
 	}
-
+	logging.Infof("Successfully updated %v Device Groups", len(playbookInstances.DeviceGroup))
+	tx.requestCommit()
+	return nil
 }
 
-func provisionPlaybookInstances(config cmd.Config, filenames []string) {
-	for _, filename := range filenames {
+func provisionPlaybookInstances(config cmd.Config, filenames []string) error {
+	return provisionDocuments(config, filenames, func(tx *transaction, document []byte) error {
 		var playbookInstances types.PlaybookInstances
-		if err := types.LoadConfiguration(config.Directory+"/"+filename, &playbookInstances); err != nil {
-			log.Fatal("Problem with "+filename+" ", err)
+		if err := playbookInstances.Parse(document); err != nil {
+			return err
 		}
-		if config.Erase == "true" {
-			deletePlaybookInstances(config, playbookInstances)
-		} else {
-			createPlaybookInstances(config, playbookInstances)
+		if tx.config.Erase == "true" {
+			return deletePlaybookInstances(tx, playbookInstances)
 		}
-	}
+		return createPlaybookInstances(tx, playbookInstances)
+	})
 }
 
 func init() {
@@ -87,9 +69,9 @@ func init() {
 	// Cobra supports Persistent Flags which will work for this command
 	// and all subcommands, e.g.:
 	// playbookInstancesCmd.PersistentFlags().String("foo", "", "A help for foo")
-	provisionCmd.PersistentFlags().StringP("directory", "d", "playbook-instances", "Default file location")
+	playbookInstancesCmd.PersistentFlags().StringP("directory", "d", "playbook-instances", "Default file location")
 
-	provisionCmd.PersistentFlags().BoolP("erase", "e", false, "to erase this configuration")
+	playbookInstancesCmd.PersistentFlags().BoolP("erase", "e", false, "to erase this configuration")
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
 	// playbookInstancesCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
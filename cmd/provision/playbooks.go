@@ -6,13 +6,11 @@ package provision
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/damianoneill/hb/cmd"
+	"github.com/damianoneill/hb/logging"
 	"github.com/damianoneill/hb/types"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
-	"gopkg.in/resty.v1"
 )
 
 // playbooksCmd represents the playbook command
@@ -20,63 +18,47 @@ var playbooksCmd = &cobra.Command{
 	Use:   "playbook",
 	Short: "Provision Playbook from configuration files.",
 	Long:  `The Playbook can be defined in YAML or JSON and conform to the payload definitions for the REST API.`,
-	PreRun: func(cmd *cobra.Command, args []string) {
-		if viper.GetString("debug") == "true" {
-			resty.SetDebug(true)
-		}
-	},
 	Run: func(c *cobra.Command, args []string) {
-		config := cmd.NewConfig(c)
-		config.Erase = c.Flag("erase").Value.String()
-		config.Directory = c.Flag("directory").Value.String()
-		filenames := cmd.FilesInDirectory(config.Directory)
-		provisionPlaybooks(config, filenames)
+		rollout(c, func(config cmd.Config) error {
+			config.Erase = c.Flag("erase").Value.String()
+			config.Directory = c.Flag("directory").Value.String()
+			filenames, err := cmd.ConfigFiles(config)
+			if err != nil {
+				return err
+			}
+			return provisionPlaybooks(config, filenames)
+		})
 	},
 }
 
-func deletePlaybooks(config cmd.Config, playbooks types.Playbooks) {
+func deletePlaybooks(tx *transaction, playbooks types.Playbooks) error {
+	return nil
 }
 
-func createPlaybooks(config cmd.Config, playbooks types.Playbooks) {
-	resp, err := cmd.POST(playbooks, config.Resource, "/api/v1/playbooks/", config.Username, config.Password)
-	if err != nil {
-		fmt.Printf("Problem posting to DeviceGroups %v", err)
-		return
-	}
-	switch resp.StatusCode() {
-	case 200:
-		fmt.Printf("Successfully updated %v %s", len(playbooks.Playbooks), "Playbooks \n")
-	default:
-		fmt.Printf("Problem updating Device Groups: %v \n", resp.String())
-		return
-	}
-
-	resp, err = cmd.POST(nil, config.Resource, "/api/v1/configuration/", config.Username, config.Password)
+func createPlaybooks(tx *transaction, playbooks types.Playbooks) error {
+	resp, err := tx.post(playbooks, playbooksResource)
 	if err != nil {
-		fmt.Printf("Problem posting to Configuration %v", err)
-		return
+		return fmt.Errorf("problem posting to Playbooks %v", err)
 	}
-	switch resp.StatusCode() {
-	case 200:
-		fmt.Printf("Successfully committed Playbooks configuration \n")
-	default:
-		fmt.Printf("Problem committing Playbooks configuration: %v \n", resp.String())
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("problem updating Playbooks: %v", resp.String())
 	}
-
+	logging.Infof("Successfully updated %v Playbooks", len(playbooks.Playbooks))
+	tx.requestCommit()
+	return nil
 }
 
-func provisionPlaybooks(config cmd.Config, filenames []string) {
-	for _, filename := range filenames {
+func provisionPlaybooks(config cmd.Config, filenames []string) error {
+	return provisionDocuments(config, filenames, func(tx *transaction, document []byte) error {
 		var playbooks types.Playbooks
-		if err := types.LoadConfiguration(config.Directory+"/"+filename, &playbooks); err != nil {
-			log.Fatal("Problem with "+filename+" ", err)
+		if err := playbooks.Parse(document); err != nil {
+			return err
 		}
-		if config.Erase == "true" {
-			deletePlaybooks(config, playbooks)
-		} else {
-			createPlaybooks(config, playbooks)
+		if tx.config.Erase == "true" {
+			return deletePlaybooks(tx, playbooks)
 		}
-	}
+		return createPlaybooks(tx, playbooks)
+	})
 }
 
 func init() {
@@ -87,9 +69,9 @@ func init() {
 	// Cobra supports Persistent Flags which will work for this command
 	// and all subcommands, e.g.:
 	// playbookInstancesCmd.PersistentFlags().String("foo", "", "A help for foo")
-	provisionCmd.PersistentFlags().StringP("directory", "d", "playbooks", "Default file location")
+	playbooksCmd.PersistentFlags().StringP("directory", "d", "playbooks", "Default file location")
 
-	provisionCmd.PersistentFlags().BoolP("erase", "e", false, "to erase this configuration")
+	playbooksCmd.PersistentFlags().BoolP("erase", "e", false, "to erase this configuration")
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
 	// playbookInstancesCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
@@ -1,8 +1,16 @@
 package provision
 
 import (
-	//"github.com/damianoneill/hb/cmd"
-	"github.com/johnamac7/hb/cmd"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/damianoneill/hb/cmd"
+	"github.com/damianoneill/hb/logging"
+	"github.com/damianoneill/hb/types"
 	"github.com/spf13/cobra"
 )
 
@@ -10,12 +18,62 @@ import (
 var provisionCmd = &cobra.Command{
 	Use:   "provision",
 	Short: "Provision Healthbot Entities using config files.",
-	Long:  `Grouping for a set of commands for provisioning Healthbot Entities.`,
+	Long: `Grouping for a set of commands for provisioning Healthbot Entities.
+
+	With --servers or --all-contexts the Entities are provisioned to each server in turn, stopping at
+	the first server that fails so that the rest are left unchanged.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		_ = cmd.Usage()
 	},
 }
 
+// stdin - where the documents are read from with -f -, replaced in rolloutServers so each server gets them
+var stdin io.Reader = os.Stdin
+
+// rollout - provisions each of the servers in turn, stopping at the first that fails
+func rollout(c *cobra.Command, provision func(config cmd.Config) error) {
+	if err := rolloutServers(cmd.NewServers(c), provision); err != nil {
+		logging.Fatal(err)
+	}
+}
+
+// rolloutServers - provisions each of the servers in turn, standard input is read once up front so that
+// every server is provisioned with the same documents
+func rolloutServers(servers []cmd.Server, provision func(config cmd.Config) error) error {
+	var input []byte
+	if len(servers) > 0 && servers[0].Config.Filename == types.Stdin {
+		data, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return fmt.Errorf("problem reading stdin %v", err)
+		}
+		input = data
+		defer func(original io.Reader) { stdin = original }(stdin)
+	}
+	for i, server := range servers {
+		if len(servers) > 1 {
+			logging.Infof("Provisioning %s (%v of %v)", server.Name, i+1, len(servers))
+		}
+		if input != nil {
+			stdin = bytes.NewReader(input)
+		}
+		err := provision(server.Config)
+		if err != nil && len(servers) > 1 {
+			var skipped []string
+			for _, s := range servers[i+1:] {
+				skipped = append(skipped, s.Name)
+			}
+			err = fmt.Errorf("provisioning %s failed: %v", server.Name, err)
+			if len(skipped) > 0 {
+				err = fmt.Errorf("%v, not provisioned: %s", err, strings.Join(skipped, ", "))
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func init() {
 	cmd.RootCmd.AddCommand(provisionCmd)
 
@@ -24,6 +82,9 @@ func init() {
 	// Cobra supports Persistent Flags which will work for this command
 	// and all subcommands, e.g.:
 	// provisionCmd.PersistentFlags().String("foo", "", "A help for foo")
+	provisionCmd.PersistentFlags().StringP("filename", "f", "", "config file, directory or - for stdin, overrides the directory")
+
+	provisionCmd.PersistentFlags().BoolP("recursive", "R", false, "walk the subdirectories of the filename or directory")
 
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
@@ -0,0 +1,269 @@
+package provision
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/damianoneill/hb/cmd"
+	"github.com/damianoneill/hb/hbtest"
+	"github.com/damianoneill/hb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer() (*hbtest.Server, cmd.Config, func()) {
+	server := hbtest.NewServer()
+	ts := server.Start()
+	config := cmd.Config{Resource: hbtest.Resource(ts), Username: "admin", Password: "changeme"}
+	return server, config, ts.Close
+}
+
+func TestProvisionDevices(t *testing.T) {
+	server, config, stop := newTestServer()
+	defer stop()
+
+	filenames, err := types.FindConfigurationFiles("testdata/devices", false)
+	assert.Nil(t, err)
+	assert.Nil(t, provisionDevices(config, filenames))
+	assert.Equal(t, []string{"mx960-1", "mx960-3", "ex-1"}, server.Names("device"), "Expected every document to be provisioned")
+
+	config.Erase = "true"
+	assert.Nil(t, provisionDevices(config, filenames))
+	assert.Empty(t, server.Names("device"))
+}
+
+func TestProvisionPlaybooksCommits(t *testing.T) {
+	server, config, stop := newTestServer()
+	defer stop()
+
+	assert.Nil(t, provisionPlaybooks(config, []string{"testdata/playbooks.yml"}))
+	assert.Equal(t, []string{"chassis-kpis-playbook"}, server.Names("playbooks"))
+	assert.Equal(t, 1, server.Commits(), "Expected the Playbooks to be committed")
+}
+
+func TestValidateDeviceGroups(t *testing.T) {
+	server, config, stop := newTestServer()
+	defer stop()
+
+	retentionPolicy := "one-week"
+	deviceGroups := types.DeviceGroups{DeviceGroup: []types.DeviceGroup{{DeviceGroupName: "core", RetentionPolicy: &retentionPolicy}}}
+	assert.NotNil(t, validateDeviceGroups(config, deviceGroups), "Expected an unknown Retention Policy to be rejected")
+
+	assert.Nil(t, server.Seed(&types.RetentionPolicies{RetentionPolicy: []types.RetentionPolicy{{RetentionPolicyName: "one-week"}}}))
+	assert.Nil(t, validateDeviceGroups(config, deviceGroups))
+
+	deviceGroups.DeviceGroup[0].IngestFrequency = &[]string{"fast-interfaces"}
+	assert.NotNil(t, validateDeviceGroups(config, deviceGroups), "Expected an unknown Frequency Profile to be rejected")
+
+	assert.Nil(t, server.Seed(&types.FrequencyProfiles{FrequencyProfile: []types.FrequencyProfile{{Name: "fast-interfaces"}}}))
+	assert.Nil(t, validateDeviceGroups(config, deviceGroups))
+
+	level := "verbose"
+	deviceGroups.DeviceGroup[0].Logging = &types.Logging{LogLevel: &level}
+	assert.NotNil(t, validateDeviceGroups(config, deviceGroups), "Expected an invalid log level to be rejected")
+}
+
+func TestProvisionTopicsRetentionPolicy(t *testing.T) {
+	server, config, stop := newTestServer()
+	defer stop()
+	filenames := []string{"../../types/testdata/topics/topics.yml"}
+
+	err := provisionTopics(config, filenames)
+	assert.Contains(t, err.Error(), "Retention Policy one-week is not defined in Healthbot", "Expected the Rule's Retention Policy to be checked")
+	assert.Empty(t, server.Names("topic"))
+
+	assert.Nil(t, server.Seed(&types.RetentionPolicies{RetentionPolicy: []types.RetentionPolicy{{RetentionPolicyName: "one-week"}}}))
+	assert.Nil(t, provisionTopics(config, filenames))
+	assert.Equal(t, []string{"system.cpu"}, server.Names("topic"))
+
+	config.Erase = "true"
+	assert.Nil(t, provisionTopics(config, filenames))
+	assert.Empty(t, server.Names("topic"))
+}
+
+func seedCoreGroup(t *testing.T, server *hbtest.Server) {
+	description := "Core routers"
+	assert.Nil(t, server.Seed(&types.DeviceGroups{DeviceGroup: []types.DeviceGroup{{DeviceGroupName: "core", Description: &description}}}))
+}
+
+func TestProvisionRollsBack(t *testing.T) {
+	server, config, stop := newTestServer()
+	defer stop()
+	seedCoreGroup(t, server)
+
+	err := provisionDeviceGroups(config, []string{"testdata/device-groups/device-groups.yml"})
+	assert.Contains(t, err.Error(), "Retention Policy missing-policy is not defined in Healthbot, rolled back 2 changes")
+	assert.Equal(t, []string{"core"}, server.Names("device-group"), "Expected the created Device Group to be deleted")
+	assert.False(t, server.Uncommitted(), "Expected the modified Device Group to be restored")
+}
+
+func TestProvisionRollsBackErase(t *testing.T) {
+	server, config, stop := newTestServer()
+	defer stop()
+	seedCoreGroup(t, server)
+	dir, err := ioutil.TempDir("", "erase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "device-groups.yml")
+	assert.Nil(t, ioutil.WriteFile(filename, []byte("device-group:\n  - device-group-name: core\n  - device-group-name: nothere\n"), 0644))
+
+	config.Erase = "true"
+	err = provisionDeviceGroups(config, []string{filename})
+	assert.Contains(t, err.Error(), "rolled back 2 changes")
+	assert.Equal(t, []string{"core"}, server.Names("device-group"), "Expected the deleted Device Group to be created again")
+	for _, r := range server.Requests() {
+		assert.NotEqual(t, "/api/v1/configuration/", r.Path, "Expected the candidate configuration to be kept")
+	}
+}
+
+func TestProvisionDiscardsWhenRollbackFails(t *testing.T) {
+	server, config, stop := newTestServer()
+	defer stop()
+	seedCoreGroup(t, server)
+	server.Inject(hbtest.Fault{Method: "PUT", Path: "/api/v1/device-group/core/", Status: 500, Body: "simulated failure"})
+
+	err := provisionDeviceGroups(config, []string{"testdata/device-groups/device-groups.yml"})
+	assert.Contains(t, err.Error(), "so the candidate configuration was discarded")
+	assert.False(t, server.Uncommitted())
+}
+
+func TestTrackDocumentThenList(t *testing.T) {
+	_, config, stop := newTestServer()
+	defer stop()
+
+	assert.Nil(t, transact(config, func(tx *transaction) error {
+		body := map[string]interface{}{"device": []interface{}{map[string]interface{}{"device-id": "mx960-1"}}}
+		assert.Nil(t, tx.track(body, snmpNotificationResource, devicesResource))
+		assert.Len(t, tx.changes, 2, "Expected the resources after a document to be tracked")
+		assert.True(t, tx.tracked[devicesResource]["mx960-1"])
+		return nil
+	}))
+}
+
+func TestProvisionPlaybooksCommitFailure(t *testing.T) {
+	server, config, stop := newTestServer()
+	defer stop()
+	server.FailJobs("playbook is invalid")
+
+	err := provisionPlaybooks(config, []string{"testdata/playbooks.yml"})
+	assert.Contains(t, err.Error(), "playbook is invalid, rolled back 1 changes")
+	assert.Empty(t, server.Names("playbooks"))
+	assert.Equal(t, 0, server.Commits())
+}
+
+func TestCreateDeviceGroupsFault(t *testing.T) {
+	server, config, stop := newTestServer()
+	defer stop()
+	server.Inject(hbtest.Fault{Method: "POST", Path: "/api/v1/device-groups/", Status: 500, Body: "simulated failure"})
+
+	err := transact(config, func(tx *transaction) error {
+		return createDeviceGroups(tx, types.DeviceGroups{DeviceGroup: []types.DeviceGroup{{DeviceGroupName: "core"}}})
+	})
+	assert.Contains(t, err.Error(), "simulated failure")
+	assert.Empty(t, server.Names("device-group"), "Expected the failed post to leave no Device Groups")
+}
+
+func TestProvisionResolvesSecretReferences(t *testing.T) {
+	_, config, stop := newTestServer()
+	defer stop()
+	dir, err := ioutil.TempDir("", "provision")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "devices.yml")
+	assert.Nil(t, ioutil.WriteFile(filename, []byte(`device:
+  - device-id: mx1
+    host: 10.0.0.1
+    authentication:
+      password:
+        username: root
+        password: ${HB_SECRET_DEVICE_MX1_PASSWORD}
+`), 0600))
+
+	err = provisionDevices(config, []string{filename})
+	assert.Contains(t, err.Error(), "HB_SECRET_DEVICE_MX1_PASSWORD", "Expected an unset reference to be reported")
+
+	os.Setenv("HB_SECRET_DEVICE_MX1_PASSWORD", "s3cret: #1")
+	defer os.Unsetenv("HB_SECRET_DEVICE_MX1_PASSWORD")
+	assert.Nil(t, provisionDevices(config, []string{filename}))
+	resp, err := cmd.GET(config.Resource, "/api/v1/device/mx1/?working=true", config.Username, config.Password)
+	assert.Nil(t, err)
+	assert.Contains(t, resp.String(), `"password":"s3cret: #1"`)
+}
+
+func TestMigrate(t *testing.T) {
+	source, from, stopSource := newTestServer()
+	defer stopSource()
+	target, to, stopTarget := newTestServer()
+	defer stopTarget()
+	assert.Nil(t, source.LoadScaffold("../../hbtest/testdata/scaffold"))
+	masked := "****"
+	access := types.Devices{Device: []types.Device{{DeviceID: "ex-1", Host: "10.0.1.1", Authentication: &types.Authentication{}}}}
+	access.Device[0].Authentication.Password.Password = &masked
+	assert.Nil(t, source.Seed(&access))
+	assert.Nil(t, source.Seed(&types.DeviceGroups{DeviceGroup: []types.DeviceGroup{{DeviceGroupName: "access", Devices: &[]string{"ex-1"}}}}))
+	assert.Nil(t, target.Seed(&types.Devices{Device: []types.Device{{DeviceID: "mx960-3", Host: "10.9.9.9"}}}))
+
+	var out bytes.Buffer
+	err := migrate(from, to, migrateOptions{OnConflict: "fail"}, &out)
+	assert.Contains(t, err.Error(), "secrets are masked on the source, set them with --map-credential: device/ex-1/password")
+
+	err = migrate(from, to, migrateOptions{Groups: []string{"core"}, OnConflict: "fail"}, &out)
+	assert.EqualError(t, err, "1 entities conflict with the target, use --on-conflict skip or overwrite")
+	assert.Regexp(t, `Device\s+mx960-3\s+conflict`, out.String())
+
+	out.Reset()
+	assert.Nil(t, migrate(from, to, migrateOptions{Groups: []string{"core"}, OnConflict: "skip", DryRun: true}, &out))
+	assert.Regexp(t, `Device\s+mx960-1\s+create`, out.String())
+	assert.Regexp(t, `Device\s+mx960-3\s+skip`, out.String())
+	assert.Regexp(t, `Helper File\s+lib/util.py\s+create`, out.String())
+	assert.NotContains(t, out.String(), "ex-1", "Expected only the Devices of the core group")
+	assert.Equal(t, []string{"mx960-3"}, target.Names("device"), "Expected a dry run to change nothing")
+
+	os.Setenv("HB_MIGRATE_PASSWORD", "new-password")
+	defer os.Unsetenv("HB_MIGRATE_PASSWORD")
+	options := migrateOptions{OnConflict: "overwrite", Credentials: []credentialMapping{{Pattern: "device/ex-*/password", Env: "HB_MIGRATE_PASSWORD"}}}
+	assert.Nil(t, migrate(from, to, options, &out))
+	assert.Equal(t, []string{"mx960-3", "mx960-1", "ex-1"}, target.Names("device"))
+	assert.Equal(t, []string{"core", "access"}, target.Names("device-group"))
+	assert.Equal(t, []string{"chassis-kpis-playbook"}, target.Names("playbooks"))
+	_, ok := target.HelperFile("lib/util.py")
+	assert.True(t, ok, "Expected the Helper Files to be copied")
+	assert.Equal(t, 1, target.Commits())
+
+	var devices types.Devices
+	assert.Nil(t, getConfiguration(to, devicesResource.listPath, &devices))
+	assert.Equal(t, "new-password", *devices.Device[2].Authentication.Password.Password)
+	assert.Equal(t, "172.30.177.113", devices.Device[0].Host, "Expected the conflicting Device to be overwritten")
+
+	out.Reset()
+	assert.Nil(t, migrate(from, to, options, &out))
+	assert.NotRegexp(t, `create|update`, out.String(), "Expected nothing to be copied twice")
+	assert.Equal(t, 1, target.Commits())
+}
+
+func TestRolloutStdinToEveryServer(t *testing.T) {
+	lab, labConfig, stopLab := newTestServer()
+	defer stopLab()
+	emea, emeaConfig, stopEmea := newTestServer()
+	defer stopEmea()
+	labConfig.Filename, emeaConfig.Filename = types.Stdin, types.Stdin
+	servers := []cmd.Server{{Name: "lab", Config: labConfig}, {Name: "emea", Config: emeaConfig}}
+
+	defer func() { stdin = os.Stdin }()
+	stdin = strings.NewReader("device:\n- device-id: mx1\n  host: 10.0.0.1\n---\ndevice:\n- device-id: mx2\n  host: 10.0.0.2\n")
+	err := rolloutServers(servers, func(config cmd.Config) error {
+		filenames, err := cmd.ConfigFiles(config)
+		if err != nil {
+			return err
+		}
+		return provisionDevices(config, filenames)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mx1", "mx2"}, lab.Names("device"))
+	assert.Equal(t, []string{"mx1", "mx2"}, emea.Names("device"), "Expected the second server to get the documents from stdin too")
+}
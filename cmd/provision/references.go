@@ -0,0 +1,76 @@
+package provision
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/damianoneill/hb/cmd"
+	"github.com/damianoneill/hb/healthbot"
+)
+
+// getConfiguration - retrieves the existing configuration for a resource from Healthbot, including uncommitted changes
+func getConfiguration(config cmd.Config, path string, configuration interface{}) error {
+	resp, err := cmd.GET(config.Resource, path+"?working=true", config.Username, config.Password)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("problem retrieving %s: %v", path, resp.String())
+	}
+	return json.Unmarshal(resp.Body(), configuration)
+}
+
+// workingClient - a client for the configuration in Healthbot, including uncommitted changes
+func workingClient(config cmd.Config) (*healthbot.Client, error) {
+	client, err := config.Client()
+	if err != nil {
+		return nil, err
+	}
+	return client.Working(), nil
+}
+
+// checkReferences - ensures each referenced name is one of the known names
+func checkReferences(kind string, referenced, known []string) error {
+	names := map[string]bool{}
+	for _, name := range known {
+		names[name] = true
+	}
+	for _, name := range referenced {
+		if !names[name] {
+			return fmt.Errorf("%s %s is not defined in Healthbot", kind, name)
+		}
+	}
+	return nil
+}
+
+// checkGroupReferences - ensures the Retention Policies and Reports used by a Device or Network Group, or a Rule, exist
+func checkGroupReferences(config cmd.Config, retentionPolicyNames, reportNames []string) error {
+	if len(retentionPolicyNames) > 0 {
+		client, err := workingClient(config)
+		if err != nil {
+			return err
+		}
+		retentionPolicies, err := client.RetentionPolicies(context.Background())
+		if err != nil {
+			return fmt.Errorf("problem retrieving Retention Policies: %v", err)
+		}
+		if err := checkReferences("Retention Policy", retentionPolicyNames, retentionPolicies.Names()); err != nil {
+			return err
+		}
+	}
+	if len(reportNames) > 0 {
+		client, err := workingClient(config)
+		if err != nil {
+			return err
+		}
+		reports, err := client.Reports(context.Background())
+		if err != nil {
+			return fmt.Errorf("problem retrieving Reports: %v", err)
+		}
+		if err := checkReferences("Report", reportNames, reports.Names()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/damianoneill/hb/cmd"
+	"github.com/damianoneill/hb/logging"
+	"github.com/damianoneill/hb/types"
+	"github.com/spf13/cobra"
+)
+
+// reportsCmd represents the reports command
+var reportsCmd = &cobra.Command{
+	Use:   "reports",
+	Short: "Provision a set of Reports from configuration files.",
+	Long:  `The Reports can be defined in YAML or JSON and conform to the payload definitions for the REST API.`,
+	Run: func(c *cobra.Command, args []string) {
+		rollout(c, func(config cmd.Config) error {
+			config.Directory = c.Flag("directory").Value.String()
+			config.Erase = c.Flag("erase").Value.String()
+			filenames, err := cmd.ConfigFiles(config)
+			if err != nil {
+				return err
+			}
+			return provisionReports(config, filenames)
+		})
+	},
+}
+
+func deleteReports(tx *transaction, reports types.Reports) error {
+	for _, r := range reports.Report {
+		resp, err := tx.delete(reportsResource, r.Name)
+		if err != nil {
+			return fmt.Errorf("problem posting to Reports %v", err)
+		}
+		if resp.StatusCode() != 204 {
+			return fmt.Errorf("problem updating Report %v: %v", r.Name, resp.String())
+		}
+	}
+	logging.Infof("Successfully updated %v Reports", len(reports.Report))
+	return nil
+}
+
+func createReports(tx *transaction, reports types.Reports) error {
+	resp, err := tx.post(reports, reportsResource)
+	if err != nil {
+		return fmt.Errorf("problem posting to Reports %v", err)
+	}
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("problem updating Reports: %v", resp.String())
+	}
+	logging.Infof("Successfully updated %v Reports", len(reports.Report))
+	return nil
+}
+
+func validateReports(config cmd.Config, reports types.Reports) error {
+	var destinationNames, schedulerNames []string
+	for _, r := range reports.Report {
+		if r.Destination != nil {
+			destinationNames = append(destinationNames, *r.Destination...)
+		}
+		if r.Schedule != nil {
+			schedulerNames = append(schedulerNames, *r.Schedule...)
+		}
+	}
+	if len(destinationNames) > 0 {
+		client, err := workingClient(config)
+		if err != nil {
+			return err
+		}
+		destinations, err := client.Destinations(context.Background())
+		if err != nil {
+			return fmt.Errorf("problem retrieving Destinations: %v", err)
+		}
+		if err := checkReferences("Destination", destinationNames, destinations.Names()); err != nil {
+			return err
+		}
+	}
+	if len(schedulerNames) > 0 {
+		client, err := workingClient(config)
+		if err != nil {
+			return err
+		}
+		schedulers, err := client.Schedulers(context.Background())
+		if err != nil {
+			return fmt.Errorf("problem retrieving Schedulers: %v", err)
+		}
+		if err := checkReferences("Scheduler", schedulerNames, schedulers.Names()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func provisionReports(config cmd.Config, filenames []string) error {
+	return provisionDocuments(config, filenames, func(tx *transaction, document []byte) error {
+		var reports types.Reports
+		if err := reports.Parse(document); err != nil {
+			return err
+		}
+		if tx.config.Erase == "true" {
+			return deleteReports(tx, reports)
+		}
+		if err := validateReports(tx.config, reports); err != nil {
+			return err
+		}
+		return createReports(tx, reports)
+	})
+}
+
+func init() {
+	provisionCmd.AddCommand(reportsCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// reportsCmd.PersistentFlags().String("foo", "", "A help for foo")
+	reportsCmd.PersistentFlags().StringP("directory", "d", "reports", "Default file location")
+
+	reportsCmd.PersistentFlags().BoolP("erase", "e", false, "to erase this configuration")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// reportsCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
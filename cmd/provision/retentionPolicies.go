@@ -0,0 +1,87 @@
+package provision
+
+import (
+	"fmt"
+
+	"github.com/damianoneill/hb/cmd"
+	"github.com/damianoneill/hb/logging"
+	"github.com/damianoneill/hb/types"
+	"github.com/spf13/cobra"
+)
+
+// retentionPoliciesCmd represents the retentionPolicies command
+var retentionPoliciesCmd = &cobra.Command{
+	Use:   "retention-policies",
+	Short: "Provision a set of Retention Policies from configuration files.",
+	Long:  `The Retention Policies can be defined in YAML or JSON and conform to the payload definitions for the REST API.`,
+	Run: func(c *cobra.Command, args []string) {
+		rollout(c, func(config cmd.Config) error {
+			config.Directory = c.Flag("directory").Value.String()
+			config.Erase = c.Flag("erase").Value.String()
+			filenames, err := cmd.ConfigFiles(config)
+			if err != nil {
+				return err
+			}
+			return provisionRetentionPolicies(config, filenames)
+		})
+	},
+}
+
+func deleteRetentionPolicies(tx *transaction, retentionPolicies types.RetentionPolicies) error {
+	for _, rp := range retentionPolicies.RetentionPolicy {
+		resp, err := tx.delete(retentionPoliciesResource, rp.RetentionPolicyName)
+		if err != nil {
+			return fmt.Errorf("problem posting to Retention Policies %v", err)
+		}
+		if resp.StatusCode() != 204 {
+			return fmt.Errorf("problem updating Retention Policy %v: %v", rp.RetentionPolicyName, resp.String())
+		}
+	}
+	logging.Infof("Successfully updated %v Retention Policies", len(retentionPolicies.RetentionPolicy))
+	return nil
+}
+
+func createRetentionPolicies(tx *transaction, retentionPolicies types.RetentionPolicies) error {
+	resp, err := tx.post(retentionPolicies, retentionPoliciesResource)
+	if err != nil {
+		return fmt.Errorf("problem posting to Retention Policies %v", err)
+	}
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("problem updating Retention Policies: %v", resp.String())
+	}
+	logging.Infof("Successfully updated %v Retention Policies", len(retentionPolicies.RetentionPolicy))
+	return nil
+}
+
+func provisionRetentionPolicies(config cmd.Config, filenames []string) error {
+	return provisionDocuments(config, filenames, func(tx *transaction, document []byte) error {
+		var retentionPolicies types.RetentionPolicies
+		if err := retentionPolicies.Parse(document); err != nil {
+			return err
+		}
+		if tx.config.Erase == "true" {
+			return deleteRetentionPolicies(tx, retentionPolicies)
+		}
+		if err := retentionPolicies.Validate(); err != nil {
+			return err
+		}
+		return createRetentionPolicies(tx, retentionPolicies)
+	})
+}
+
+func init() {
+	provisionCmd.AddCommand(retentionPoliciesCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// retentionPoliciesCmd.PersistentFlags().String("foo", "", "A help for foo")
+	retentionPoliciesCmd.PersistentFlags().StringP("directory", "d", "retention-policies", "Default file location")
+
+	retentionPoliciesCmd.PersistentFlags().BoolP("erase", "e", false, "to erase this configuration")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// retentionPoliciesCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
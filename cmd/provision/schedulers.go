@@ -0,0 +1,84 @@
+package provision
+
+import (
+	"fmt"
+
+	"github.com/damianoneill/hb/cmd"
+	"github.com/damianoneill/hb/logging"
+	"github.com/damianoneill/hb/types"
+	"github.com/spf13/cobra"
+)
+
+// schedulersCmd represents the schedulers command
+var schedulersCmd = &cobra.Command{
+	Use:   "schedulers",
+	Short: "Provision a set of Schedulers from configuration files.",
+	Long:  `The Schedulers can be defined in YAML or JSON and conform to the payload definitions for the REST API.`,
+	Run: func(c *cobra.Command, args []string) {
+		rollout(c, func(config cmd.Config) error {
+			config.Directory = c.Flag("directory").Value.String()
+			config.Erase = c.Flag("erase").Value.String()
+			filenames, err := cmd.ConfigFiles(config)
+			if err != nil {
+				return err
+			}
+			return provisionSchedulers(config, filenames)
+		})
+	},
+}
+
+func deleteSchedulers(tx *transaction, schedulers types.Schedulers) error {
+	for _, s := range schedulers.Scheduler {
+		resp, err := tx.delete(schedulersResource, s.Name)
+		if err != nil {
+			return fmt.Errorf("problem posting to Schedulers %v", err)
+		}
+		if resp.StatusCode() != 204 {
+			return fmt.Errorf("problem updating Scheduler %v: %v", s.Name, resp.String())
+		}
+	}
+	logging.Infof("Successfully updated %v Schedulers", len(schedulers.Scheduler))
+	return nil
+}
+
+func createSchedulers(tx *transaction, schedulers types.Schedulers) error {
+	resp, err := tx.post(schedulers, schedulersResource)
+	if err != nil {
+		return fmt.Errorf("problem posting to Schedulers %v", err)
+	}
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("problem updating Schedulers: %v", resp.String())
+	}
+	logging.Infof("Successfully updated %v Schedulers", len(schedulers.Scheduler))
+	return nil
+}
+
+func provisionSchedulers(config cmd.Config, filenames []string) error {
+	return provisionDocuments(config, filenames, func(tx *transaction, document []byte) error {
+		var schedulers types.Schedulers
+		if err := schedulers.Parse(document); err != nil {
+			return err
+		}
+		if tx.config.Erase == "true" {
+			return deleteSchedulers(tx, schedulers)
+		}
+		return createSchedulers(tx, schedulers)
+	})
+}
+
+func init() {
+	provisionCmd.AddCommand(schedulersCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// schedulersCmd.PersistentFlags().String("foo", "", "A help for foo")
+	schedulersCmd.PersistentFlags().StringP("directory", "d", "schedulers", "Default file location")
+
+	schedulersCmd.PersistentFlags().BoolP("erase", "e", false, "to erase this configuration")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// schedulersCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
@@ -0,0 +1,82 @@
+package provision
+
+import (
+	"fmt"
+
+	"github.com/damianoneill/hb/cmd"
+	"github.com/damianoneill/hb/logging"
+	"github.com/damianoneill/hb/types"
+	"github.com/spf13/cobra"
+)
+
+// snmpNotificationCmd represents the snmpNotification command
+var snmpNotificationCmd = &cobra.Command{
+	Use:   "snmp-notification",
+	Short: "Provision the SNMP Notification ingest settings from configuration files.",
+	Long:  `The SNMP Notification (trap) ingest settings can be defined in YAML or JSON and conform to the payload definitions for the REST API.`,
+	Run: func(c *cobra.Command, args []string) {
+		rollout(c, func(config cmd.Config) error {
+			config.Directory = c.Flag("directory").Value.String()
+			config.Erase = c.Flag("erase").Value.String()
+			filenames, err := cmd.ConfigFiles(config)
+			if err != nil {
+				return err
+			}
+			return provisionSnmpNotification(config, filenames)
+		})
+	},
+}
+
+func deleteSnmpNotification(tx *transaction) error {
+	resp, err := tx.delete(snmpNotificationResource, "")
+	if err != nil {
+		return fmt.Errorf("problem posting to SNMP Notification %v", err)
+	}
+	if resp.StatusCode() != 204 {
+		return fmt.Errorf("problem updating SNMP Notification: %v", resp.String())
+	}
+	logging.Infof("Successfully updated SNMP Notification")
+	return nil
+}
+
+func createSnmpNotification(tx *transaction, snmpNotification types.SnmpNotification) error {
+	resp, err := tx.post(snmpNotification, snmpNotificationResource)
+	if err != nil {
+		return fmt.Errorf("problem posting to SNMP Notification %v", err)
+	}
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("problem updating SNMP Notification: %v", resp.String())
+	}
+	logging.Infof("Successfully updated SNMP Notification")
+	return nil
+}
+
+func provisionSnmpNotification(config cmd.Config, filenames []string) error {
+	return provisionDocuments(config, filenames, func(tx *transaction, document []byte) error {
+		var snmpNotification types.SnmpNotification
+		if err := snmpNotification.Parse(document); err != nil {
+			return err
+		}
+		if tx.config.Erase == "true" {
+			return deleteSnmpNotification(tx)
+		}
+		return createSnmpNotification(tx, snmpNotification)
+	})
+}
+
+func init() {
+	provisionCmd.AddCommand(snmpNotificationCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// snmpNotificationCmd.PersistentFlags().String("foo", "", "A help for foo")
+	snmpNotificationCmd.PersistentFlags().StringP("directory", "d", "snmp-notification", "Default file location")
+
+	snmpNotificationCmd.PersistentFlags().BoolP("erase", "e", false, "to erase this configuration")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// snmpNotificationCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
@@ -0,0 +1,113 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/damianoneill/hb/cmd"
+	"github.com/damianoneill/hb/logging"
+	"github.com/damianoneill/hb/types"
+	"github.com/spf13/cobra"
+)
+
+// syslogCmd represents the syslog command
+var syslogCmd = &cobra.Command{
+	Use:   "syslog",
+	Short: "Provision Syslog Patterns and Pattern Sets from configuration files.",
+	Long:  `The Syslog ingest settings can be defined in YAML or JSON and conform to the payload definitions for the REST API.`,
+	Run: func(c *cobra.Command, args []string) {
+		rollout(c, func(config cmd.Config) error {
+			config.Directory = c.Flag("directory").Value.String()
+			config.Erase = c.Flag("erase").Value.String()
+			filenames, err := cmd.ConfigFiles(config)
+			if err != nil {
+				return err
+			}
+			return provisionSyslog(config, filenames)
+		})
+	},
+}
+
+func deleteSyslog(tx *transaction, syslog types.Syslog) error {
+	// Pattern Sets reference Patterns so are removed first
+	for _, ps := range syslog.PatternSet {
+		resp, err := tx.delete(syslogPatternSetsResource, ps.Name)
+		if err != nil {
+			return fmt.Errorf("problem posting to Syslog %v", err)
+		}
+		if resp.StatusCode() != 204 {
+			return fmt.Errorf("problem updating Pattern Set %v: %v", ps.Name, resp.String())
+		}
+	}
+	for _, p := range syslog.Pattern {
+		resp, err := tx.delete(syslogPatternsResource, p.Name)
+		if err != nil {
+			return fmt.Errorf("problem posting to Syslog %v", err)
+		}
+		if resp.StatusCode() != 204 {
+			return fmt.Errorf("problem updating Pattern %v: %v", p.Name, resp.String())
+		}
+	}
+	logging.Infof("Successfully updated %v Patterns and %v Pattern Sets", len(syslog.Pattern), len(syslog.PatternSet))
+	return nil
+}
+
+func createSyslog(tx *transaction, syslog types.Syslog) error {
+	resp, err := tx.post(syslog, syslogPatternsResource, syslogPatternSetsResource)
+	if err != nil {
+		return fmt.Errorf("problem posting to Syslog %v", err)
+	}
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("problem updating Syslog: %v", resp.String())
+	}
+	logging.Infof("Successfully updated %v Patterns and %v Pattern Sets", len(syslog.Pattern), len(syslog.PatternSet))
+	return nil
+}
+
+func validateSyslog(config cmd.Config, syslog types.Syslog) error {
+	// Patterns already in Healthbot can be used by the Pattern Sets in this file
+	var existing types.Syslog
+	if len(syslog.PatternSet) > 0 {
+		client, err := workingClient(config)
+		if err != nil {
+			return err
+		}
+		if existing, err = client.Syslog(context.Background()); err != nil {
+			return fmt.Errorf("problem retrieving Syslog: %v", err)
+		}
+	}
+	return syslog.Validate(existing.PatternNames())
+}
+
+func provisionSyslog(config cmd.Config, filenames []string) error {
+	return provisionDocuments(config, filenames, func(tx *transaction, document []byte) error {
+		var syslog types.Syslog
+		if err := syslog.Parse(document); err != nil {
+			return err
+		}
+		if tx.config.Erase == "true" {
+			return deleteSyslog(tx, syslog)
+		}
+		if err := validateSyslog(tx.config, syslog); err != nil {
+			return err
+		}
+		return createSyslog(tx, syslog)
+	})
+}
+
+func init() {
+	provisionCmd.AddCommand(syslogCmd)
+
+	// Here you will define your flags and configuration settings.
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	// syslogCmd.PersistentFlags().String("foo", "", "A help for foo")
+	syslogCmd.PersistentFlags().StringP("directory", "d", "syslog", "Default file location")
+
+	syslogCmd.PersistentFlags().BoolP("erase", "e", false, "to erase this configuration")
+
+	// Cobra supports local flags which will only run when this command
+	// is called directly, e.g.:
+	// syslogCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+}
@@ -0,0 +1,86 @@
+package provision
+
+import (
+	"fmt"
+
+	"github.com/damianoneill/hb/cmd"
+	"github.com/damianoneill/hb/logging"
+	"github.com/damianoneill/hb/types"
+	"github.com/spf13/cobra"
+)
+
+// topicsCmd represents the topics command
+var topicsCmd = &cobra.Command{
+	Use:   "topics",
+	Short: "Provision a set of Topics and their Rules from configuration files.",
+	Long: `The Topics can be defined in YAML or JSON and conform to the payload definitions for the REST API.
+	The Retention Policy used by a Rule must be defined in Healthbot.`,
+	Run: func(c *cobra.Command, args []string) {
+		rollout(c, func(config cmd.Config) error {
+			config.Directory = c.Flag("directory").Value.String()
+			config.Erase = c.Flag("erase").Value.String()
+			filenames, err := cmd.ConfigFiles(config)
+			if err != nil {
+				return err
+			}
+			return provisionTopics(config, filenames)
+		})
+	},
+}
+
+func deleteTopics(tx *transaction, topics types.Topics) error {
+	for _, t := range topics.Topic {
+		resp, err := tx.delete(topicsResource, t.TopicName)
+		if err != nil {
+			return fmt.Errorf("problem posting to Topics %v", err)
+		}
+		if resp.StatusCode() != 204 {
+			return fmt.Errorf("problem updating Topic %v: %v", t.TopicName, resp.String())
+		}
+	}
+	logging.Infof("Successfully updated %v Topics", len(topics.Topic))
+	return nil
+}
+
+func createTopics(tx *transaction, topics types.Topics) error {
+	resp, err := tx.post(topics, topicsResource)
+	if err != nil {
+		return fmt.Errorf("problem posting to Topics %v", err)
+	}
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("problem updating Topics: %v", resp.String())
+	}
+	logging.Infof("Successfully updated %v Topics", len(topics.Topic))
+	return nil
+}
+
+func validateTopics(config cmd.Config, topics types.Topics) error {
+	if err := topics.Validate(); err != nil {
+		return err
+	}
+	return checkGroupReferences(config, topics.RetentionPolicies(), nil)
+}
+
+func provisionTopics(config cmd.Config, filenames []string) error {
+	return provisionDocuments(config, filenames, func(tx *transaction, document []byte) error {
+		var topics types.Topics
+		if err := topics.Parse(document); err != nil {
+			return err
+		}
+		if tx.config.Erase == "true" {
+			return deleteTopics(tx, topics)
+		}
+		if err := validateTopics(tx.config, topics); err != nil {
+			return err
+		}
+		return createTopics(tx, topics)
+	})
+}
+
+func init() {
+	provisionCmd.AddCommand(topicsCmd)
+
+	topicsCmd.PersistentFlags().StringP("directory", "d", "topics", "Default file location")
+
+	topicsCmd.PersistentFlags().BoolP("erase", "e", false, "to erase this configuration")
+}
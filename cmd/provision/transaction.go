@@ -0,0 +1,292 @@
+package provision
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/damianoneill/hb/cmd"
+	"github.com/damianoneill/hb/healthbot"
+	"github.com/damianoneill/hb/logging"
+	"github.com/damianoneill/hb/redact"
+	"github.com/damianoneill/hb/types"
+)
+
+// resource - where a kind of entity is posted, addressed and listed; a document when listKey is empty
+type resource struct {
+	listPath string
+	itemPath string
+	listKey  string
+	idKey    string
+}
+
+var (
+	devicesResource           = resource{"/api/v1/devices/", "/api/v1/device/", "device", "device-id"}
+	deviceGroupsResource      = resource{"/api/v1/device-groups/", "/api/v1/device-group/", "device-group", "device-group-name"}
+	networkGroupsResource     = resource{"/api/v1/network-groups/", "/api/v1/network-group/", "network-group", "network-group-name"}
+	playbooksResource         = resource{"/api/v1/playbooks/", "/api/v1/playbook/", "playbooks", "playbook-name"}
+	retentionPoliciesResource = resource{"/api/v1/retention-policies/", "/api/v1/retention-policy/", "retention-policy", "retention-policy-name"}
+	topicsResource            = resource{"/api/v1/topics/", "/api/v1/topic/", "topic", "topic-name"}
+	schedulersResource        = resource{"/api/v1/system-settings/schedulers/", "/api/v1/system-settings/scheduler/", "scheduler", "name"}
+	destinationsResource      = resource{"/api/v1/system-settings/report-generation/destinations/", "/api/v1/system-settings/report-generation/destination/", "destination", "name"}
+	reportsResource           = resource{"/api/v1/system-settings/report-generation/reports/", "/api/v1/system-settings/report-generation/report/", "report", "name"}
+	frequencyProfilesResource = resource{"/api/v1/ingest/frequency-profiles/", "/api/v1/ingest/frequency-profile/", "frequency-profile", "name"}
+	syslogPatternsResource    = resource{"/api/v1/ingest/syslog/", "/api/v1/ingest/syslog/pattern/", "pattern", "name"}
+	syslogPatternSetsResource = resource{"/api/v1/ingest/syslog/", "/api/v1/ingest/syslog/pattern-set/", "pattern-set", "name"}
+	snmpNotificationResource  = resource{"/api/v1/ingest/snmp-notification/", "", "", ""}
+)
+
+var errAborted = errors.New("provisioning was interrupted")
+
+// change - an entity modified by a provision run and its payload beforehand, nil if it did not exist
+type change struct {
+	resource resource
+	id       string
+	previous map[string]interface{}
+	deleted  bool
+}
+
+// transaction - the changes made by a provision run, so the run can leave Healthbot either fully updated or untouched
+//
+// On failure the changes are compensated in reverse order, re-creating or restoring what was deleted or modified
+// and deleting what was created, which leaves other uncommitted edits in the candidate configuration alone.
+// If that is not possible the whole candidate configuration is discarded.
+type transaction struct {
+	config cmd.Config
+
+	mu       sync.Mutex // held for each request, so an interrupt rolls back between requests
+	existing map[resource]map[string]map[string]interface{}
+	tracked  map[resource]map[string]bool
+	changes  []change
+	commit   bool
+	aborted  bool
+	finished bool // committed or rolled back, so an interrupt has nothing left to undo
+}
+
+// transact - runs the provisioning in a transaction, rolling it back on error or interrupt
+// and committing at the end if any step asked for it
+func transact(config cmd.Config, provision func(tx *transaction) error) error {
+	tx := &transaction{config: config, existing: map[resource]map[string]map[string]interface{}{}, tracked: map[resource]map[string]bool{}}
+
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	defer close(done)
+	go func() {
+		select {
+		case <-signals:
+			tx.mu.Lock()
+			if tx.finished {
+				tx.mu.Unlock()
+				return
+			}
+			tx.aborted = true
+			logging.Errorf("%v", tx.rollback(errAborted))
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+
+	err := provision(tx)
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	// the lock is released before done is closed, an interrupt waiting for it must not undo the outcome
+	defer func() { tx.finished = true }()
+	if err == nil && tx.commit {
+		if err = cmd.CommitConfiguration(config, cmd.DefaultJobOptions); err == nil {
+			logging.Infof("Successfully committed configuration")
+			return nil
+		}
+	}
+	if err != nil {
+		return tx.rollback(err)
+	}
+	return nil
+}
+
+// requestCommit - the configuration is committed once every file has been provisioned
+func (tx *transaction) requestCommit() {
+	tx.commit = true
+}
+
+// previous - the payload of an entity in the candidate configuration before this run, the caller holds the lock
+func (tx *transaction) previous(r resource, id string) (map[string]interface{}, error) {
+	entities, ok := tx.existing[r]
+	if !ok {
+		var body map[string]interface{}
+		if err := getConfiguration(tx.config, r.listPath, &body); err != nil {
+			return nil, err
+		}
+		entities = map[string]map[string]interface{}{}
+		if r.listKey == "" {
+			if len(body) > 0 {
+				entities[""] = body
+			}
+		} else {
+			items, _ := body[r.listKey].([]interface{})
+			for _, item := range items {
+				if e, ok := item.(map[string]interface{}); ok {
+					entities[fmt.Sprintf("%v", e[r.idKey])] = e
+				}
+			}
+		}
+		tx.existing[r] = entities
+	}
+	return entities[id], nil
+}
+
+// track - records the entities in the body as changed, the caller holds the lock
+func (tx *transaction) track(body interface{}, resources ...resource) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return err
+	}
+	for _, r := range resources {
+		if r.listKey == "" {
+			if err := tx.trackID(r, ""); err != nil {
+				return err
+			}
+			continue
+		}
+		items, _ := payload[r.listKey].([]interface{})
+		for _, item := range items {
+			if e, ok := item.(map[string]interface{}); ok {
+				if err := tx.trackID(r, fmt.Sprintf("%v", e[r.idKey])); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// trackID - only the first change to an entity is recorded, restoring its payload undoes the later ones too
+func (tx *transaction) trackID(r resource, id string) error {
+	if tx.tracked[r][id] {
+		return nil
+	}
+	previous, err := tx.previous(r, id)
+	if err != nil {
+		return err
+	}
+	if tx.tracked[r] == nil {
+		tx.tracked[r] = map[string]bool{}
+	}
+	tx.tracked[r][id] = true
+	tx.changes = append(tx.changes, change{resource: r, id: id, previous: previous})
+	return nil
+}
+
+// post - posts the body to the resource, recording the entities it contains for rollback
+func (tx *transaction) post(body interface{}, resources ...resource) (*healthbot.Response, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.aborted {
+		return nil, errAborted
+	}
+	if err := tx.track(body, resources...); err != nil {
+		return nil, err
+	}
+	return cmd.POST(body, tx.config.Resource, resources[0].listPath, tx.config.Username, tx.config.Password)
+}
+
+// delete - deletes an entity, or the document when the resource has no list key, recording it for rollback
+func (tx *transaction) delete(r resource, id string) (*healthbot.Response, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.aborted {
+		return nil, errAborted
+	}
+	if err := tx.trackID(r, id); err != nil {
+		return nil, err
+	}
+	for i := range tx.changes {
+		if tx.changes[i].resource == r && tx.changes[i].id == id {
+			tx.changes[i].deleted = true
+		}
+	}
+	return cmd.DELETE(tx.config.Resource, tx.path(r, id), tx.config.Username, tx.config.Password)
+}
+
+func (tx *transaction) path(r resource, id string) string {
+	if r.listKey == "" {
+		return r.listPath
+	}
+	return r.itemPath + id + "/"
+}
+
+// rollback - undoes the changes, the caller holds the lock; returns the cause with what was done about it
+func (tx *transaction) rollback(cause error) error {
+	if len(tx.changes) == 0 {
+		return cause
+	}
+	if err := tx.compensate(); err != nil {
+		if discardErr := cmd.DiscardConfiguration(tx.config); discardErr != nil {
+			return fmt.Errorf("%v, rolling back failed: %v, and discarding the candidate configuration failed: %v", cause, err, discardErr)
+		}
+		return fmt.Errorf("%v, rolling back failed: %v, so the candidate configuration was discarded", cause, err)
+	}
+	return fmt.Errorf("%v, rolled back %v changes", cause, len(tx.changes))
+}
+
+func (tx *transaction) compensate() error {
+	for i := len(tx.changes) - 1; i >= 0; i-- {
+		c := tx.changes[i]
+		var resp *healthbot.Response
+		var err error
+		switch {
+		case c.previous == nil:
+			resp, err = cmd.DELETE(tx.config.Resource, tx.path(c.resource, c.id), tx.config.Username, tx.config.Password)
+			if err == nil && resp.StatusCode() == 404 {
+				continue
+			}
+		case c.resource.listKey == "":
+			resp, err = cmd.POST(c.previous, tx.config.Resource, c.resource.listPath, tx.config.Username, tx.config.Password)
+		case c.deleted:
+			// a deleted entity can not be put back at its path, it is created again
+			body := map[string]interface{}{c.resource.listKey: []interface{}{c.previous}}
+			resp, err = cmd.POST(body, tx.config.Resource, c.resource.listPath, tx.config.Username, tx.config.Password)
+		default:
+			resp, err = cmd.PUT(c.previous, tx.config.Resource, tx.path(c.resource, c.id), tx.config.Username, tx.config.Password)
+		}
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode() >= 300 {
+			return fmt.Errorf("problem restoring %s %s: %v", c.resource.listPath, c.id, resp.String())
+		}
+	}
+	return nil
+}
+
+// provisionDocuments - applies every document in the files within a transaction
+func provisionDocuments(config cmd.Config, filenames []string, apply func(tx *transaction, document []byte) error) error {
+	return transact(config, func(tx *transaction) error {
+		for _, filename := range filenames {
+			documents, err := types.ReadDocuments(filename, stdin)
+			if err != nil {
+				return fmt.Errorf("problem with %s %v", filename, err)
+			}
+			for _, document := range documents {
+				// secrets exported by scaffold as references are read from the environment
+				document, err := redact.Resolve(document, os.LookupEnv)
+				if err != nil {
+					return fmt.Errorf("problem with %s %v", filename, err)
+				}
+				if err := apply(tx, document); err != nil {
+					return fmt.Errorf("problem with %s %v", filename, err)
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/damianoneill/hb/healthbot"
+	"github.com/damianoneill/hb/logging"
+	"github.com/spf13/cobra"
+)
+
+// queryCmd represents the query command
+var queryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Query the Healthbot time series data.",
+	Long: `Retrieves the time series data recorded by a Rule for a Device, e.g. the interface errors
+	for one device over the last 24 hours.
+
+		$ hb query --device mx960-1 --topic interface.statistics --rule check-interface-errors --field input-errors --since 24h
+
+	The points can be narrowed to one Playbook or Playbook instance with --playbook and --instance.
+	The data can be downsampled with --interval and --aggregate, and printed as a table, csv or json.
+	A raw InfluxQL query can be sent with --query, in which case --db names the database (<device-group>:<device-id>).`,
+	Run: func(c *cobra.Command, args []string) {
+		options, err := newQueryOptions(c)
+		if err == nil {
+			err = query(NewConfig(c), options)
+		}
+		if err != nil {
+			logging.Fatal(err)
+		}
+	},
+}
+
+type queryOptions struct {
+	Device    string
+	Group     string
+	Topic     string
+	Rule      string
+	Playbook  string
+	Instance  string
+	Fields    []string
+	Since     time.Duration
+	Interval  time.Duration
+	Aggregate string
+	Limit     int
+	Raw       string
+	Database  string
+	Output    string
+	Location  *time.Location
+}
+
+func newQueryOptions(c *cobra.Command) (options queryOptions, err error) {
+	flags := c.Flags()
+	options.Device, _ = flags.GetString("device")
+	options.Group, _ = flags.GetString("group")
+	options.Topic, _ = flags.GetString("topic")
+	options.Rule, _ = flags.GetString("rule")
+	options.Playbook, _ = flags.GetString("playbook")
+	options.Instance, _ = flags.GetString("instance")
+	options.Fields, _ = flags.GetStringSlice("field")
+	options.Since, _ = flags.GetDuration("since")
+	interval, _ := flags.GetString("interval")
+	options.Aggregate, _ = flags.GetString("aggregate")
+	options.Limit, _ = flags.GetInt("limit")
+	options.Raw, _ = flags.GetString("query")
+	options.Database, _ = flags.GetString("db")
+	options.Output, _ = flags.GetString("output")
+	timezone, _ := flags.GetString("timezone")
+	if options.Location, err = time.LoadLocation(timezone); err != nil {
+		return
+	}
+	switch options.Output {
+	case "table", "csv", "json":
+	default:
+		return options, fmt.Errorf("unknown output %s, expected table, csv or json", options.Output)
+	}
+	if interval != "" {
+		if options.Interval, err = time.ParseDuration(interval); err != nil {
+			return options, fmt.Errorf("invalid interval %s: %v", interval, err)
+		}
+		if options.Interval < time.Second {
+			return options, fmt.Errorf("invalid interval %s, expected at least 1s", interval)
+		}
+	}
+	if !isAggregate(options.Aggregate) {
+		return options, fmt.Errorf("unknown aggregate %s, expected one of %s", options.Aggregate, strings.Join(aggregates, ", "))
+	}
+	if options.Raw != "" {
+		if options.Database == "" {
+			return options, errors.New("--query requires --db")
+		}
+		return
+	}
+	if options.Device == "" || options.Topic == "" || options.Rule == "" {
+		return options, errors.New("query requires --device, --topic and --rule, or --query and --db")
+	}
+	return
+}
+
+// aggregates - the InfluxQL functions that can downsample a field
+var aggregates = []string{"count", "distinct", "first", "integral", "last", "max", "mean", "median", "min", "mode", "spread", "stddev", "sum"}
+
+func isAggregate(name string) bool {
+	for _, a := range aggregates {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteIdentifier - an InfluxQL identifier in double quotes, escaping any quote in the name
+func quoteIdentifier(name string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(name) + `"`
+}
+
+// quoteString - an InfluxQL string literal in single quotes, escaping any quote in the value
+func quoteString(value string) string {
+	return `'` + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + `'`
+}
+
+// buildQuery - the InfluxQL for the options, a raw query is used as is; downsampling without fields
+// aggregates every field, as GROUP BY time needs an aggregate
+func buildQuery(options queryOptions) string {
+	if options.Raw != "" {
+		return options.Raw
+	}
+	downsample := options.Interval > 0
+	fields := "*"
+	if downsample {
+		fields = fmt.Sprintf("%s(*)", options.Aggregate)
+	}
+	if len(options.Fields) > 0 {
+		var selected []string
+		for _, f := range options.Fields {
+			if downsample {
+				selected = append(selected, fmt.Sprintf(`%s(%s) AS %s`, options.Aggregate, quoteIdentifier(f), quoteIdentifier(f)))
+			} else {
+				selected = append(selected, quoteIdentifier(f))
+			}
+		}
+		fields = strings.Join(selected, ", ")
+	}
+	q := fmt.Sprintf(`SELECT %s FROM %s WHERE time > now() - %ds`, fields, quoteIdentifier(options.Topic+"/"+options.Rule), int64(options.Since.Seconds()))
+	if options.Playbook != "" {
+		q += fmt.Sprintf(` AND "_playbook_name" = %s`, quoteString(options.Playbook))
+	}
+	if options.Instance != "" {
+		q += fmt.Sprintf(` AND "_instance_id" = %s`, quoteString(options.Instance))
+	}
+	if downsample {
+		q += fmt.Sprintf(" GROUP BY time(%ds) fill(none)", int64(options.Interval.Seconds()))
+	}
+	if options.Limit > 0 {
+		q += fmt.Sprintf(" LIMIT %d", options.Limit)
+	}
+	return q
+}
+
+// deviceGroupFor - the first Device Group containing the Device, its database is <device-group>:<device-id>
+func deviceGroupFor(client *healthbot.Client, device string) (string, error) {
+	deviceGroups, err := client.DeviceGroups(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("problem retrieving Device Groups: %v", err)
+	}
+	for _, dg := range deviceGroups.DeviceGroup {
+		if dg.Devices == nil {
+			continue
+		}
+		for _, d := range *dg.Devices {
+			if d == device {
+				return dg.DeviceGroupName, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("device %s is not in a Device Group, use --group", device)
+}
+
+func query(config Config, options queryOptions) error {
+	client, err := config.Client()
+	if err != nil {
+		return err
+	}
+	database := options.Database
+	if options.Raw == "" {
+		group := options.Group
+		if group == "" {
+			if group, err = deviceGroupFor(client, options.Device); err != nil {
+				return err
+			}
+		}
+		database = group + ":" + options.Device
+	}
+	series, err := client.Query(context.Background(), database, buildQuery(options))
+	if err != nil {
+		return fmt.Errorf("problem querying Healthbot: %v", err)
+	}
+	return writeSeries(os.Stdout, series, options)
+}
+
+// formatValue - times are shown in the location, other values as is
+func formatValue(column string, value interface{}, location *time.Location) string {
+	if value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		if column == "time" {
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				return t.In(location).Format(time.RFC3339)
+			}
+		}
+		return s
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func writeSeries(w io.Writer, series []healthbot.Series, options queryOptions) error {
+	switch options.Output {
+	case "json":
+		// the values keep their json types, only the time is formatted in the time zone
+		rows := []map[string]interface{}{}
+		for _, s := range series {
+			for _, values := range s.Values {
+				row := map[string]interface{}{"series": s.Name}
+				for i, column := range s.Columns {
+					if i >= len(values) {
+						continue
+					}
+					if column == "time" {
+						row[column] = formatValue(column, values[i], options.Location)
+					} else {
+						row[column] = values[i]
+					}
+				}
+				rows = append(rows, row)
+			}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(rows)
+	case "csv":
+		// the series can have different columns, so each row has the columns of every series
+		var columns []string
+		seen := map[string]bool{}
+		for _, s := range series {
+			for _, column := range s.Columns {
+				if !seen[column] {
+					seen[column] = true
+					columns = append(columns, column)
+				}
+			}
+		}
+		writer := csv.NewWriter(w)
+		_ = writer.Write(append([]string{"series"}, columns...))
+		for _, s := range series {
+			for _, values := range s.Values {
+				row := map[string]string{}
+				for i, column := range s.Columns {
+					if i < len(values) {
+						row[column] = formatValue(column, values[i], options.Location)
+					}
+				}
+				record := []string{s.Name}
+				for _, column := range columns {
+					record = append(record, row[column])
+				}
+				_ = writer.Write(record)
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		for _, s := range series {
+			fmt.Fprintf(w, "\n%s \n\n", s.Name)
+			table := NewTableWriter(w)
+			table.SetHeader(s.Columns)
+			for _, values := range s.Values {
+				table.Append(formatRow(s.Columns, values, options.Location))
+			}
+			table.Render()
+		}
+		fmt.Fprintln(w, "")
+		return nil
+	}
+}
+
+func formatRow(columns []string, values []interface{}, location *time.Location) []string {
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		if i < len(values) {
+			row[i] = formatValue(column, values[i], location)
+		}
+	}
+	return row
+}
+
+func init() {
+	RootCmd.AddCommand(queryCmd)
+
+	queryCmd.Flags().String("device", "", "Device Id")
+	queryCmd.Flags().String("group", "", "Device Group, defaults to the first group containing the Device")
+	queryCmd.Flags().String("topic", "", "Topic e.g. interface.statistics")
+	queryCmd.Flags().String("rule", "", "Rule e.g. check-interface-errors")
+	queryCmd.Flags().String("playbook", "", "only points recorded by the Playbook")
+	queryCmd.Flags().String("instance", "", "only points recorded by the Playbook instance")
+	queryCmd.Flags().StringSlice("field", nil, "Field(s) to return, defaults to all")
+	queryCmd.Flags().Duration("since", 24*time.Hour, "how far back to query")
+	queryCmd.Flags().String("interval", "", "downsample into intervals e.g. 5m")
+	queryCmd.Flags().String("aggregate", "mean", "function used when downsampling: "+strings.Join(aggregates, ", "))
+	queryCmd.Flags().Int("limit", 0, "maximum number of points per series")
+	queryCmd.Flags().String("query", "", "raw InfluxQL query")
+	queryCmd.Flags().String("db", "", "database for a raw query e.g. <device-group>:<device-id>")
+	queryCmd.Flags().StringP("output", "o", "table", "output format table, csv or json")
+	queryCmd.Flags().String("timezone", "Local", "time zone for timestamps e.g. UTC, Europe/Dublin")
+
+	_ = queryCmd.RegisterFlagCompletionFunc("device", completeNames(deviceNames))
+	_ = queryCmd.RegisterFlagCompletionFunc("group", completeNames(deviceGroupNames))
+	_ = queryCmd.RegisterFlagCompletionFunc("playbook", completeNames(playbookNames))
+	_ = queryCmd.RegisterFlagCompletionFunc("instance", completeNames(playbookInstanceNames))
+}
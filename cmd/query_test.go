@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/damianoneill/hb/hbtest"
+	"github.com/damianoneill/hb/healthbot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildQuery(t *testing.T) {
+	options := queryOptions{Topic: "interface.statistics", Rule: "check-interface-errors", Fields: []string{"input-errors"}, Since: 24 * time.Hour}
+	assert.Equal(t, `SELECT "input-errors" FROM "interface.statistics/check-interface-errors" WHERE time > now() - 86400s`, buildQuery(options))
+
+	options.Interval = 5 * time.Minute
+	options.Aggregate = "max"
+	options.Limit = 10
+	assert.Equal(t, `SELECT max("input-errors") AS "input-errors" FROM "interface.statistics/check-interface-errors" WHERE time > now() - 86400s GROUP BY time(300s) fill(none) LIMIT 10`, buildQuery(options))
+
+	options = queryOptions{Topic: "system", Rule: "check-cpu", Playbook: "system-kpis", Instance: "core-1", Since: time.Hour}
+	assert.Equal(t, `SELECT * FROM "system/check-cpu" WHERE time > now() - 3600s AND "_playbook_name" = 'system-kpis' AND "_instance_id" = 'core-1'`, buildQuery(options))
+
+	options.Interval = 5 * time.Minute
+	options.Aggregate = "mean"
+	assert.Equal(t, `SELECT mean(*) FROM "system/check-cpu" WHERE time > now() - 3600s AND "_playbook_name" = 'system-kpis' AND "_instance_id" = 'core-1' GROUP BY time(300s) fill(none)`, buildQuery(options), "Expected every field to be aggregated")
+
+	options = queryOptions{Topic: `sys"tem`, Rule: "check-cpu", Fields: []string{`a"b`}, Playbook: "it's", Since: time.Hour}
+	assert.Equal(t, `SELECT "a\"b" FROM "sys\"tem/check-cpu" WHERE time > now() - 3600s AND "_playbook_name" = 'it\'s'`, buildQuery(options), "Expected quotes to be escaped")
+
+	assert.Equal(t, "SHOW MEASUREMENTS", buildQuery(queryOptions{Raw: "SHOW MEASUREMENTS"}))
+}
+
+func TestNewQueryOptions(t *testing.T) {
+	flags := queryCmd.Flags()
+	defer func() {
+		for _, name := range []string{"device", "topic", "rule", "interval", "aggregate"} {
+			f := flags.Lookup(name)
+			_ = f.Value.Set(f.DefValue)
+		}
+	}()
+	assert.Nil(t, flags.Set("device", "mx960-1"))
+	assert.Nil(t, flags.Set("topic", "system"))
+	assert.Nil(t, flags.Set("rule", "check-cpu"))
+
+	assert.Nil(t, flags.Set("interval", "5m"))
+	options, err := newQueryOptions(queryCmd)
+	assert.Nil(t, err)
+	assert.Equal(t, 5*time.Minute, options.Interval)
+
+	assert.Nil(t, flags.Set("interval", "5 minutes"))
+	_, err = newQueryOptions(queryCmd)
+	assert.NotNil(t, err, "Expected an interval that is not a duration to be rejected")
+	assert.Nil(t, flags.Set("interval", "0s"))
+	_, err = newQueryOptions(queryCmd)
+	assert.NotNil(t, err, "Expected an interval under a second to be rejected")
+
+	assert.Nil(t, flags.Set("interval", "1h"))
+	assert.Nil(t, flags.Set("aggregate", "mean(*) FROM x; DROP"))
+	_, err = newQueryOptions(queryCmd)
+	assert.NotNil(t, err, "Expected an unknown aggregate to be rejected")
+	assert.Contains(t, err.Error(), "unknown aggregate")
+}
+
+func TestWriteSeries(t *testing.T) {
+	kolkata, _ := time.LoadLocation("Asia/Kolkata")
+	series := []healthbot.Series{{Name: "interface.statistics/check-interface-errors", Columns: []string{"time", "input-errors"}, Values: [][]interface{}{{"2019-11-01T18:00:00Z", 3.0}, {"2019-11-01T18:05:00Z", nil}}}}
+
+	var out bytes.Buffer
+	assert.Nil(t, writeSeries(&out, series, queryOptions{Output: "csv", Location: kolkata}))
+	assert.Equal(t, "series,time,input-errors\ninterface.statistics/check-interface-errors,2019-11-01T23:30:00+05:30,3\ninterface.statistics/check-interface-errors,2019-11-01T23:35:00+05:30,\n", out.String(), "Expected timestamps in the time zone")
+
+	out.Reset()
+	cpu := healthbot.Series{Name: "system/check-cpu", Columns: []string{"time", "cpu"}, Values: [][]interface{}{{"2019-11-01T18:00:00Z", 42.0}}}
+	assert.Nil(t, writeSeries(&out, append(series[:1:1], cpu), queryOptions{Output: "csv", Location: time.UTC}))
+	assert.Equal(t, "series,time,input-errors,cpu\n"+
+		"interface.statistics/check-interface-errors,2019-11-01T18:00:00Z,3,\n"+
+		"interface.statistics/check-interface-errors,2019-11-01T18:05:00Z,,\n"+
+		"system/check-cpu,2019-11-01T18:00:00Z,,42\n", out.String(), "Expected the columns of every series")
+
+	out.Reset()
+	assert.Nil(t, writeSeries(&out, nil, queryOptions{Output: "json", Location: time.UTC}))
+	assert.Equal(t, "[]\n", out.String(), "Expected no rows to be an empty list")
+
+	out.Reset()
+	assert.Nil(t, writeSeries(&out, series, queryOptions{Output: "json", Location: time.UTC}))
+	var rows []map[string]interface{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &rows))
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "interface.statistics/check-interface-errors", rows[0]["series"])
+	assert.Equal(t, "2019-11-01T18:00:00Z", rows[0]["time"])
+	assert.Equal(t, 3.0, rows[0]["input-errors"], "Expected the values to stay numbers")
+	assert.Nil(t, rows[1]["input-errors"], "Expected a missing value to be null")
+}
+
+func TestQuery(t *testing.T) {
+	server, config, stop := newTestServer(t)
+	defer stop()
+	server.SetTimeSeries("core:mx960-1", map[string]interface{}{"results": []interface{}{map[string]interface{}{"series": []healthbot.Series{
+		{Name: "interface.statistics/check-interface-errors", Columns: []string{"time", "input-errors"}, Values: [][]interface{}{{"2019-11-01T18:00:00Z", 3}}},
+	}}}})
+
+	options := queryOptions{Device: "mx960-1", Topic: "interface.statistics", Rule: "check-interface-errors", Since: time.Hour, Output: "csv", Location: time.UTC}
+	out := captureStdout(t, func() { assert.Nil(t, query(config, options)) })
+	assert.Equal(t, "series,time,input-errors\ninterface.statistics/check-interface-errors,2019-11-01T18:00:00Z,3\n", out, "Expected the database to be found from the Device Group")
+	assert.Equal(t, []string{`SELECT * FROM "interface.statistics/check-interface-errors" WHERE time > now() - 3600s`}, server.Queries())
+
+	options.Group = "missing"
+	assert.NotNil(t, query(config, options), "Expected a query error to be reported")
+
+	options.Group = ""
+	server.Inject(hbtest.Fault{Method: "GET", Path: "/api/v1/device-groups/", Status: 500, Body: "simulated failure"})
+	err := query(config, options)
+	assert.NotNil(t, err, "Expected a failure to retrieve the Device Groups to be reported")
+	assert.Contains(t, err.Error(), "simulated failure")
+}
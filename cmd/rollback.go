@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/damianoneill/hb/healthbot"
+	"github.com/damianoneill/hb/logging"
+	"github.com/spf13/cobra"
+)
+
+// rollbackCmd represents the rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Roll back to an earlier committed configuration.",
+	Long: `Restores the configuration committed at a checkpoint and waits for the rollback job to finish.
+	Without --to the configuration before the last commit is restored, --list shows the checkpoints.`,
+	Run: func(c *cobra.Command, args []string) {
+		config := NewConfig(c)
+		if list, _ := c.Flags().GetBool("list"); list {
+			checkpoints, err := getCheckpoints(config)
+			if err != nil {
+				logging.Fatal(err)
+			}
+			writeCheckpoints(os.Stdout, checkpoints)
+			return
+		}
+		to, _ := c.Flags().GetString("to")
+		checkpoint, err := rollback(config, to, jobOptions(c))
+		if err != nil {
+			logging.Fatal(err)
+		}
+		logging.Infof("Successfully rolled back to %s", checkpoint)
+	},
+}
+
+// Checkpoint - a committed configuration
+type Checkpoint = healthbot.Checkpoint
+
+func getCheckpoints(config Config) ([]Checkpoint, error) {
+	client, err := config.Client()
+	if err != nil {
+		return nil, err
+	}
+	return client.Checkpoints(context.Background())
+}
+
+func writeCheckpoints(w io.Writer, checkpoints []Checkpoint) {
+	table := NewTableWriter(w)
+	table.SetHeader([]string{"Checkpoint", "Timestamp"})
+	for i := len(checkpoints) - 1; i >= 0; i-- {
+		table.Append([]string{checkpoints[i].Name, checkpoints[i].Timestamp})
+	}
+	table.Render()
+}
+
+// rollback - restores the checkpoint, or the one before the last commit when to is empty, returning its name
+func rollback(config Config, to string, options JobOptions) (string, error) {
+	if to == "" {
+		checkpoints, err := getCheckpoints(config)
+		if err != nil {
+			return "", err
+		}
+		if len(checkpoints) < 2 {
+			return "", fmt.Errorf("there is no earlier committed configuration to roll back to")
+		}
+		to = checkpoints[len(checkpoints)-2].Name
+	}
+	client, err := config.Client()
+	if err != nil {
+		return "", err
+	}
+	return to, client.Rollback(context.Background(), to, options)
+}
+
+func init() {
+	RootCmd.AddCommand(rollbackCmd)
+
+	rollbackCmd.Flags().String("to", "", "Checkpoint to roll back to, default is the configuration before the last commit")
+	rollbackCmd.Flags().Bool("list", false, "List the checkpoints")
+	addJobFlags(rollbackCmd)
+
+	_ = rollbackCmd.RegisterFlagCompletionFunc("to", completeNames(checkpointNames))
+}
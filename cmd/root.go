@@ -2,15 +2,22 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"io/ioutil"
+	"net/http"
 	"os"
 	"strings"
+	"sync"
 
+	"github.com/damianoneill/hb/healthbot"
+	"github.com/damianoneill/hb/logging"
+	"github.com/damianoneill/hb/recorder"
+	"github.com/damianoneill/hb/types"
 	"github.com/spf13/cobra"
-	"gopkg.in/resty.v1"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
@@ -18,10 +25,21 @@ import (
 
 var (
 	// VERSION passed in as a build variable
-	VERSION string
-	cfgFile string
+	VERSION        string
+	cfgFile        string
+	configFileUsed string
+	recordFile     string
+	replayFile     string
+	serverNames    []string
+	allContexts    bool
 )
 
+// transport - carries the requests of every command, wrapped to record, replay and log them
+var transport http.RoundTripper = &http.Transport{
+	Proxy:           http.ProxyFromEnvironment,
+	TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // nolint : gosec
+}
+
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
 	Use:   "hb",
@@ -41,8 +59,7 @@ simplify interacting with Healthbot.
 func Execute(version string) {
 	VERSION = version
 	if err := RootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		logging.Fatal(err)
 	}
 }
 
@@ -53,37 +70,100 @@ type Config struct {
 	Username  string
 	Password  string
 	Erase     string
+	Filename  string
+	Recursive bool
 }
 
-// FilesInDirectory - returns a list of filenames for a given directory
+// FilesInDirectory - returns a sorted list of filenames for a given directory, hidden files and subdirectories are skipped
 func FilesInDirectory(dirname string) (names []string) {
-	fmt.Printf("Using directory: %s \n", dirname)
-	f, err := os.Open(dirname)
+	logging.Infof("Using directory: %s", dirname)
+	infos, err := ioutil.ReadDir(dirname)
 	if err != nil {
 		return
 	}
-	names, err = f.Readdirnames(-1)
-	f.Close()
-	fmt.Printf("Using files: %s \n", names)
+	for _, info := range infos {
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			continue
+		}
+		names = append(names, info.Name())
+	}
+	logging.Infof("Using files: %s", names)
 	return
 }
 
+// ConfigFiles - returns the sorted list of config files to provision from, the Filename (a file, a
+// directory or - for stdin) takes precedence over the Directory
+func ConfigFiles(config Config) ([]string, error) {
+	location := config.Filename
+	if location == "" {
+		location = config.Directory
+	}
+	logging.Infof("Using location: %s", location)
+	filenames, err := types.FindConfigurationFiles(location, config.Recursive)
+	if err != nil {
+		return nil, err
+	}
+	logging.Infof("Using files: %s", filenames)
+	return filenames, nil
+}
+
+// clientKey - what a client is built from, a client is kept for each
+type clientKey struct {
+	resource  string
+	username  string
+	password  string
+	transport http.RoundTripper
+}
+
+// clients - the client of each server, built once and shared by the commands
+var clients = struct {
+	sync.Mutex
+	byKey map[clientKey]*healthbot.Client
+}{byKey: map[clientKey]*healthbot.Client{}}
+
+// Client - the Healthbot client for the bean, built on first use, its requests go through the transport of the CLI
+func (config Config) Client() (*healthbot.Client, error) {
+	key := clientKey{config.Resource, config.Username, config.Password, transport}
+	clients.Lock()
+	defer clients.Unlock()
+	if client, ok := clients.byKey[key]; ok {
+		return client, nil
+	}
+	client, err := healthbot.NewClient(healthbot.Options{
+		BaseURL:   config.Resource,
+		Username:  config.Username,
+		Password:  config.Password,
+		Transport: transport,
+	})
+	if err != nil {
+		return nil, err
+	}
+	clients.byKey[key] = client
+	return client, nil
+}
+
+// request - an HTTP request to a Resource with the client for the bean
+func request(method string, body interface{}, resource, path, username, password string) (*healthbot.Response, error) {
+	client, err := Config{Resource: resource, Username: username, Password: password}.Client()
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(context.Background(), method, path, body)
+}
+
 // POST - HTTP POST to a Resource
-func POST(body interface{}, resource, path, username, password string) (resp *resty.Response, err error) {
-	resp, err = resty.R().
-		SetBasicAuth(username, password).
-		SetBody(body).
-		Post("https://" + resource + path)
-	return
+func POST(body interface{}, resource, path, username, password string) (*healthbot.Response, error) {
+	return request(http.MethodPost, body, resource, path, username, password)
 }
 
-// DELETE - HTTP POST to a Resource
-func DELETE(resource, path, username, password string) (resp *resty.Response, err error) {
-	resp, err = resty.R().
-		SetBasicAuth(username, password).
-		Delete("https://" + resource + path)
+// PUT - HTTP PUT to a Resource
+func PUT(body interface{}, resource, path, username, password string) (*healthbot.Response, error) {
+	return request(http.MethodPut, body, resource, path, username, password)
+}
 
-	return
+// DELETE - HTTP DELETE to a Resource
+func DELETE(resource, path, username, password string) (*healthbot.Response, error) {
+	return request(http.MethodDelete, nil, resource, path, username, password)
 }
 
 // AskForConfirmation - console y/n
@@ -93,7 +173,7 @@ func AskForConfirmation(s string, tries int, in io.Reader) bool {
 		fmt.Printf("%s [y/n]: ", s)
 		res, err := r.ReadString('\n')
 		if err != nil {
-			log.Fatal(err)
+			logging.Fatal(err)
 		}
 
 		// Empty input (i.e. "\n")
@@ -105,25 +185,41 @@ func AskForConfirmation(s string, tries int, in io.Reader) bool {
 	return false
 }
 
-// NewConfig - construct the bean from viper / cmd
+// NewConfig - construct the bean from viper / cmd, a password that is not configured is asked of the
+// credential helper or prompted for, and hb exits if there is none
 func NewConfig(cmd *cobra.Command) Config {
-	return Config{
+	config := newConfig(cmd)
+	password, err := lookupPassword(config, true)
+	if err != nil {
+		logging.Fatal(err)
+	}
+	config.Password = password
+	return config
+}
+
+// newConfig - the bean from viper / cmd, without looking up the password
+func newConfig(cmd *cobra.Command) Config {
+	config := Config{
 		Resource: viper.GetString("resource"),
 		Username: viper.GetString("username"),
 		Password: viper.GetString("password"),
 	}
+	if f := cmd.Flag("filename"); f != nil {
+		config.Filename = f.Value.String()
+	}
+	if f := cmd.Flag("recursive"); f != nil {
+		config.Recursive = f.Value.String() == "true"
+	}
+	return config
 }
 
 // GET - HTTP GET to a Resource
-func GET(resource, path, username, password string) (resp *resty.Response, err error) {
-	resp, err = resty.R().
-		SetBasicAuth(username, password).
-		Get("https://" + resource + path)
-	return
+func GET(resource, path, username, password string) (*healthbot.Response, error) {
+	return request(http.MethodGet, nil, resource, path, username, password)
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
+	cobra.OnInitialize(initConfig, initLogging, initSession, initRequestLogging)
 
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
@@ -137,15 +233,27 @@ func init() {
 	RootCmd.PersistentFlags().StringP("username", "u", "admin", "Healthbot Username")
 	viper.BindPFlag("username", RootCmd.PersistentFlags().Lookup("username"))
 
-	RootCmd.PersistentFlags().StringP("password", "p", "****", "Healthbot Password")
+	RootCmd.PersistentFlags().StringP("password", "p", "", "Healthbot Password, visible in ps so prefer a credential-helper")
 	viper.BindPFlag("password", RootCmd.PersistentFlags().Lookup("password"))
 
-	RootCmd.PersistentFlags().Bool("debug", false, "Enable REST debugging")
+	RootCmd.PersistentFlags().String("credential-helper", "", "credential helper for the password: store, prompt or an executable (see hb credential)")
+	viper.BindPFlag("credential-helper", RootCmd.PersistentFlags().Lookup("credential-helper"))
+
+	RootCmd.PersistentFlags().Bool("debug", false, "Enable REST debugging, the same as --log-level debug")
 	viper.BindPFlag("debug", RootCmd.PersistentFlags().Lookup("debug"))
 
-	//setup resty
-	resty.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true})
-	viper.Set("restclient.RedirectPolicy", "always")
+	RootCmd.PersistentFlags().String("log-level", "info", "Log level error, warn, info, debug or trace, logs are written to stderr")
+	viper.BindPFlag("log-level", RootCmd.PersistentFlags().Lookup("log-level"))
+
+	RootCmd.PersistentFlags().String("log-format", "text", "Log format text or json")
+	viper.BindPFlag("log-format", RootCmd.PersistentFlags().Lookup("log-format"))
+
+	RootCmd.PersistentFlags().StringVar(&recordFile, "record", "", "record the REST requests and responses to a session file, credentials are redacted")
+	RootCmd.PersistentFlags().StringVar(&replayFile, "replay", "", "replay the responses in a session file instead of contacting Healthbot")
+
+	RootCmd.PersistentFlags().StringSliceVar(&serverNames, "servers", nil, "contexts in the config file or resources to run against, read-only commands run concurrently")
+	RootCmd.PersistentFlags().BoolVar(&allContexts, "all-contexts", false, "run against every context in the config file")
+	_ = RootCmd.RegisterFlagCompletionFunc("servers", CompleteContextNames)
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -157,8 +265,7 @@ func initConfig() {
 		// Find home directory.
 		home, err := homedir.Dir()
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			logging.Fatal(err)
 		}
 
 		// Search config in home directory with name ".hb" (without extension).
@@ -168,8 +275,52 @@ func initConfig() {
 
 	viper.AutomaticEnv() // read in environment variables that match
 
-	// If a config file is found, read it in.
+	// If a config file is found, read it in, it is logged once the logging is configured from it.
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		configFileUsed = viper.ConfigFileUsed()
+	}
+}
+
+// initLogging configures the default logger from the flags and config file.
+func initLogging() {
+	name := viper.GetString("log-level")
+	if viper.GetBool("debug") && !RootCmd.PersistentFlags().Changed("log-level") {
+		name = "debug"
 	}
+	level, err := logging.ParseLevel(name)
+	if err == nil {
+		var logger *logging.Logger
+		if logger, err = logging.New(os.Stderr, level, viper.GetString("log-format")); err == nil {
+			logging.SetDefault(logger)
+		}
+	}
+	if err != nil {
+		logging.Fatal(err)
+	}
+	if configFileUsed != "" {
+		logging.Infof("Using config file: %s", configFileUsed)
+	}
+}
+
+// initSession wraps the transport to record or replay a session.
+func initSession() {
+	switch {
+	case recordFile != "" && replayFile != "":
+		logging.Fatal(errors.New("--record and --replay can not be used together"))
+	case recordFile != "":
+		transport = recorder.NewRecorder(recordFile, transport)
+		logging.Infof("Recording session to: %s", recordFile)
+	case replayFile != "":
+		replayer, err := recorder.NewReplayer(replayFile)
+		if err != nil {
+			logging.Fatal(err)
+		}
+		transport = replayer
+		logging.Infof("Replaying session from: %s", replayFile)
+	}
+}
+
+// initRequestLogging logs the REST requests, with credentials redacted, at debug and their bodies at trace.
+func initRequestLogging() {
+	transport = logging.NewTransport(transport, nil)
 }
@@ -1,17 +1,20 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 
+	"github.com/damianoneill/hb/logging"
+	"github.com/damianoneill/hb/redact"
 	"github.com/damianoneill/hb/types"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
-	"gopkg.in/resty.v1"
+
 	"gopkg.in/yaml.v2"
 )
 
@@ -22,12 +25,17 @@ var scaffoldCmd = &cobra.Command{
 	Long: `This command when pointed at an existing Healthbot installation, will generate
 	valid configuration for the provision sub commands e.g. devices, device-groups, playbook-instances, etc.
 	
-	The command requires a single argument, the directory where the configs should be written too, current directory is valid.`,
-	PreRun: func(cmd *cobra.Command, args []string) {
-		if viper.GetString("debug") == "true" {
-			resty.SetDebug(true)
-		}
-	},
+	The command requires a single argument, the directory where the configs should be written too, current directory is valid.
+
+	Usernames, passwords, SNMP communities and SNMP v3 passwords are redacted so the directory can be
+	kept in git. Each is masked by default, --redact sets the mode for every secret and --redact-field
+	the mode for one field e.g. --redact-field username=drop. The modes are:
+
+		mask       the value is replaced with ****
+		drop       the field is removed
+		reference  the value is replaced with ${HB_SECRET_...}, which provision reads from the environment
+
+	A summary of what was redacted is printed once the directory has been written.`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) != 1 {
 			return errors.New("scaffold requires the name of the directory to store the config files")
@@ -36,43 +44,100 @@ var scaffoldCmd = &cobra.Command{
 	},
 	Run: func(c *cobra.Command, args []string) {
 		config := NewConfig(c)
-		scaffold(config, args[0])
+		mode, _ := c.Flags().GetString("redact")
+		fields, _ := c.Flags().GetStringSlice("redact-field")
+		policy, err := redact.ParsePolicy(mode, fields)
+		if err != nil {
+			logging.Fatal(err)
+		}
+		writeRedactions(os.Stdout, scaffold(config, args[0], policy))
 	},
 }
 
-func collectInfo(config Config, path, folder, resource, message string) *resty.Response {
+// collectInfo - makes the folder for a kind of configuration, exiting when it could not be read from the server
+func collectInfo(err error, path, folder, message string) {
 	os.Mkdir(path+string(filepath.Separator)+folder, os.ModePerm)
-
-	resp, err := GET(config.Resource, resource, config.Username, config.Password)
 	if err != nil {
-		fmt.Printf(message+" %v", err)
-		os.Exit(1)
-	}
-	switch resp.StatusCode() {
-	case 200:
-		break
-	default:
-		fmt.Printf(message+": %v \n", resp.String())
+		logging.Errorf("%s: %v", message, err)
 		os.Exit(1)
 	}
-	return resp
 }
 
 func writeInfo(config interface{}, path, folder, filename string) {
 	data, err := yaml.Marshal(config)
 	if err != nil {
-		fmt.Printf("Problem with Marshalling Yaml: %v", err)
+		logging.Errorf("Problem with Marshalling Yaml: %v", err)
 		os.Exit(1)
 	}
 	err = ioutil.WriteFile(path+string(filepath.Separator)+folder+string(filepath.Separator)+filename, data, os.ModePerm)
 	if err != nil {
-		fmt.Printf("Problem writing "+folder+" config %v", err)
+		logging.Errorf("Problem writing %s config %v", folder, err)
 		os.Exit(1)
 	}
 }
 
-func scaffold(config Config, path string) {
-	fmt.Printf("Healthbot scaffold: %v\n", config.Resource)
+// scaffoldRedaction - a secret redacted from a file written by scaffold
+type scaffoldRedaction struct {
+	File string
+	redact.Redaction
+}
+
+// redactSecrets - redacts the configuration with the policy, the secrets in fields that are not modelled are always masked
+func redactSecrets(redactions []scaffoldRedaction, policy redact.Policy, configuration interface{}, folder, filename string) []scaffoldRedaction {
+	var applied []redact.Redaction
+	if holder, ok := configuration.(types.SecretHolder); ok {
+		applied = policy.Apply(holder)
+	} else {
+		applied = redact.Unmodelled(configuration)
+	}
+	for _, r := range applied {
+		redactions = append(redactions, scaffoldRedaction{File: filepath.Join(folder, filename), Redaction: r})
+	}
+	return redactions
+}
+
+// writeRedactions - a summary of the secrets redacted from each file, and the environment variables
+// that provision needs for the references
+func writeRedactions(w io.Writer, redactions []scaffoldRedaction) {
+	if len(redactions) == 0 {
+		fmt.Fprintln(w, "No secrets were redacted")
+		return
+	}
+	type summaryKey struct {
+		File  string
+		Field string
+		Mode  redact.Mode
+	}
+	var keys []summaryKey
+	counts := map[summaryKey]int{}
+	var references []string
+	for _, r := range redactions {
+		key := summaryKey{r.File, r.Field, r.Mode}
+		if counts[key] == 0 {
+			keys = append(keys, key)
+		}
+		counts[key]++
+		if r.Reference != "" {
+			references = append(references, r.Reference)
+		}
+	}
+	table := NewTableWriter(w)
+	table.SetHeader([]string{"File", "Field", "Mode", "Redacted"})
+	for _, k := range keys {
+		table.Append([]string{k.File, k.Field, string(k.Mode), strconv.Itoa(counts[k])})
+	}
+	table.Render()
+	if len(references) > 0 {
+		fmt.Fprintln(w, "\nSet these environment variables before provisioning:")
+		for _, r := range references {
+			fmt.Fprintln(w, "  "+r)
+		}
+	}
+}
+
+// scaffold - writes the configuration on the server to the path, returning the secrets it redacted
+func scaffold(config Config, path string, policy redact.Policy) (redactions []scaffoldRedaction) {
+	logging.Infof("Healthbot scaffold: %v", config.Resource)
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		os.Mkdir(path, os.ModePerm)
 	} else {
@@ -82,62 +147,77 @@ func scaffold(config Config, path string) {
 		}
 	}
 
-	//
-
-	resp := collectInfo(config, path, "devices", "/api/v1/devices/", "Problem getting Devices")
-
-	var devices types.Devices
-	if err := json.Unmarshal(resp.Body(), &devices); err != nil {
-		fmt.Printf("%v", err)
-		return
-	}
-
-	// need to blank out the passwords
-	blankPassword := "****"
-	for _, device := range devices.Device {
-		if device.Authentication != nil && device.Authentication.Password.Password != nil {
-			device.Authentication.Password.Password = &blankPassword
-		}
+	client, err := config.Client()
+	if err != nil {
+		logging.Fatal(err)
 	}
+	ctx := context.Background()
 
+	devices, err := client.Devices(ctx)
+	collectInfo(err, path, "devices", "Problem getting Devices")
+	redactions = redactSecrets(redactions, policy, &devices, "devices", "devices.yml")
 	writeInfo(devices, path, "devices", "devices.yml")
 
-	//
-
-	dgResp := collectInfo(config, path, "device-groups", "/api/v1/device-groups/", "Problem getting Devices Groups")
-
-	var deviceGroups types.DeviceGroups
-	if err := json.Unmarshal(dgResp.Body(), &deviceGroups); err != nil {
-		fmt.Printf("%v", err)
-		return
-	}
-
+	retentionPolicies, err := client.RetentionPolicies(ctx)
+	collectInfo(err, path, "retention-policies", "Problem getting Retention Policies")
+	redactions = redactSecrets(redactions, policy, &retentionPolicies, "retention-policies", "retention-policies.yml")
+	writeInfo(retentionPolicies, path, "retention-policies", "retention-policies.yml")
+
+	topics, err := client.Topics(ctx)
+	collectInfo(err, path, "topics", "Problem getting Topics")
+	redactions = redactSecrets(redactions, policy, &topics, "topics", "topics.yml")
+	writeInfo(topics, path, "topics", "topics.yml")
+
+	schedulers, err := client.Schedulers(ctx)
+	collectInfo(err, path, "schedulers", "Problem getting Schedulers")
+	redactions = redactSecrets(redactions, policy, &schedulers, "schedulers", "schedulers.yml")
+	writeInfo(schedulers, path, "schedulers", "schedulers.yml")
+
+	destinations, err := client.Destinations(ctx)
+	collectInfo(err, path, "destinations", "Problem getting Destinations")
+	redactions = redactSecrets(redactions, policy, &destinations, "destinations", "destinations.yml")
+	writeInfo(destinations, path, "destinations", "destinations.yml")
+
+	reports, err := client.Reports(ctx)
+	collectInfo(err, path, "reports", "Problem getting Reports")
+	redactions = redactSecrets(redactions, policy, &reports, "reports", "reports.yml")
+	writeInfo(reports, path, "reports", "reports.yml")
+
+	syslog, err := client.Syslog(ctx)
+	collectInfo(err, path, "syslog", "Problem getting Syslog")
+	redactions = redactSecrets(redactions, policy, &syslog, "syslog", "syslog.yml")
+	writeInfo(syslog, path, "syslog", "syslog.yml")
+
+	snmpNotification, err := client.SnmpNotification(ctx)
+	collectInfo(err, path, "snmp-notification", "Problem getting SNMP Notification")
+	redactions = redactSecrets(redactions, policy, &snmpNotification, "snmp-notification", "snmp-notification.yml")
+	writeInfo(snmpNotification, path, "snmp-notification", "snmp-notification.yml")
+
+	frequencyProfiles, err := client.FrequencyProfiles(ctx)
+	collectInfo(err, path, "frequency-profiles", "Problem getting Frequency Profiles")
+	redactions = redactSecrets(redactions, policy, &frequencyProfiles, "frequency-profiles", "frequency-profiles.yml")
+	writeInfo(frequencyProfiles, path, "frequency-profiles", "frequency-profiles.yml")
+
+	deviceGroups, err := client.DeviceGroups(ctx)
+	collectInfo(err, path, "device-groups", "Problem getting Devices Groups")
+	redactions = redactSecrets(redactions, policy, &deviceGroups, "device-groups", "device-groups.yml")
 	writeInfo(deviceGroups, path, "device-groups", "device-groups.yml")
 
-	//
-
-	os.Mkdir(path+string(filepath.Separator)+"playbook-instances", os.ModePerm)
-
-	var playbookInstances types.PlaybookInstances
-	if err := json.Unmarshal(dgResp.Body(), &playbookInstances); err != nil {
-		fmt.Printf("%v", err)
-		return
-	}
-
+	playbookInstances, err := client.PlaybookInstances(ctx)
+	collectInfo(err, path, "playbook-instances", "Problem getting Playbook Instances")
+	redactions = redactSecrets(redactions, policy, &playbookInstances, "playbook-instances", "playbook-instances.yml")
 	writeInfo(playbookInstances, path, "playbook-instances", "playbook-instances.yml")
 
+	networkGroups, err := client.NetworkGroups(ctx)
+	collectInfo(err, path, "network-groups", "Problem getting Network Groups")
+	redactions = redactSecrets(redactions, policy, &networkGroups, "network-groups", "network-groups.yml")
+	writeInfo(networkGroups, path, "network-groups", "network-groups.yml")
+	return
 }
 
 func init() {
 	RootCmd.AddCommand(scaffoldCmd)
 
-	// Here you will define your flags and configuration settings.
-
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// scaffoldCmd.PersistentFlags().String("foo", "", "A help for foo")
-
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// scaffoldCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	scaffoldCmd.Flags().String("redact", string(redact.MaskMode), "how secrets are redacted: mask, drop or reference")
+	scaffoldCmd.Flags().StringSlice("redact-field", nil, "the redaction for one field e.g. username=drop, community=reference")
 }
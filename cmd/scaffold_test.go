@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/damianoneill/hb/redact"
+	"github.com/damianoneill/hb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaffold(t *testing.T) {
+	_, config, stop := newTestServer(t)
+	defer stop()
+	dir, err := ioutil.TempDir("", "scaffold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config")
+
+	captureLog(t, func() { scaffold(config, path, redact.DefaultPolicy) })
+
+	var devices types.Devices
+	assert.Nil(t, types.LoadConfiguration(filepath.Join(path, "devices", "devices.yml"), &devices), "Expected scaffold to write the Devices")
+	assert.Len(t, devices.Device, 2)
+	assert.EqualValues(t, "****", *devices.Device[0].Authentication.Password.Password, "Expected the Device password to be blanked")
+
+	var deviceGroups types.DeviceGroups
+	assert.Nil(t, types.LoadConfiguration(filepath.Join(path, "device-groups", "device-groups.yml"), &deviceGroups), "Expected scaffold to write the Device Groups")
+	assert.Len(t, deviceGroups.DeviceGroup, 2)
+
+	for _, folder := range []string{"playbook-instances", "network-groups", "retention-policies", "topics", "schedulers", "destinations", "reports", "syslog", "snmp-notification", "frequency-profiles"} {
+		_, err := os.Stat(filepath.Join(path, folder, folder+".yml"))
+		assert.Nil(t, err, "Expected scaffold to write "+folder)
+	}
+}
+
+func TestScaffoldRedaction(t *testing.T) {
+	server, config, stop := newTestServer(t)
+	defer stop()
+	username := "root"
+	device := types.Device{DeviceID: "ex-1", Host: "10.0.0.9", Snmp: &types.Snmp{V2: &types.V2{Community: "public"}}}
+	device.Authentication = &types.Authentication{}
+	device.Authentication.Password.Username = &username
+	assert.Nil(t, server.Seed(&types.Devices{Device: []types.Device{device}}))
+	dir, err := ioutil.TempDir("", "scaffold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config")
+
+	policy, err := redact.ParsePolicy("reference", []string{"username=drop"})
+	assert.Nil(t, err)
+	var redactions []scaffoldRedaction
+	captureLog(t, func() { redactions = scaffold(config, path, policy) })
+
+	data, err := ioutil.ReadFile(filepath.Join(path, "devices", "devices.yml"))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "secret")
+	assert.NotContains(t, string(data), "public")
+	assert.NotContains(t, string(data), "root")
+	assert.Contains(t, string(data), "${HB_SECRET_DEVICE_MX960_1_PASSWORD}")
+	assert.Contains(t, string(data), "${HB_SECRET_DEVICE_EX_1_COMMUNITY}")
+
+	var out bytes.Buffer
+	writeRedactions(&out, redactions)
+	assert.Regexp(t, `devices/devices.yml\s+community\s+reference\s+1`, out.String())
+	assert.Regexp(t, `devices/devices.yml\s+username\s+drop\s+1`, out.String())
+	assert.Contains(t, out.String(), "  HB_SECRET_DEVICE_EX_1_COMMUNITY\n")
+}
+
+func TestScaffoldWritesNoSecrets(t *testing.T) {
+	server, config, stop := newTestServer(t)
+	defer stop()
+	var deviceGroups types.DeviceGroups
+	assert.Nil(t, deviceGroups.Parse([]byte(`
+device-group:
+  - device-group-name: secured
+    playbooks: [kpis]
+    authentication:
+      password:
+        username: operator
+        password: TOPSECRET
+    kerberos:
+      password: TOPSECRET
+`)))
+	assert.Nil(t, server.Seed(&deviceGroups))
+	dir, err := ioutil.TempDir("", "scaffold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config")
+
+	captureLog(t, func() { scaffold(config, path, redact.DefaultPolicy) })
+
+	written := 0
+	err = filepath.Walk(path, func(name string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		assert.NotContains(t, string(data), "TOPSECRET", "Expected no secret in %s", name)
+		written++
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 12, written)
+
+	data, err := ioutil.ReadFile(filepath.Join(path, "playbook-instances", "playbook-instances.yml"))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "authentication", "Expected only the Playbook relevant fields of the Device Groups")
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/damianoneill/hb/logging"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// serverContext - a Healthbot named under contexts in the config file
+type serverContext struct {
+	Resource string `mapstructure:"resource"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// Server - one of the Healthbots a command is run against
+type Server struct {
+	Name   string
+	Config Config
+}
+
+// serverContexts - the contexts in the config file by name
+func serverContexts() (map[string]serverContext, error) {
+	contexts := map[string]serverContext{}
+	if err := viper.UnmarshalKey("contexts", &contexts); err != nil {
+		return nil, fmt.Errorf("problem reading the contexts in the config file: %v", err)
+	}
+	return contexts, nil
+}
+
+// selectServers - the servers named with --servers, or every context with --all-contexts, a name that is
+// not a context is taken to be the resource of a Healthbot; only the server of the config when neither is set
+func selectServers(config Config, names []string, all bool) ([]Server, error) {
+	if len(names) > 0 && all {
+		return nil, errors.New("--servers and --all-contexts can not be used together")
+	}
+	if len(names) == 0 && !all {
+		return []Server{{Name: config.Resource, Config: config}}, nil
+	}
+	contexts, err := serverContexts()
+	if err != nil {
+		return nil, err
+	}
+	if all {
+		if len(contexts) == 0 {
+			return nil, errors.New("there are no contexts in the config file")
+		}
+		for name := range contexts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	var servers []Server
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		server := Server{Name: name, Config: config}
+		server.Config.Resource = name
+		if context, ok := contexts[name]; ok {
+			if context.Resource == "" {
+				return nil, fmt.Errorf("context %s has no resource", name)
+			}
+			server.Config.Resource = context.Resource
+			if context.Username != "" {
+				server.Config.Username = context.Username
+			}
+			if context.Password != "" {
+				server.Config.Password = context.Password
+			}
+		}
+		servers = append(servers, server)
+	}
+	return servers, nil
+}
+
+// NewServers - the servers selected with --servers or --all-contexts, each with its password looked up as
+// in NewConfig, or the single server of NewConfig when neither is set
+func NewServers(cmd *cobra.Command) []Server {
+	servers, err := selectServers(newConfig(cmd), serverNames, allContexts)
+	if err != nil {
+		logging.Fatal(err)
+	}
+	for i := range servers {
+		if servers[i].Config.Password, err = lookupPassword(servers[i].Config, true); err != nil {
+			logging.Fatal(err)
+		}
+	}
+	return servers
+}
+
+// NewServer - the context or resource with the name, with its password looked up as in NewConfig
+func NewServer(cmd *cobra.Command, name string) Server {
+	servers, err := selectServers(newConfig(cmd), []string{name}, false)
+	if err == nil && len(servers) == 0 {
+		err = errors.New("a context or resource is required")
+	}
+	if err != nil {
+		logging.Fatal(err)
+	}
+	if servers[0].Config.Password, err = lookupPassword(servers[0].Config, true); err != nil {
+		logging.Fatal(err)
+	}
+	return servers[0]
+}
+
+// parallel - runs f against every server concurrently, the errors are in the order of the servers
+func parallel(servers []Server, f func(i int, server Server) error) []error {
+	errs := make([]error, len(servers))
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		wg.Add(1)
+		go func(i int, server Server) {
+			defer wg.Done()
+			errs[i] = f(i, server)
+		}(i, server)
+	}
+	wg.Wait()
+	return errs
+}
+
+// failures - logs the servers that failed, and the error to exit with when there were any
+func failures(servers []Server, errs []error) error {
+	if len(servers) == 1 {
+		return errs[0]
+	}
+	var failed []string
+	for i, err := range errs {
+		if err != nil {
+			logging.Errorf("%s: %v", servers[i].Name, err)
+			failed = append(failed, servers[i].Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("problem retrieving from %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// fanOut - the sections from every server merged into one, with a Server column when there is more than
+// one server, a server that fails is left out so that the others are still reported
+func fanOut(servers []Server, f func(config Config) ([]section, error)) ([]section, error) {
+	results := make([][]section, len(servers))
+	errs := parallel(servers, func(i int, server Server) (err error) {
+		results[i], err = f(server.Config)
+		return
+	})
+	if len(servers) == 1 {
+		return results[0], errs[0]
+	}
+	var merged []section
+	for i, server := range servers {
+		if errs[i] != nil {
+			continue
+		}
+		for j, s := range results[i] {
+			if j == len(merged) {
+				merged = append(merged, section{Title: s.Title, Header: append([]string{"Server"}, s.Header...)})
+			}
+			for _, row := range s.Rows {
+				merged[j].Rows = append(merged[j].Rows, append([]string{server.Name}, row...))
+			}
+		}
+	}
+	return merged, failures(servers, errs)
+}
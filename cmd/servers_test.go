@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectServers(t *testing.T) {
+	viper.Set("contexts", map[string]interface{}{
+		"lab":  map[string]interface{}{"resource": "hb-lab:8080"},
+		"emea": map[string]interface{}{"resource": "hb-emea:8080", "username": "ops", "password": "emea"},
+		"apac": map[string]interface{}{"username": "ops"},
+	})
+	defer viper.Set("contexts", nil)
+	config := Config{Resource: "localhost:8080", Username: "admin", Password: "changeme"}
+
+	servers, err := selectServers(config, nil, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []Server{{Name: "localhost:8080", Config: config}}, servers)
+
+	servers, err = selectServers(config, []string{"lab", "emea", "hb-3:8080", "lab"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []Server{
+		{Name: "lab", Config: Config{Resource: "hb-lab:8080", Username: "admin", Password: "changeme"}},
+		{Name: "emea", Config: Config{Resource: "hb-emea:8080", Username: "ops", Password: "emea"}},
+		{Name: "hb-3:8080", Config: Config{Resource: "hb-3:8080", Username: "admin", Password: "changeme"}},
+	}, servers)
+
+	_, err = selectServers(config, nil, true)
+	assert.EqualError(t, err, "context apac has no resource")
+	_, err = selectServers(config, []string{"lab"}, true)
+	assert.NotNil(t, err)
+}
+
+func TestSummaryServers(t *testing.T) {
+	_, lab, stopLab := newTestServer(t)
+	defer stopLab()
+	_, emea, stopEmea := newTestServer(t)
+	defer stopEmea()
+	servers := []Server{{Name: "lab", Config: lab}, {Name: "emea", Config: emea}}
+
+	var out bytes.Buffer
+	assert.Nil(t, summary(&out, servers))
+	assert.Contains(t, out.String(), "No of Healthbot Servers: 2")
+	assert.Contains(t, out.String(), "No of Managed Devices: 4")
+	assert.Regexp(t, `(?m)^\s*lab\s+mx960-1\s+MX960`, out.String())
+	assert.Regexp(t, `(?m)^\s*emea\s+mx960-1\s+MX960`, out.String())
+	assert.Regexp(t, `(?m)^\s*emea\s+core\s+2`, out.String())
+
+	servers = append(servers, Server{Name: "down", Config: Config{Resource: "127.0.0.1:1", Username: "admin"}})
+	out.Reset()
+	var err error
+	log := captureLog(t, func() { err = summary(&out, servers) })
+	assert.EqualError(t, err, "problem retrieving from down")
+	assert.Contains(t, log, "down: ")
+	assert.Contains(t, out.String(), "No of Healthbot Servers: 2", "Expected the servers that answered to be reported")
+}
+
+func TestConfigClient(t *testing.T) {
+	config := Config{Resource: "hb-1:8080", Username: "admin", Password: "changeme", Directory: "devices"}
+	first, err := config.Client()
+	assert.Nil(t, err)
+	config.Directory = "device-groups"
+	second, err := config.Client()
+	assert.Nil(t, err)
+	assert.True(t, first == second, "Expected one client for the server")
+
+	config.Password = "other"
+	third, err := config.Client()
+	assert.Nil(t, err)
+	assert.False(t, first == third, "Expected a client for each credential")
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/damianoneill/hb/recorder"
+	"github.com/damianoneill/hb/redact"
+	"github.com/damianoneill/hb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// replay - runs f with the requests answered from a recorded session
+func replay(t *testing.T, session string, f func()) {
+	replayer, err := recorder.NewReplayer(filepath.Join("testdata", "sessions", session))
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := transport
+	transport = replayer
+	defer func() { transport = previous }()
+	f()
+}
+
+func TestSummaryReplay(t *testing.T) {
+	config := Config{Resource: "hb-server:8080", Username: "admin", Password: "changeme"}
+	var out string
+	replay(t, "summary.json", func() {
+		out = captureStdout(t, func() { assert.Nil(t, summary(os.Stdout, single(config))) })
+	})
+	assert.Contains(t, out, "Healthbot Version: HealthBot 2.1.0-beta")
+	assert.Contains(t, out, "Healthbot Time: 2019-11-01T18:41:59Z")
+	assert.Contains(t, out, "No of Managed Devices: 2")
+	assert.Contains(t, out, "JN1232C39AFA")
+	assert.Contains(t, out, "No of Device Groups: 1")
+}
+
+func TestScaffoldReplay(t *testing.T) {
+	config := Config{Resource: "hb-server:8080", Username: "admin", Password: "changeme"}
+	dir, err := ioutil.TempDir("", "scaffold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config")
+
+	replay(t, "scaffold.json", func() {
+		captureLog(t, func() { scaffold(config, path, redact.DefaultPolicy) })
+	})
+
+	var deviceGroups types.DeviceGroups
+	assert.Nil(t, types.LoadConfiguration(filepath.Join(path, "device-groups", "device-groups.yml"), &deviceGroups))
+	assert.Len(t, deviceGroups.DeviceGroup, 1)
+	assert.Equal(t, []string{"chassis-kpis-playbook"}, *deviceGroups.DeviceGroup[0].Playbooks)
+}
@@ -5,66 +5,37 @@ Copyright © 2019 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 
-	"github.com/damianoneill/hb/types"
+	"github.com/damianoneill/hb/logging"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
-	"gopkg.in/resty.v1"
 )
 
 // summaryCmd represents the summary command
 var summaryCmd = &cobra.Command{
 	Use:   "summary",
 	Short: "Summarizes the Healthbot Installation.",
-	Long:  `Provides some high level information on the installation version, Provisioned Devices and Device Groups.`,
-	PreRun: func(cmd *cobra.Command, args []string) {
-		if viper.GetString("debug") == "true" {
-			resty.SetDebug(true)
-		}
-	},
+	Long:  `Provides some high level information on the installation version, Provisioned Devices, Device Groups and Network Groups.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		summary(NewConfig(cmd))
+		if err := summary(os.Stdout, NewServers(cmd)); err != nil {
+			logging.Fatal(err)
+		}
 	},
 }
 
-// SystemDetails - Provides some basic hb info
-type SystemDetails struct {
-	ServerTime string `json:"server-time"`
-	Version    string `json:"version"`
-}
-
-// DeviceFacts - Provides Device Facts
-type DeviceFacts []struct {
-	DeviceID string `json:"device-id"`
-	Facts    struct {
-		Hostname  string `json:"hostname"`
-		JunosInfo []struct {
-			LastRebootReason string `json:"last-reboot-reason"`
-			MastershipState  string `json:"mastership-state"`
-			Model            string `json:"model"`
-			Name             string `json:"name"`
-			Status           string `json:"status"`
-			UpTime           string `json:"up-time"`
-		} `json:"junos-info"`
-		Platform     string `json:"platform"`
-		PlatformInfo []struct {
-			Name     string `json:"name"`
-			Platform string `json:"platform"`
-		} `json:"platform-info"`
-		Product      string `json:"product"`
-		Release      string `json:"release"`
-		SerialNumber string `json:"serial-number"`
-	} `json:"facts,omitempty"`
-}
-
 // NewTable - provides a blank table for rendering.
 func NewTable() *tablewriter.Table {
-	table := tablewriter.NewWriter(os.Stdout)
+	return NewTableWriter(os.Stdout)
+}
+
+// NewTableWriter - provides a blank table for rendering to a writer.
+func NewTableWriter(w io.Writer) *tablewriter.Table {
+	table := tablewriter.NewWriter(w)
 	table.SetBorder(false)
 	table.SetColumnSeparator("")
 	table.SetHeaderLine(false)
@@ -74,71 +45,79 @@ func NewTable() *tablewriter.Table {
 	return table
 }
 
-func summary(config Config) {
-	resp, err := GET(config.Resource, "/api/v1/system-details/", config.Username, config.Password)
+// summarySections - the installation, Devices, Device Groups and Network Groups of a Healthbot
+func summarySections(config Config) ([]section, error) {
+	client, err := config.Client()
 	if err != nil {
-		fmt.Printf("Problem retrieving from Healthbot %v", err)
-	}
-
-	var systemDetails SystemDetails
-	if err := json.Unmarshal(resp.Body(), &systemDetails); err != nil {
-		fmt.Printf("%v", err)
-		return
+		return nil, err
 	}
-	fmt.Println("")
-	fmt.Printf("Healthbot Resource: %s \n", config.Resource)
-	fmt.Printf("Healthbot Version: %s \n", systemDetails.Version)
-	fmt.Printf("Healthbot Time: %s \n", systemDetails.ServerTime)
-
-	//
-
-	resp, err = GET(config.Resource, "/api/v1/devices/facts/", config.Username, config.Password)
+	systemDetails, err := client.SystemDetails(context.Background())
 	if err != nil {
-		fmt.Printf("Problem retrieving from Healthbot %v", err)
+		return nil, fmt.Errorf("problem retrieving System Details: %v", err)
 	}
+	installation := section{Title: "Healthbot Servers", Header: []string{"Resource", "Version", "Time"}}
+	installation.Rows = append(installation.Rows, []string{config.Resource, systemDetails.Version, systemDetails.ServerTime})
 
-	var deviceFacts DeviceFacts
-	if err := json.Unmarshal(resp.Body(), &deviceFacts); err != nil {
-		fmt.Printf("%v", err)
-		return
+	deviceFacts, err := client.DeviceFacts(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("problem retrieving Device Facts: %v", err)
 	}
-	fmt.Println("")
-	fmt.Printf("No of Managed Devices: %v \n", len(deviceFacts))
-
-	fmt.Println("")
-
-	table := NewTable()
-	table.SetHeader([]string{"Device Id", "Platform", "Release", "Serial Number"})
+	devices := section{Title: "Managed Devices", Header: []string{"Device Id", "Platform", "Release", "Serial Number"}}
 	for _, fact := range deviceFacts {
-		table.Append([]string{fact.DeviceID, fact.Facts.Platform, fact.Facts.Release, fact.Facts.SerialNumber})
+		devices.Rows = append(devices.Rows, []string{fact.DeviceID, fact.Facts.Platform, fact.Facts.Release, fact.Facts.SerialNumber})
 	}
-	table.Render() // Send output
 
-	//
-
-	resp, err = GET(config.Resource, "/api/v1/device-groups/", config.Username, config.Password)
+	deviceGroups, err := client.DeviceGroups(context.Background())
 	if err != nil {
-		fmt.Printf("Problem retrieving from Healthbot %v", err)
+		return nil, fmt.Errorf("problem retrieving Device Groups: %v", err)
+	}
+	groups := section{Title: "Device Groups", Header: []string{"Device Group", "No of Devices"}}
+	for _, deviceGroup := range deviceGroups.DeviceGroup {
+		noOfDevices := 0
+		if deviceGroup.Devices != nil {
+			noOfDevices = len(*deviceGroup.Devices)
+		}
+		groups.Rows = append(groups.Rows, []string{deviceGroup.DeviceGroupName, strconv.Itoa(noOfDevices)})
 	}
 
-	var deviceGroups types.DeviceGroups
-	if err := json.Unmarshal(resp.Body(), &deviceGroups); err != nil {
-		fmt.Printf("%v", err)
-		return
+	networkGroups, err := client.NetworkGroups(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("problem retrieving Network Groups: %v", err)
 	}
-	fmt.Println("")
-	fmt.Printf("No of Device Groups: %v \n", len(deviceGroups.DeviceGroup))
+	networks := section{Title: "Network Groups", Header: []string{"Network Group", "No of Playbooks"}}
+	for _, networkGroup := range networkGroups.NetworkGroup {
+		noOfPlaybooks := 0
+		if networkGroup.Playbooks != nil {
+			noOfPlaybooks = len(*networkGroup.Playbooks)
+		}
+		networks.Rows = append(networks.Rows, []string{networkGroup.NetworkGroupName, strconv.Itoa(noOfPlaybooks)})
+	}
+	return []section{installation, devices, groups, networks}, nil
+}
 
-	fmt.Println("")
+// summary - writes the summary of each server, several servers are merged into one set of tables
+func summary(w io.Writer, servers []Server) error {
+	sections, err := fanOut(servers, summarySections)
+	for i, s := range sections {
+		fmt.Fprintln(w, "")
+		if i == 0 && len(servers) == 1 {
+			fmt.Fprintf(w, "Healthbot Resource: %s \n", s.Rows[0][0])
+			fmt.Fprintf(w, "Healthbot Version: %s \n", s.Rows[0][1])
+			fmt.Fprintf(w, "Healthbot Time: %s \n", s.Rows[0][2])
+			continue
+		}
+		fmt.Fprintf(w, "No of %s: %v \n", s.Title, len(s.Rows))
+		fmt.Fprintln(w, "")
 
-	table = NewTable()
-	table.SetHeader([]string{"Device Group", "No of Devices"})
-	for _, deviceGroup := range deviceGroups.DeviceGroup {
-		table.Append([]string{deviceGroup.DeviceGroupName, strconv.Itoa(len(*deviceGroup.Devices))})
+		table := NewTableWriter(w)
+		table.SetHeader(s.Header)
+		table.AppendBulk(s.Rows)
+		table.Render() // Send output
 	}
-	table.Render() // Send output
-
-	fmt.Println("")
+	if len(sections) > 0 {
+		fmt.Fprintln(w, "")
+	}
+	return err
 }
 
 func init() {
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/damianoneill/hb/hbtest"
+	"github.com/damianoneill/hb/logging"
+	"github.com/damianoneill/hb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// captureStdout - returns what f writes to stdout
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	f()
+	w.Close()
+	out, _ := ioutil.ReadAll(r)
+	return string(out)
+}
+
+// captureLog - returns what f logs at info and above
+func captureLog(t *testing.T, f func()) string {
+	var out bytes.Buffer
+	logger, err := logging.New(&out, logging.InfoLevel, logging.Text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logging.SetDefault(logging.SetDefault(logger))
+	f()
+	return out.String()
+}
+
+// newTestServer - a seeded in-memory Healthbot and the config to reach it
+func newTestServer(t *testing.T) (*hbtest.Server, Config, func()) {
+	server := hbtest.NewServer()
+	description := "Core routers"
+	password := "secret"
+	devices := types.Devices{Device: []types.Device{{DeviceID: "mx960-1", Host: "10.0.0.1"}, {DeviceID: "mx960-3", Host: "10.0.0.3"}}}
+	devices.Device[0].Authentication = &types.Authentication{}
+	devices.Device[0].Authentication.Password.Password = &password
+	assert.Nil(t, server.Seed(&devices))
+	assert.Nil(t, server.Seed(&types.DeviceGroups{DeviceGroup: []types.DeviceGroup{
+		{DeviceGroupName: "core", Description: &description, Devices: &[]string{"mx960-1", "mx960-3"}},
+		{DeviceGroupName: "empty"},
+	}}))
+	server.SetFacts("mx960-1", map[string]string{"platform": "MX960", "release": "19.3R1.8", "serial-number": "JN1232C39AFA"})
+	ts := server.Start()
+	config := Config{Resource: hbtest.Resource(ts), Username: "admin", Password: "changeme"}
+	return server, config, ts.Close
+}
+
+// single - the one server of a config
+func single(config Config) []Server {
+	return []Server{{Name: config.Resource, Config: config}}
+}
+
+func TestSummary(t *testing.T) {
+	_, config, stop := newTestServer(t)
+	defer stop()
+
+	out := captureStdout(t, func() { assert.Nil(t, summary(os.Stdout, single(config))) })
+	assert.Contains(t, out, "Healthbot Version: "+hbtest.Version)
+	assert.Contains(t, out, "No of Managed Devices: 2")
+	assert.Contains(t, out, "JN1232C39AFA")
+	assert.Contains(t, out, "No of Device Groups: 2")
+	assert.Contains(t, out, "No of Network Groups: 0")
+}
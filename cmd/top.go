@@ -0,0 +1,375 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/damianoneill/hb/healthbot"
+	"github.com/damianoneill/hb/logging"
+	"github.com/damianoneill/hb/terminal"
+	"github.com/damianoneill/hb/types"
+	"github.com/spf13/cobra"
+)
+
+// topCmd represents the top command
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Live full screen dashboard of Device health and alarms.",
+	Long: `Shows the Healthbot version and server time, the active alarms per Device Group and the health of
+	each Device, refreshed every --interval. Alarms raised within --since are considered, a Device's
+	health is the most severe of its active alarms.
+
+	Keys: up/down (or k/j) select a Device, enter shows its facts, groups, playbooks and latest alarms,
+	esc returns to the dashboard, r refreshes now and q quits.`,
+	Run: func(c *cobra.Command, args []string) {
+		interval, _ := c.Flags().GetDuration("interval")
+		since, _ := c.Flags().GetDuration("since")
+		if interval <= 0 {
+			logging.Fatal(fmt.Errorf("invalid interval %v, expected a positive duration", interval))
+		}
+		if err := top(NewConfig(c), interval, since); err != nil {
+			logging.Fatal(err)
+		}
+	},
+}
+
+// topSnapshot - what Healthbot reported at one refresh
+type topSnapshot struct {
+	SystemDetails healthbot.SystemDetails
+	DeviceFacts   healthbot.DeviceFacts
+	DeviceGroups  types.DeviceGroups
+	Alarms        []Alarm
+	Refreshed     time.Time
+	Err           error
+}
+
+func fetchTopSnapshot(config Config, since time.Duration) (snapshot topSnapshot) {
+	snapshot.Refreshed = time.Now()
+	ctx := context.Background()
+	client, err := config.Client()
+	if err == nil {
+		snapshot.SystemDetails, err = client.SystemDetails(ctx)
+	}
+	if err == nil {
+		snapshot.DeviceFacts, err = client.DeviceFacts(ctx)
+	}
+	if err == nil {
+		snapshot.DeviceGroups, err = client.DeviceGroups(ctx)
+	}
+	if err == nil {
+		snapshot.Alarms, err = fetchAlarms(config, alarmOptions{}, time.Now().Add(-since), map[string]bool{})
+	}
+	snapshot.Err = err
+	return
+}
+
+// activeAlarms - the latest alarm of each Rule trigger on each Device, unless it has returned to normal, most severe first
+func activeAlarms(alarms []Alarm) []Alarm {
+	latest := map[string]Alarm{}
+	for _, alarm := range alarms {
+		key := strings.Join([]string{alarm.DeviceID, alarm.DeviceGroup, alarm.Topic, alarm.Rule, alarm.Trigger}, "\x00")
+		if current, ok := latest[key]; !ok || !alarmBefore(alarm, current) {
+			latest[key] = alarm
+		}
+	}
+	var active []Alarm
+	for _, alarm := range latest {
+		if severityRank(alarm.Severity) > severityRank("normal") {
+			active = append(active, alarm)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool {
+		if ri, rj := severityRank(active[i].Severity), severityRank(active[j].Severity); ri != rj {
+			return ri > rj
+		}
+		return alarmBefore(active[j], active[i])
+	})
+	return active
+}
+
+// alarmBefore - whether a was raised before b, by the time rather than its text as the offsets can differ
+func alarmBefore(a, b Alarm) bool {
+	ta, _ := a.parsedTime()
+	tb, _ := b.parsedTime()
+	return ta.Before(tb)
+}
+
+// deviceHealth - a row of the Devices table
+type deviceHealth struct {
+	DeviceID string
+	Health   string
+	Active   int
+	Platform string
+	Release  string
+	Groups   []string
+}
+
+func (s topSnapshot) devices() []deviceHealth {
+	active := activeAlarms(s.Alarms)
+	var devices []deviceHealth
+	for _, fact := range s.DeviceFacts {
+		d := deviceHealth{DeviceID: fact.DeviceID, Health: "normal", Platform: fact.Facts.Platform, Release: fact.Facts.Release}
+		for _, alarm := range active {
+			if alarm.DeviceID != fact.DeviceID {
+				continue
+			}
+			if d.Active == 0 {
+				d.Health = strings.ToLower(alarm.Severity)
+			}
+			d.Active++
+		}
+		for _, group := range s.groupsOf(fact.DeviceID) {
+			d.Groups = append(d.Groups, group.DeviceGroupName)
+		}
+		devices = append(devices, d)
+	}
+	sort.SliceStable(devices, func(i, j int) bool {
+		if ri, rj := severityRank(devices[i].Health), severityRank(devices[j].Health); ri != rj {
+			return ri > rj
+		}
+		return devices[i].DeviceID < devices[j].DeviceID
+	})
+	return devices
+}
+
+func (s topSnapshot) groupsOf(deviceID string) (groups []types.DeviceGroup) {
+	for _, group := range s.DeviceGroups.DeviceGroup {
+		if group.Devices == nil {
+			continue
+		}
+		for _, d := range *group.Devices {
+			if d == deviceID {
+				groups = append(groups, group)
+				break
+			}
+		}
+	}
+	return
+}
+
+// topView - what is shown and selected
+type topView struct {
+	Selected int
+	Detail   bool
+}
+
+// handle - applies a key press, returning whether to quit and whether to refresh now
+func (v *topView) handle(e terminal.Event, rows int) (quit, refresh bool) {
+	switch {
+	case e.Key == terminal.KeyCtrlC, e.Key == terminal.KeyRune && e.Rune == 'q':
+		return true, false
+	case e.Key == terminal.KeyRune && e.Rune == 'r':
+		return false, true
+	case e.Key == terminal.KeyEscape, e.Key == terminal.KeyBackspace, e.Key == terminal.KeyLeft:
+		v.Detail = false
+	case e.Key == terminal.KeyEnter, e.Key == terminal.KeyRight:
+		v.Detail = rows > 0
+	case e.Key == terminal.KeyUp, e.Key == terminal.KeyRune && e.Rune == 'k':
+		v.Selected--
+	case e.Key == terminal.KeyDown, e.Key == terminal.KeyRune && e.Rune == 'j':
+		v.Selected++
+	case e.Key == terminal.KeyPageUp:
+		v.Selected -= 10
+	case e.Key == terminal.KeyPageDown:
+		v.Selected += 10
+	case e.Key == terminal.KeyHome:
+		v.Selected = 0
+	case e.Key == terminal.KeyEnd:
+		v.Selected = rows - 1
+	}
+	v.clamp(rows)
+	return false, false
+}
+
+func (v *topView) clamp(rows int) {
+	if v.Selected >= rows {
+		v.Selected = rows - 1
+	}
+	if v.Selected < 0 {
+		v.Selected = 0
+	}
+}
+
+func colourSeverity(severity string, width int, colour bool) string {
+	text := terminal.Pad(severity, width)
+	if c, ok := severityColours[severity]; ok && colour {
+		return c + text + terminal.Reset
+	}
+	return text
+}
+
+// row - pads each cell to its column width
+func row(widths []int, cells ...string) string {
+	var b strings.Builder
+	for i, cell := range cells {
+		b.WriteString(terminal.Pad(cell, widths[i]) + " ")
+	}
+	return b.String()
+}
+
+// renderTop - the lines of the screen
+func renderTop(resource string, s topSnapshot, v topView, width, height int, colour bool) []string {
+	lines := []string{
+		terminal.Bold + terminal.Truncate(fmt.Sprintf("Healthbot %s  %s  Server Time: %s  Refreshed: %s",
+			resource, s.SystemDetails.Version, s.SystemDetails.ServerTime, s.Refreshed.Format("15:04:05")), width) + terminal.Reset,
+		terminal.Truncate("q quit  r refresh  up/down select  enter details  esc back", width),
+	}
+	if s.Err != nil {
+		lines = append(lines, terminal.Truncate("Problem retrieving from Healthbot: "+s.Err.Error(), width))
+	}
+	lines = append(lines, "")
+	devices := s.devices()
+	v.clamp(len(devices))
+	if v.Detail && len(devices) > 0 {
+		return append(lines, renderDevice(s, devices[v.Selected], width, colour)...)
+	}
+
+	active := activeAlarms(s.Alarms)
+	groupWidths := []int{24, 8, 9, 7, 7, 8}
+	lines = append(lines, terminal.Bold+terminal.Truncate(row(groupWidths, "DEVICE GROUP", "DEVICES", "CRITICAL", "MAJOR", "MINOR", "WARNING"), width)+terminal.Reset)
+	for _, group := range s.DeviceGroups.DeviceGroup {
+		counts := map[string]int{}
+		for _, alarm := range active {
+			if alarm.DeviceGroup == group.DeviceGroupName {
+				counts[strings.ToLower(alarm.Severity)]++
+			}
+		}
+		members := 0
+		if group.Devices != nil {
+			members = len(*group.Devices)
+		}
+		lines = append(lines, terminal.Truncate(row(groupWidths, group.DeviceGroupName, strconv.Itoa(members),
+			strconv.Itoa(counts["critical"]), strconv.Itoa(counts["major"]), strconv.Itoa(counts["minor"]), strconv.Itoa(counts["warning"])), width))
+	}
+	lines = append(lines, "")
+
+	deviceWidths := []int{20, 10, 7, 12, 14}
+	lines = append(lines, terminal.Bold+terminal.Truncate(row(deviceWidths, "DEVICE", "HEALTH", "ALARMS", "PLATFORM", "RELEASE")+"GROUPS", width)+terminal.Reset)
+	// scroll so the selected Device stays on screen
+	available := height - len(lines)
+	first := 0
+	if available > 0 && v.Selected >= available {
+		first = v.Selected - available + 1
+	}
+	for i := first; i < len(devices) && i-first < available; i++ {
+		d := devices[i]
+		text := terminal.Pad(d.DeviceID, deviceWidths[0]) + " " + colourSeverity(d.Health, deviceWidths[1], colour && i != v.Selected) + " " +
+			row(deviceWidths[2:], strconv.Itoa(d.Active), d.Platform, d.Release) + strings.Join(d.Groups, ",")
+		if i == v.Selected {
+			text = terminal.Reverse + text
+		}
+		lines = append(lines, text)
+	}
+	return lines
+}
+
+// renderDevice - the details of one Device
+func renderDevice(s topSnapshot, d deviceHealth, width int, colour bool) []string {
+	lines := []string{terminal.Bold + "Device " + d.DeviceID + terminal.Reset + "  " + colourSeverity(d.Health, 10, colour), ""}
+	for _, fact := range s.DeviceFacts {
+		if fact.DeviceID != d.DeviceID {
+			continue
+		}
+		f := fact.Facts
+		lines = append(lines, terminal.Bold+"Facts"+terminal.Reset,
+			fmt.Sprintf("  Hostname: %s  Platform: %s  Release: %s  Serial Number: %s", f.Hostname, f.Platform, f.Release, f.SerialNumber))
+		for _, re := range f.JunosInfo {
+			lines = append(lines, terminal.Truncate(fmt.Sprintf("  %s %s %s %s up %s, last reboot: %s", re.Name, re.Model, re.MastershipState, re.Status, re.UpTime, re.LastRebootReason), width))
+		}
+	}
+	lines = append(lines, "", terminal.Bold+"Groups and Playbooks"+terminal.Reset)
+	for _, group := range s.groupsOf(d.DeviceID) {
+		playbooks := "no playbooks"
+		if group.Playbooks != nil && len(*group.Playbooks) > 0 {
+			playbooks = strings.Join(*group.Playbooks, ", ")
+		}
+		lines = append(lines, terminal.Truncate("  "+group.DeviceGroupName+": "+playbooks, width))
+	}
+	lines = append(lines, "", terminal.Bold+"Latest Alarms"+terminal.Reset)
+	var latest []Alarm
+	for _, alarm := range s.Alarms {
+		if alarm.DeviceID == d.DeviceID {
+			latest = append(latest, alarm)
+		}
+	}
+	sortAlarms(latest)
+	if len(latest) > 10 {
+		latest = latest[len(latest)-10:]
+	}
+	// newest first
+	for i := len(latest) - 1; i >= 0; i-- {
+		alarm := latest[i]
+		lines = append(lines, "  "+alarm.Time+"  "+colourSeverity(strings.ToLower(alarm.Severity), 8, colour)+"  "+terminal.Truncate(alarm.DeviceGroup+"  "+alarm.Message, width-len(alarm.Time)-14))
+	}
+	return lines
+}
+
+func top(config Config, interval, since time.Duration) error {
+	t, err := terminal.Open()
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+
+	snapshots := make(chan topSnapshot)
+	refresh := make(chan struct{}, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case snapshots <- fetchTopSnapshot(config, since):
+			case <-done:
+				return
+			}
+			select {
+			case <-time.After(interval):
+			case <-refresh:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var snapshot topSnapshot
+	var view topView
+	events := t.Events()
+	// redraw periodically so a resized terminal is filled
+	redraw := time.NewTicker(time.Second)
+	defer redraw.Stop()
+	for {
+		width, height := t.Size()
+		if err := t.Draw(renderTop(config.Resource, snapshot, view, width, height, true)); err != nil {
+			return err
+		}
+		select {
+		case snapshot = <-snapshots:
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+			quit, now := view.handle(e, len(snapshot.DeviceFacts))
+			if quit {
+				return nil
+			}
+			if now {
+				select {
+				case refresh <- struct{}{}:
+				default:
+				}
+			}
+		case <-redraw.C:
+		}
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(topCmd)
+
+	topCmd.Flags().Duration("interval", 5*time.Second, "How often to refresh")
+	topCmd.Flags().Duration("since", time.Hour, "How far back to consider alarms")
+}
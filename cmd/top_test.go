@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/damianoneill/hb/terminal"
+	"github.com/damianoneill/hb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActiveAlarms(t *testing.T) {
+	alarms := []Alarm{
+		{ID: "1", DeviceID: "mx960-1", Rule: "r1", Severity: "major", Time: "2019-10-01T10:00:00Z"},
+		{ID: "2", DeviceID: "mx960-1", Rule: "r1", Severity: "normal", Time: "2019-10-01T10:05:00Z"},
+		{ID: "3", DeviceID: "mx960-1", Rule: "r2", Severity: "minor", Time: "2019-10-01T10:01:00Z"},
+		{ID: "4", DeviceID: "mx960-3", Rule: "r1", Severity: "critical", Time: "2019-10-01T10:02:00Z"},
+		{ID: "5", DeviceID: "mx960-3", Rule: "r2", Severity: "major", Time: "2019-10-01T11:30:00+02:00"},
+		{ID: "6", DeviceID: "mx960-3", Rule: "r2", Severity: "normal", Time: "2019-10-01T10:00:00Z"},
+	}
+	active := activeAlarms(alarms)
+	assert.Len(t, active, 2, "Expected the Rules that returned to normal to be inactive, by time rather than its text")
+	assert.Equal(t, "4", active[0].ID)
+	assert.Equal(t, "3", active[1].ID)
+}
+
+func TestTopView(t *testing.T) {
+	var v topView
+	quit, _ := v.handle(terminal.Event{Key: terminal.KeyDown}, 2)
+	assert.False(t, quit)
+	v.handle(terminal.Event{Key: terminal.KeyDown}, 2)
+	assert.Equal(t, 1, v.Selected, "Expected the selection to stop at the last Device")
+	v.handle(terminal.Event{Key: terminal.KeyEnter}, 2)
+	assert.True(t, v.Detail)
+	v.handle(terminal.Event{Key: terminal.KeyEscape}, 2)
+	assert.False(t, v.Detail)
+	_, refresh := v.handle(terminal.Event{Key: terminal.KeyRune, Rune: 'r'}, 2)
+	assert.True(t, refresh)
+	quit, _ = v.handle(terminal.Event{Key: terminal.KeyRune, Rune: 'q'}, 2)
+	assert.True(t, quit)
+}
+
+func TestTop(t *testing.T) {
+	server, config, stop := newTestServer(t)
+	defer stop()
+	assert.Nil(t, server.Seed(&types.DeviceGroups{DeviceGroup: []types.DeviceGroup{{DeviceGroupName: "core", Playbooks: &[]string{"chassis-kpis-playbook"}}}}))
+	server.AddAlert(Alarm{ID: "1", DeviceID: "mx960-3", DeviceGroup: "core", Severity: "major", Message: "fan failure", Time: time.Now().UTC().Format(time.RFC3339)})
+
+	snapshot := fetchTopSnapshot(config, time.Hour)
+	assert.Nil(t, snapshot.Err)
+	screen := strings.Join(renderTop(config.Resource, snapshot, topView{}, 120, 40, false), "\n")
+	assert.Contains(t, screen, "HealthBot 2.1.0 (hbtest)")
+	assert.Contains(t, screen, "core                     2        0         1       0       0")
+	assert.Contains(t, screen, terminal.Reverse+"mx960-3              major      1", "Expected the unhealthy Device first and selected")
+
+	detail := strings.Join(renderTop(config.Resource, snapshot, topView{Detail: true}, 120, 40, false), "\n")
+	assert.Contains(t, detail, "Device mx960-3")
+	assert.Contains(t, detail, "core: chassis-kpis-playbook")
+	assert.Contains(t, detail, "fan failure")
+}
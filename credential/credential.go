@@ -0,0 +1,91 @@
+// Package credential obtains Healthbot passwords from git-style credential helpers, so that the password
+// does not have to be kept in the config file or passed with -p, where it shows up in ps.
+//
+// A helper is either built in, store (an encrypted file) or prompt (asks on the terminal), or an executable.
+// An executable is run with the action get, store or erase as its argument and is given the request on
+// stdin as key=value lines ending with a blank line:
+//
+//	resource=hb-server:8080
+//	username=admin
+//
+// For get it writes password=<password> on stdout, a single line without a key is also accepted.
+package credential
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Built in helpers
+const (
+	StoreHelper  = "store"
+	PromptHelper = "prompt"
+)
+
+// ErrNotFound - the helper has no password for the request
+var ErrNotFound = errors.New("no password found")
+
+// Request - identifies a password, the Healthbot resource and the username
+type Request struct {
+	Resource string
+	Username string
+}
+
+func (r Request) String() string {
+	return fmt.Sprintf("%s@%s", r.Username, r.Resource)
+}
+
+// Helper - gets, stores and erases passwords
+type Helper interface {
+	Get(r Request) (string, error)
+	Store(r Request, password string) error
+	Erase(r Request) error
+}
+
+// Options - the settings of the built in helpers
+type Options struct {
+	// StoreFile - the encrypted file of the store helper
+	StoreFile string
+	// Passphrase - the passphrase the store file is encrypted with
+	Passphrase func() (string, error)
+	// Prompt - asks for a secret without echoing it
+	Prompt func(prompt string) (string, error)
+}
+
+// New - the helper for the credential-helper setting, the name of a built in helper or an executable
+func New(name string, options Options) (Helper, error) {
+	switch name {
+	case "":
+		return nil, errors.New("no credential helper")
+	case StoreHelper:
+		if options.StoreFile == "" || options.Passphrase == nil {
+			return nil, errors.New("the store credential helper needs a file and a passphrase")
+		}
+		return &FileStore{Filename: options.StoreFile, Passphrase: options.Passphrase}, nil
+	case PromptHelper:
+		if options.Prompt == nil {
+			return nil, errors.New("the prompt credential helper needs a terminal")
+		}
+		return Prompt(options.Prompt), nil
+	default:
+		return NewExec(name), nil
+	}
+}
+
+// Prompt - a helper that asks for the password each time, there is nothing to store or erase
+type Prompt func(prompt string) (string, error)
+
+// Get - asks for the password of the request
+func (p Prompt) Get(r Request) (string, error) {
+	return p(fmt.Sprintf("Password for %s: ", r))
+}
+
+// Store - does nothing
+func (p Prompt) Store(r Request, password string) error {
+	return nil
+}
+
+// Erase - does nothing
+func (p Prompt) Erase(r Request) error {
+	return nil
+}
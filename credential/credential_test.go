@@ -0,0 +1,94 @@
+package credential
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credential")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "credentials")
+	passphrase := func() (string, error) { return "correct horse", nil }
+	hb1 := Request{Resource: "hb-1:8080", Username: "admin"}
+
+	store, err := New(StoreHelper, Options{StoreFile: filename, Passphrase: passphrase})
+	assert.Nil(t, err)
+	_, err = store.Get(hb1)
+	assert.Equal(t, ErrNotFound, err)
+	assert.Nil(t, store.Store(hb1, "changeme"))
+	assert.Nil(t, store.Store(Request{Resource: "hb-2:8080", Username: "admin"}, "other"))
+
+	data, err := ioutil.ReadFile(filename)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "changeme", "Expected the store to be encrypted")
+	info, _ := os.Stat(filename)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	reopened := &FileStore{Filename: filename, Passphrase: passphrase}
+	password, err := reopened.Get(hb1)
+	assert.Nil(t, err)
+	assert.Equal(t, "changeme", password)
+
+	wrong := &FileStore{Filename: filename, Passphrase: func() (string, error) { return "battery staple", nil }}
+	_, err = wrong.Get(hb1)
+	assert.Contains(t, err.Error(), "wrong passphrase")
+
+	assert.Nil(t, reopened.Erase(hb1))
+	_, err = reopened.Get(hb1)
+	assert.Equal(t, ErrNotFound, err)
+	assert.Equal(t, ErrNotFound, reopened.Erase(hb1))
+}
+
+func TestExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the helper is a shell script")
+	}
+	dir, err := ioutil.TempDir("", "credential")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	helper := filepath.Join(dir, "helper")
+	script := `#!/bin/sh
+input=$(cat)
+for action; do :; done
+case "$action" in
+get)
+  case "$input" in
+  *resource=hb-1:8080*username=admin*) echo "password=from-helper" ;;
+  *resource=hb-2:8080*) echo "bare-password" ;;
+  *resource=hb-3:8080*) echo "s3cr=t" ;;
+  *resource=hb-4:8080*) echo "username=admin" ;;
+  esac ;;
+store) echo "$input" > "$(dirname "$0")/stored" ;;
+erase) exit 3 ;;
+esac
+`
+	assert.Nil(t, ioutil.WriteFile(helper, []byte(script), 0700))
+
+	h, err := New(helper+" --verbose", Options{})
+	assert.Nil(t, err)
+	password, err := h.Get(Request{Resource: "hb-1:8080", Username: "admin"})
+	assert.Nil(t, err)
+	assert.Equal(t, "from-helper", password)
+	password, err = h.Get(Request{Resource: "hb-2:8080", Username: "admin"})
+	assert.Nil(t, err)
+	assert.Equal(t, "bare-password", password)
+	password, err = h.Get(Request{Resource: "hb-3:8080", Username: "admin"})
+	assert.Nil(t, err)
+	assert.Equal(t, "s3cr=t", password, "Expected a bare password containing = to be used")
+	_, err = h.Get(Request{Resource: "hb-4:8080", Username: "admin"})
+	assert.Equal(t, ErrNotFound, err, "Expected a key line other than password to not be taken as the password")
+	_, err = h.Get(Request{Resource: "hb-5:8080", Username: "admin"})
+	assert.Equal(t, ErrNotFound, err)
+
+	assert.Nil(t, h.Store(Request{Resource: "hb-1:8080", Username: "admin"}, "new"))
+	stored, _ := ioutil.ReadFile(filepath.Join(dir, "stored"))
+	assert.Equal(t, "resource=hb-1:8080\nusername=admin\npassword=new\n", string(stored))
+	assert.NotNil(t, h.Erase(Request{Resource: "hb-1:8080", Username: "admin"}), "Expected a failing helper to be reported")
+}
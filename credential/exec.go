@@ -0,0 +1,97 @@
+package credential
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Exec - a helper run as an executable, the Command may include arguments that come before the action
+type Exec struct {
+	Command []string
+}
+
+// NewExec - the helper for a command line, a bare name without an executable of its own is looked up
+// as hb-credential-<name> first, in the way git looks up git-credential-<name>
+func NewExec(commandLine string) *Exec {
+	command := strings.Fields(commandLine)
+	if len(command) > 0 && !strings.ContainsAny(command[0], `/\`) {
+		if path, err := exec.LookPath("hb-credential-" + command[0]); err == nil {
+			command[0] = path
+		}
+	}
+	return &Exec{Command: command}
+}
+
+func (e *Exec) run(action string, input map[string]string) ([]byte, error) {
+	if len(e.Command) == 0 {
+		return nil, fmt.Errorf("no credential helper command")
+	}
+	var stdin bytes.Buffer
+	for _, key := range keys {
+		if value, ok := input[key]; ok {
+			fmt.Fprintf(&stdin, "%s=%s\n", key, value)
+		}
+	}
+	stdin.WriteString("\n")
+	var stdout, stderr bytes.Buffer
+	c := exec.Command(e.Command[0], append(e.Command[1:], action)...) // nolint : gosec
+	c.Stdin = &stdin
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		return nil, fmt.Errorf("credential helper %s %s failed: %v %s", e.Command[0], action, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// keys - the keys of the lines passed to and from a helper
+var keys = []string{"resource", "username", "password"}
+
+// isKeyLine - whether the line is a key=value line of the helper protocol, rather than a bare password
+func isKeyLine(line string) bool {
+	for _, key := range keys {
+		if strings.HasPrefix(line, key+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// Get - the password=<password> line written by the helper, or its only line when that is not a key=value
+// line, so a bare password may contain =
+func (e *Exec) Get(r Request) (string, error) {
+	out, err := e.run("get", map[string]string{"resource": r.Resource, "username": r.Username})
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(line, "password=") {
+			return strings.TrimPrefix(line, "password="), nil
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 1 && !isKeyLine(lines[0]) {
+		return lines[0], nil
+	}
+	return "", ErrNotFound
+}
+
+// Store - passes the password to the helper to keep
+func (e *Exec) Store(r Request, password string) error {
+	_, err := e.run("store", map[string]string{"resource": r.Resource, "username": r.Username, "password": password})
+	return err
+}
+
+// Erase - asks the helper to forget the password
+func (e *Exec) Erase(r Request) error {
+	_, err := e.run("erase", map[string]string{"resource": r.Resource, "username": r.Username})
+	return err
+}
@@ -0,0 +1,181 @@
+package credential
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// iterations of PBKDF2 used to derive the key of a new store file
+const iterations = 200000
+
+// storeFile - the encrypted store, the key is derived from the passphrase with PBKDF2-HMAC-SHA256
+// and the credentials are sealed with AES-256-GCM
+type storeFile struct {
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// storedCredential - a password in the store
+type storedCredential struct {
+	Resource string `json:"resource"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// FileStore - a helper keeping the passwords in a file encrypted with a passphrase
+type FileStore struct {
+	Filename   string
+	Passphrase func() (string, error)
+
+	passphrase string
+}
+
+func (s *FileStore) key(salt []byte, iterations int) ([]byte, error) {
+	if s.passphrase == "" {
+		passphrase, err := s.Passphrase()
+		if err != nil {
+			return nil, err
+		}
+		if passphrase == "" {
+			return nil, errors.New("the credential store passphrase can not be empty")
+		}
+		s.passphrase = passphrase
+	}
+	return pbkdf2.Key([]byte(s.passphrase), salt, iterations, 32, sha256.New), nil
+}
+
+func (s *FileStore) load() ([]storedCredential, error) {
+	data, err := ioutil.ReadFile(s.Filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("problem reading credential store %s: %v", s.Filename, err)
+	}
+	if file.KDF != "pbkdf2-sha256" {
+		return nil, fmt.Errorf("credential store %s uses an unknown kdf %s", s.Filename, file.KDF)
+	}
+	key, err := s.key(file.Salt, file.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("wrong passphrase for credential store %s", s.Filename)
+	}
+	var credentials []storedCredential
+	err = json.Unmarshal(plaintext, &credentials)
+	return credentials, err
+}
+
+func (s *FileStore) save(credentials []storedCredential) error {
+	sort.Slice(credentials, func(i, j int) bool {
+		return credentials[i].Resource+"\x00"+credentials[i].Username < credentials[j].Resource+"\x00"+credentials[j].Username
+	})
+	plaintext, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+	file := storeFile{KDF: "pbkdf2-sha256", Iterations: iterations, Salt: make([]byte, 16)}
+	if _, err := rand.Read(file.Salt); err != nil {
+		return err
+	}
+	key, err := s.key(file.Salt, file.Iterations)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Ciphertext = gcm.Seal(nil, file.Nonce, plaintext, nil)
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Filename), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.Filename, data, 0600)
+}
+
+// Get - the stored password for the request
+func (s *FileStore) Get(r Request) (string, error) {
+	credentials, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	for _, c := range credentials {
+		if c.Resource == r.Resource && c.Username == r.Username {
+			return c.Password, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+// Store - adds or replaces the password for the request
+func (s *FileStore) Store(r Request, password string) error {
+	credentials, err := s.load()
+	if err != nil {
+		return err
+	}
+	kept := []storedCredential{{Resource: r.Resource, Username: r.Username, Password: password}}
+	for _, c := range credentials {
+		if c.Resource != r.Resource || c.Username != r.Username {
+			kept = append(kept, c)
+		}
+	}
+	return s.save(kept)
+}
+
+// Erase - removes the password for the request
+func (s *FileStore) Erase(r Request) error {
+	credentials, err := s.load()
+	if err != nil {
+		return err
+	}
+	kept := []storedCredential{}
+	for _, c := range credentials {
+		if c.Resource != r.Resource || c.Username != r.Username {
+			kept = append(kept, c)
+		}
+	}
+	if len(kept) == len(credentials) {
+		return ErrNotFound
+	}
+	return s.save(kept)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
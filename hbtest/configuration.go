@@ -0,0 +1,167 @@
+package hbtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// state - a configuration, the Server keeps the candidate being edited and the running one last committed
+type state struct {
+	entities  map[string][]entity // keyed by collection list key
+	documents map[string]entity   // keyed by path
+}
+
+func newState() *state {
+	return &state{entities: map[string][]entity{}, documents: map[string]entity{}}
+}
+
+// copy - a deep copy, so later edits to one configuration do not show through in the other
+func (s *state) copy() *state {
+	c := newState()
+	for k, items := range s.entities {
+		for _, e := range items {
+			c.entities[k] = append(c.entities[k], clone(e))
+		}
+	}
+	for k, d := range s.documents {
+		c.documents[k] = clone(d)
+	}
+	return c
+}
+
+func clone(e entity) entity {
+	data, _ := json.Marshal(e)
+	var c entity
+	_ = json.Unmarshal(data, &c)
+	return c
+}
+
+// view - Healthbot serves the committed configuration unless the working (candidate) one is asked for
+func (s *Server) view(r *http.Request) *state {
+	if r.URL.Query().Get("working") == "true" {
+		return s.candidate
+	}
+	return s.running
+}
+
+// Checkpoint - a committed configuration that can be rolled back to
+type Checkpoint struct {
+	Name      string `json:"name"`
+	Timestamp string `json:"timestamp"`
+}
+
+type checkpoint struct {
+	Checkpoint
+	configuration *state
+}
+
+// Job - an asynchronous commit or rollback
+type Job struct {
+	JobID   string `json:"job-id"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+type job struct {
+	Job
+	result  string
+	pending int
+}
+
+// SetJobPolls - the number of times a commit or rollback job reports in-progress before it finishes
+func (s *Server) SetJobPolls(polls int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobPolls = polls
+}
+
+// FailJobs - commits and rollbacks fail with the message and leave the running configuration alone, "" to succeed again
+func (s *Server) FailJobs(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobFailure = message
+}
+
+// Checkpoints - the committed configurations, oldest first
+func (s *Server) Checkpoints() (checkpoints []Checkpoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.checkpoints {
+		checkpoints = append(checkpoints, c.Checkpoint)
+	}
+	return
+}
+
+// Uncommitted - whether the candidate configuration differs from the running one
+func (s *Server) Uncommitted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	candidate, _ := json.Marshal(s.candidate.entities)
+	running, _ := json.Marshal(s.running.entities)
+	candidateDocuments, _ := json.Marshal(s.candidate.documents)
+	runningDocuments, _ := json.Marshal(s.running.documents)
+	return string(candidate) != string(running) || string(candidateDocuments) != string(runningDocuments)
+}
+
+// activate - makes the configuration the running one and records a checkpoint, returning the job, the caller holds the lock
+func (s *Server) activate(configuration *state) *job {
+	j := &job{Job: Job{JobID: fmt.Sprintf("job-%d", len(s.jobs)+1), Status: "in-progress"}, pending: s.jobPolls}
+	s.jobs[j.JobID] = j
+	if s.jobFailure != "" {
+		j.result, j.Message = "failed", s.jobFailure
+	} else {
+		j.result = "completed"
+		s.running = configuration.copy()
+		s.candidate = configuration.copy()
+		s.commits++
+		s.checkpoints = append(s.checkpoints, checkpoint{
+			Checkpoint:    Checkpoint{Name: fmt.Sprintf("checkpoint-%d", len(s.checkpoints)+1), Timestamp: time.Now().UTC().Format(time.RFC3339)},
+			configuration: configuration.copy(),
+		})
+	}
+	if j.pending == 0 {
+		j.Status = j.result
+	}
+	return j
+}
+
+func (s *Server) serveConfiguration(w http.ResponseWriter, r *http.Request, p string) {
+	switch {
+	case p == "" && r.Method == http.MethodPost:
+		writeJSON(w, http.StatusOK, entity{"job-id": s.activate(s.candidate).JobID})
+	case p == "" && r.Method == http.MethodDelete:
+		s.candidate = s.running.copy()
+		w.WriteHeader(http.StatusNoContent)
+	case p == "jobs" && r.Method == http.MethodGet:
+		j, ok := s.jobs[r.URL.Query().Get("job_id")]
+		if !ok {
+			writeError(w, http.StatusNotFound, "job "+r.URL.Query().Get("job_id")+" not found")
+			return
+		}
+		if j.pending > 0 {
+			j.pending--
+		} else {
+			j.Status = j.result
+		}
+		writeJSON(w, http.StatusOK, entity{"configuration-job": []Job{j.Job}})
+	case p == "checkpoints" && r.Method == http.MethodGet:
+		checkpoints := []Checkpoint{}
+		for _, c := range s.checkpoints {
+			checkpoints = append(checkpoints, c.Checkpoint)
+		}
+		writeJSON(w, http.StatusOK, entity{"checkpoint": checkpoints})
+	case p == "rollback" && r.Method == http.MethodPost:
+		name := r.URL.Query().Get("checkpoint")
+		for _, c := range s.checkpoints {
+			if c.Name == name {
+				writeJSON(w, http.StatusOK, entity{"job-id": s.activate(c.configuration).JobID})
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "checkpoint "+name+" not found")
+	default:
+		writeError(w, http.StatusMethodNotAllowed, r.Method+" not supported on "+configurationPath+p)
+	}
+}
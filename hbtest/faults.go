@@ -0,0 +1,66 @@
+package hbtest
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Fault - a scripted error response, the Path is a path.Match pattern and an empty Method matches any method.
+// Times is the number of requests to fail, zero fails every matching request.
+type Fault struct {
+	Method string `json:"method,omitempty" yaml:"method,omitempty"`
+	Path   string `json:"path" yaml:"path"`
+	Status int    `json:"status" yaml:"status"`
+	Body   string `json:"body,omitempty" yaml:"body,omitempty"`
+	Times  int    `json:"times,omitempty" yaml:"times,omitempty"`
+}
+
+// Faults - a script of Faults
+type Faults struct {
+	Fault []Fault `json:"fault" yaml:"fault"`
+}
+
+// LoadFaults - reads a yaml or json script of Faults
+func LoadFaults(filename string) ([]Fault, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var faults Faults
+	if err := yaml.Unmarshal(data, &faults); err != nil {
+		return nil, err
+	}
+	return faults.Fault, nil
+}
+
+// Inject - adds Faults, they are matched in the order they were injected
+func (s *Server) Inject(faults ...Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range faults {
+		f := faults[i]
+		s.faults = append(s.faults, &f)
+	}
+}
+
+// fault - the first Fault matching the request, the caller holds the lock
+func (s *Server) fault(r *http.Request) *Fault {
+	for i, f := range s.faults {
+		if f.Method != "" && f.Method != r.Method {
+			continue
+		}
+		if !matches(f.Path, r.URL.Path) {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
@@ -0,0 +1,587 @@
+// Package hbtest provides an in-memory Healthbot REST API for integration testing hb and tools built on it.
+package hbtest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/damianoneill/hb/types"
+)
+
+// Version - reported by the system-details endpoint
+const Version = "HealthBot 2.1.0 (hbtest)"
+
+// collection - a set of named entities, listed and posted under listPath and addressed individually under itemPath
+type collection struct {
+	folder           string
+	listPath         string
+	itemPath         string
+	listKey          string
+	idKey            string
+	newConfiguration func() types.Configuration
+}
+
+var collections = []collection{
+	{"devices", "/api/v1/devices/", "/api/v1/device/", "device", "device-id", func() types.Configuration { return &types.Devices{} }},
+	{"device-groups", "/api/v1/device-groups/", "/api/v1/device-group/", "device-group", "device-group-name", func() types.Configuration { return &types.DeviceGroups{} }},
+	{"playbook-instances", "", "", "device-group", "device-group-name", func() types.Configuration { return &types.PlaybookInstances{} }},
+	{"network-groups", "/api/v1/network-groups/", "/api/v1/network-group/", "network-group", "network-group-name", func() types.Configuration { return &types.NetworkGroups{} }},
+	{"playbooks", "/api/v1/playbooks/", "/api/v1/playbook/", "playbooks", "playbook-name", func() types.Configuration { return &types.Playbooks{} }},
+	{"retention-policies", "/api/v1/retention-policies/", "/api/v1/retention-policy/", "retention-policy", "retention-policy-name", func() types.Configuration { return &types.RetentionPolicies{} }},
+	{"topics", "/api/v1/topics/", "/api/v1/topic/", "topic", "topic-name", func() types.Configuration { return &types.Topics{} }},
+	{"schedulers", "/api/v1/system-settings/schedulers/", "/api/v1/system-settings/scheduler/", "scheduler", "name", func() types.Configuration { return &types.Schedulers{} }},
+	{"destinations", "/api/v1/system-settings/report-generation/destinations/", "/api/v1/system-settings/report-generation/destination/", "destination", "name", func() types.Configuration { return &types.Destinations{} }},
+	{"reports", "/api/v1/system-settings/report-generation/reports/", "/api/v1/system-settings/report-generation/report/", "report", "name", func() types.Configuration { return &types.Reports{} }},
+	{"frequency-profiles", "/api/v1/ingest/frequency-profiles/", "/api/v1/ingest/frequency-profile/", "frequency-profile", "name", func() types.Configuration { return &types.FrequencyProfiles{} }},
+}
+
+// documents - single entities that are replaced as a whole
+var documents = []struct {
+	folder           string
+	path             string
+	newConfiguration func() types.Configuration
+}{
+	{"syslog", "/api/v1/ingest/syslog/", func() types.Configuration { return &types.Syslog{} }},
+	{"snmp-notification", "/api/v1/ingest/snmp-notification/", func() types.Configuration { return &types.SnmpNotification{} }},
+}
+
+const (
+	factsPath         = "/api/v1/devices/facts/"
+	systemDetailsPath = "/api/v1/system-details/"
+	configurationPath = "/api/v1/configuration/"
+	helperFilesPath   = "/api/v1/files/helper-files/"
+	tsdbQueryPath     = "/api/v1/tsdb/query/"
+	alertsPath        = "/api/v1/alerts/"
+)
+
+type entity map[string]interface{}
+
+// Request - a request received by the Server
+type Request struct {
+	Method string
+	Path   string
+}
+
+// Server - in-memory Healthbot, safe for concurrent use
+type Server struct {
+	mu          sync.Mutex
+	candidate   *state // the configuration edited by the API
+	running     *state // the committed configuration
+	checkpoints []checkpoint
+	jobs        map[string]*job
+	jobPolls    int
+	jobFailure  string
+	facts       map[string]interface{}
+	helperFiles map[string][]byte
+	timeSeries  map[string]interface{}
+	queries     []string
+	alerts      []interface{}
+	faults      []*Fault
+	requests    []Request
+	commits     int
+}
+
+// NewServer - an empty Healthbot
+func NewServer() *Server {
+	return &Server{
+		candidate:   newState(),
+		running:     newState(),
+		jobs:        map[string]*job{},
+		facts:       map[string]interface{}{},
+		helperFiles: map[string][]byte{},
+		timeSeries:  map[string]interface{}{},
+	}
+}
+
+// Start - serves the Healthbot over TLS on an ephemeral localhost port
+func (s *Server) Start() *httptest.Server {
+	return httptest.NewTLSServer(s)
+}
+
+// Listen - serves the Healthbot over TLS on the address, e.g. localhost:8443
+func (s *Server) Listen(address string) (*httptest.Server, error) {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	ts := httptest.NewUnstartedServer(s)
+	ts.Listener.Close()
+	ts.Listener = l
+	ts.StartTLS()
+	return ts, nil
+}
+
+// Resource - the host:port of a started server, as used by the hb --resource flag
+func Resource(ts *httptest.Server) string {
+	return strings.TrimPrefix(ts.URL, "https://")
+}
+
+// Requests - the requests received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Commits - the number of times the configuration has been committed
+func (s *Server) Commits() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commits
+}
+
+// SetFacts - the facts returned for a Device by the devices/facts endpoint
+func (s *Server) SetFacts(deviceID string, facts interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.facts[deviceID] = facts
+}
+
+// SetTimeSeries - the response to every time series query against a database e.g. <device-group>:<device-id>
+func (s *Server) SetTimeSeries(database string, results interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timeSeries[database] = results
+}
+
+// Queries - the time series queries received so far
+func (s *Server) Queries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.queries...)
+}
+
+// AddAlert - an alert returned by the alerts endpoint
+func (s *Server) AddAlert(alert interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alerts = append(s.alerts, alert)
+}
+
+// SetHelperFile - stores a Helper File
+func (s *Server) SetHelperFile(name string, content []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.helperFiles[name] = content
+}
+
+// HelperFile - the content of a Helper File and whether it exists
+func (s *Server) HelperFile(name string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	content, ok := s.helperFiles[name]
+	return content, ok
+}
+
+// Seed - adds the entities in a configuration, e.g. a types.Devices, as if they had been posted and committed
+func (s *Server) Seed(configuration types.Configuration) error {
+	data, err := json.Marshal(configuration)
+	if err != nil {
+		return err
+	}
+	var body entity
+	if err := json.Unmarshal(data, &body); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range collections {
+		if _, ok := body[c.listKey]; ok {
+			return s.seed(c, body)
+		}
+	}
+	return fmt.Errorf("unknown configuration %T", configuration)
+}
+
+// Names - the ids of the entities in a collection e.g. Names("device")
+func (s *Server) Names(listKey string) (names []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range collections {
+		if c.listKey == listKey {
+			for _, e := range s.candidate.entities[listKey] {
+				names = append(names, fmt.Sprintf("%v", e[c.idKey]))
+			}
+			return
+		}
+	}
+	return
+}
+
+// LoadScaffold - seeds the committed configuration from a directory written by hb scaffold, folders that are missing are skipped
+func (s *Server) LoadScaffold(directory string) error {
+	for _, c := range collections {
+		c := c
+		if err := loadFolder(filepath.Join(directory, c.folder), c.newConfiguration, func(body entity) error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return s.seed(c, body)
+		}); err != nil {
+			return err
+		}
+	}
+	for _, d := range documents {
+		d := d
+		if err := loadFolder(filepath.Join(directory, d.folder), d.newConfiguration, func(body entity) error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.candidate.documents[d.path] = body
+			s.running.documents[d.path] = clone(body)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	helperFiles := filepath.Join(directory, "helper-files")
+	if _, err := os.Stat(helperFiles); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.Walk(helperFiles, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		content, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		name, _ := filepath.Rel(helperFiles, p)
+		s.SetHelperFile(filepath.ToSlash(name), content)
+		return nil
+	})
+}
+
+func loadFolder(folder string, newConfiguration func() types.Configuration, load func(entity) error) error {
+	if _, err := os.Stat(folder); os.IsNotExist(err) {
+		return nil
+	}
+	filenames, err := types.FindConfigurationFiles(folder, true)
+	if err != nil {
+		return err
+	}
+	for _, filename := range filenames {
+		docs, err := types.LoadDocuments(filename)
+		if err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			configuration := newConfiguration()
+			if err := configuration.Parse(doc); err != nil {
+				return fmt.Errorf("problem with %s %v", filename, err)
+			}
+			data, err := json.Marshal(configuration)
+			if err != nil {
+				return err
+			}
+			var body entity
+			if err := json.Unmarshal(data, &body); err != nil {
+				return err
+			}
+			if err := load(body); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// seed - upserts the body into both the candidate and running configuration, the caller holds the lock
+func (s *Server) seed(c collection, body entity) error {
+	if err := s.candidate.upsert(c, body); err != nil {
+		return err
+	}
+	return s.running.upsert(c, clone(body))
+}
+
+// upsert - adds or merges the entities in the body
+func (s *state) upsert(c collection, body entity) error {
+	items, ok := body[c.listKey].([]interface{})
+	if !ok {
+		if body[c.listKey] == nil {
+			return nil
+		}
+		return fmt.Errorf("expected a list of %s", c.listKey)
+	}
+	for _, item := range items {
+		e, ok := item.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected a %s object", c.listKey)
+		}
+		id, ok := e[c.idKey].(string)
+		if !ok || id == "" {
+			return fmt.Errorf("%s is missing %s", c.listKey, c.idKey)
+		}
+		if i := s.find(c, id); i >= 0 {
+			for k, v := range e {
+				s.entities[c.listKey][i][k] = v
+			}
+			continue
+		}
+		s.entities[c.listKey] = append(s.entities[c.listKey], entity(e))
+	}
+	return nil
+}
+
+func (s *state) find(c collection, id string) int {
+	for i, e := range s.entities[c.listKey] {
+		if e[c.idKey] == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// ServeHTTP - implements the subset of the Healthbot REST API used by hb
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path})
+
+	if f := s.fault(r); f != nil {
+		w.WriteHeader(f.Status)
+		fmt.Fprint(w, f.Body)
+		return
+	}
+
+	p := r.URL.Path
+	switch {
+	case p == systemDetailsPath && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, entity{"server-time": time.Now().UTC().Format(time.RFC3339), "version": Version})
+		return
+	case p == factsPath && r.Method == http.MethodGet:
+		s.serveFacts(w)
+		return
+	case strings.HasPrefix(p, configurationPath):
+		s.serveConfiguration(w, r, strings.Trim(strings.TrimPrefix(p, configurationPath), "/"))
+		return
+	case p == tsdbQueryPath && r.Method == http.MethodGet:
+		s.queries = append(s.queries, r.URL.Query().Get("q"))
+		results, ok := s.timeSeries[r.URL.Query().Get("db")]
+		if !ok {
+			results = entity{"results": []entity{{"error": "database not found: " + r.URL.Query().Get("db")}}}
+		}
+		writeJSON(w, http.StatusOK, results)
+		return
+	case p == alertsPath && r.Method == http.MethodGet:
+		alerts := s.alerts
+		if alerts == nil {
+			alerts = []interface{}{}
+		}
+		writeJSON(w, http.StatusOK, entity{"alerts": alerts})
+		return
+	case strings.HasPrefix(p, helperFilesPath):
+		s.serveHelperFiles(w, r, strings.Trim(strings.TrimPrefix(p, helperFilesPath), "/"))
+		return
+	}
+	for _, d := range documents {
+		if p == d.path {
+			s.serveDocument(w, r, d.path)
+			return
+		}
+	}
+	for _, c := range collections {
+		if c.listPath != "" && p == c.listPath {
+			s.serveCollection(w, r, c)
+			return
+		}
+		if c.itemPath != "" && strings.HasPrefix(p, c.itemPath) {
+			s.serveItem(w, r, c, strings.Trim(strings.TrimPrefix(p, c.itemPath), "/"))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "no such resource "+p)
+}
+
+func (s *Server) serveCollection(w http.ResponseWriter, r *http.Request, c collection) {
+	switch r.Method {
+	case http.MethodGet:
+		items := s.view(r).entities[c.listKey]
+		if items == nil {
+			items = []entity{}
+		}
+		writeJSON(w, http.StatusOK, entity{c.listKey: items})
+	case http.MethodPost, http.MethodPut:
+		var body entity
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := s.candidate.upsert(c, body); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, entity{})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+	}
+}
+
+func (s *Server) serveItem(w http.ResponseWriter, r *http.Request, c collection, id string) {
+	view := s.candidate
+	if r.Method == http.MethodGet {
+		view = s.view(r)
+	}
+	i := view.find(c, id)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, c.listKey+" "+id+" not found")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, view.entities[c.listKey][i])
+	case http.MethodPut:
+		var body entity
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if body[c.idKey] != id {
+			writeError(w, http.StatusBadRequest, c.idKey+" does not match "+id)
+			return
+		}
+		s.candidate.entities[c.listKey][i] = body
+		writeJSON(w, http.StatusOK, entity{})
+	case http.MethodDelete:
+		if c.listKey == "device" {
+			if group := s.groupUsing(id); group != "" {
+				writeError(w, http.StatusBadRequest, "device "+id+" is used by device group "+group)
+				return
+			}
+		}
+		s.candidate.entities[c.listKey] = append(s.candidate.entities[c.listKey][:i], s.candidate.entities[c.listKey][i+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+	}
+}
+
+// groupUsing - Healthbot refuses to delete a Device that is in a Device Group
+func (s *Server) groupUsing(deviceID string) string {
+	for _, group := range s.candidate.entities["device-group"] {
+		devices, _ := group["devices"].([]interface{})
+		for _, d := range devices {
+			if d == deviceID {
+				return fmt.Sprintf("%v", group["device-group-name"])
+			}
+		}
+	}
+	return ""
+}
+
+func (s *Server) serveDocument(w http.ResponseWriter, r *http.Request, p string) {
+	switch r.Method {
+	case http.MethodGet:
+		doc := s.view(r).documents[p]
+		if doc == nil {
+			doc = entity{}
+		}
+		writeJSON(w, http.StatusOK, doc)
+	case http.MethodPost, http.MethodPut:
+		var body entity
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.candidate.documents[p] = body
+		writeJSON(w, http.StatusOK, entity{})
+	case http.MethodDelete:
+		delete(s.candidate.documents, p)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+	}
+}
+
+func (s *Server) serveFacts(w http.ResponseWriter) {
+	facts := []entity{}
+	for _, device := range s.running.entities["device"] {
+		id := fmt.Sprintf("%v", device["device-id"])
+		fact := entity{"device-id": id}
+		if f, ok := s.facts[id]; ok {
+			fact["facts"] = f
+		}
+		facts = append(facts, fact)
+	}
+	writeJSON(w, http.StatusOK, facts)
+}
+
+func (s *Server) serveHelperFiles(w http.ResponseWriter, r *http.Request, name string) {
+	if name == "" {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+			return
+		}
+		var listing types.HelperFiles
+		for n, content := range s.helperFiles {
+			sum := sha256.Sum256(content)
+			listing.HelperFile = append(listing.HelperFile, types.HelperFile{FileName: n, Checksum: hex.EncodeToString(sum[:])})
+		}
+		sort.Slice(listing.HelperFile, func(i, j int) bool { return listing.HelperFile[i].FileName < listing.HelperFile[j].FileName })
+		if listing.HelperFile == nil {
+			listing.HelperFile = []types.HelperFile{}
+		}
+		writeJSON(w, http.StatusOK, listing)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		content, ok := s.helperFiles[name]
+		if !ok {
+			writeError(w, http.StatusNotFound, "helper file "+name+" not found")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(content)
+	case http.MethodPost, http.MethodPut:
+		f, _, err := r.FormFile("up_file")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		defer f.Close()
+		content, err := ioutil.ReadAll(f)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.helperFiles[name] = content
+		writeJSON(w, http.StatusOK, entity{})
+	case http.MethodDelete:
+		if _, ok := s.helperFiles[name]; !ok {
+			writeError(w, http.StatusNotFound, "helper file "+name+" not found")
+			return
+		}
+		delete(s.helperFiles, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, r.Method+" not supported")
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, detail string) {
+	writeJSON(w, status, entity{"status": status, "detail": detail})
+}
+
+// matches - the Fault path is a path.Match pattern e.g. /api/v1/device/*/
+func matches(pattern, p string) bool {
+	ok, err := path.Match(pattern, p)
+	return err == nil && ok
+}
@@ -0,0 +1,132 @@
+package hbtest
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/damianoneill/hb/types"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/resty.v1"
+)
+
+func newClient() *resty.Client {
+	return resty.New().SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true}) // nolint : gosec
+}
+
+func TestLoadScaffold(t *testing.T) {
+	server := NewServer()
+	err := server.LoadScaffold("testdata/scaffold")
+	assert.Nil(t, err, "Failed to load scaffold directory")
+	assert.Equal(t, []string{"mx960-1", "mx960-3"}, server.Names("device"))
+	assert.Equal(t, []string{"core"}, server.Names("device-group"))
+	assert.Equal(t, []string{"chassis-kpis-playbook"}, server.Names("playbooks"))
+	_, ok := server.HelperFile("lib/util.py")
+	assert.True(t, ok, "Expected Helper Files in subdirectories to be loaded")
+
+	ts := server.Start()
+	defer ts.Close()
+	resp, err := newClient().R().Get(ts.URL + "/api/v1/device-groups/")
+	assert.Nil(t, err)
+	var deviceGroups types.DeviceGroups
+	assert.Nil(t, json.Unmarshal(resp.Body(), &deviceGroups))
+	assert.Len(t, deviceGroups.DeviceGroup, 1, "Expected playbook instances to merge into the device group")
+	assert.EqualValues(t, "Core routers", *deviceGroups.DeviceGroup[0].Description, "Expected merge to keep the description")
+	assert.Equal(t, []string{"chassis-kpis-playbook"}, *deviceGroups.DeviceGroup[0].Playbooks)
+}
+
+func TestDevicesCRUD(t *testing.T) {
+	server := NewServer()
+	ts := server.Start()
+	defer ts.Close()
+	client := newClient()
+
+	resp, _ := client.R().SetBody(types.Devices{Device: []types.Device{{DeviceID: "mx1", Host: "10.0.0.1"}}}).Post(ts.URL + "/api/v1/devices/")
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	_ = server.Seed(&types.DeviceGroups{DeviceGroup: []types.DeviceGroup{{DeviceGroupName: "g", Devices: &[]string{"mx1"}}}})
+
+	resp, _ = client.R().Delete(ts.URL + "/api/v1/device/mx1/")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode(), "Expected a Device in a Device Group to be protected")
+
+	resp, _ = client.R().Delete(ts.URL + "/api/v1/device-group/g/")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode())
+	resp, _ = client.R().Delete(ts.URL + "/api/v1/device/mx1/")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode())
+	resp, _ = client.R().Delete(ts.URL + "/api/v1/device/mx1/")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode())
+
+	resp, _ = client.R().Post(ts.URL + "/api/v1/configuration/")
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, 1, server.Commits())
+}
+
+func TestCandidateConfiguration(t *testing.T) {
+	server := NewServer()
+	_ = server.Seed(&types.Devices{Device: []types.Device{{DeviceID: "mx1", Host: "10.0.0.1"}}})
+	ts := server.Start()
+	defer ts.Close()
+	client := newClient()
+
+	_, _ = client.R().SetBody(types.Devices{Device: []types.Device{{DeviceID: "mx2", Host: "10.0.0.2"}}}).Post(ts.URL + "/api/v1/devices/")
+	var running, candidate types.Devices
+	resp, _ := client.R().Get(ts.URL + "/api/v1/devices/")
+	assert.Nil(t, json.Unmarshal(resp.Body(), &running))
+	assert.Len(t, running.Device, 1, "Expected uncommitted Devices to be left out of the running configuration")
+	resp, _ = client.R().Get(ts.URL + "/api/v1/devices/?working=true")
+	assert.Nil(t, json.Unmarshal(resp.Body(), &candidate))
+	assert.Len(t, candidate.Device, 2)
+	assert.True(t, server.Uncommitted())
+
+	resp, _ = client.R().Delete(ts.URL + "/api/v1/configuration/")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode())
+	assert.Equal(t, []string{"mx1"}, server.Names("device"))
+
+	server.SetJobPolls(1)
+	resp, _ = client.R().Post(ts.URL + "/api/v1/configuration/")
+	assert.Contains(t, resp.String(), `"job-id":"job-1"`)
+	resp, _ = client.R().Get(ts.URL + "/api/v1/configuration/jobs/?job_id=job-1")
+	assert.Contains(t, resp.String(), `"status":"in-progress"`)
+	resp, _ = client.R().Get(ts.URL + "/api/v1/configuration/jobs/?job_id=job-1")
+	assert.Contains(t, resp.String(), `"status":"completed"`)
+	assert.Len(t, server.Checkpoints(), 1)
+}
+
+func TestFaults(t *testing.T) {
+	server := NewServer()
+	server.Inject(Fault{Method: "POST", Path: "/api/v1/device*/", Status: 500, Body: "boom", Times: 1})
+	ts := server.Start()
+	defer ts.Close()
+	client := newClient()
+
+	resp, _ := client.R().SetBody(types.Devices{}).Post(ts.URL + "/api/v1/devices/")
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode())
+	assert.Equal(t, "boom", resp.String())
+	resp, _ = client.R().SetBody(types.Devices{}).Post(ts.URL + "/api/v1/devices/")
+	assert.Equal(t, http.StatusOK, resp.StatusCode(), "Expected the fault to only fire once")
+	assert.Len(t, server.Requests(), 2)
+
+	faults, err := LoadFaults("testdata/faults.yml")
+	assert.Nil(t, err, "Failed to load faults script")
+	assert.Len(t, faults, 1)
+	assert.EqualValues(t, 503, faults[0].Status)
+}
+
+func TestHelperFiles(t *testing.T) {
+	server := NewServer()
+	server.SetHelperFile("bps.py", []byte("abc"))
+	ts := server.Start()
+	defer ts.Close()
+
+	resp, _ := newClient().R().Get(ts.URL + "/api/v1/files/helper-files/")
+	var helperFiles types.HelperFiles
+	assert.Nil(t, json.Unmarshal(resp.Body(), &helperFiles))
+	assert.EqualValues(t, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", helperFiles.Checksums()["bps.py"])
+
+	resp, _ = newClient().R().SetFileReader("up_file", "new.py", strings.NewReader("xyz")).Post(ts.URL + "/api/v1/files/helper-files/lib/new.py/")
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	content, ok := server.HelperFile("lib/new.py")
+	assert.True(t, ok)
+	assert.Equal(t, "xyz", string(content))
+}
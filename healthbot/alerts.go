@@ -0,0 +1,49 @@
+package healthbot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Alert - an alert raised by a Rule
+type Alert struct {
+	ID          string `json:"id"`
+	DeviceID    string `json:"device-id"`
+	DeviceGroup string `json:"device-group"`
+	Severity    string `json:"severity"`
+	Topic       string `json:"topic,omitempty"`
+	Rule        string `json:"rule,omitempty"`
+	Trigger     string `json:"trigger,omitempty"`
+	Message     string `json:"message"`
+	Time        string `json:"time"`
+}
+
+// AlertFilter - which alerts to list, the Device and Device Group are optional
+type AlertFilter struct {
+	Since       time.Time
+	DeviceID    string
+	DeviceGroup string
+}
+
+// Alerts - the alerts raised since the time in the filter, for its Device and Device Group when set
+func (c *Client) Alerts(ctx context.Context, filter AlertFilter) ([]Alert, error) {
+	params := url.Values{}
+	params.Set("since", filter.Since.UTC().Format(time.RFC3339))
+	if filter.DeviceID != "" {
+		params.Set("device-id", filter.DeviceID)
+	}
+	if filter.DeviceGroup != "" {
+		params.Set("device-group", filter.DeviceGroup)
+	}
+	var alerts struct {
+		Alerts []Alert `json:"alerts"`
+	}
+	resp, err := c.send(ctx, http.MethodGet, "/api/v1/alerts/?"+params.Encode(), nil, http.StatusOK)
+	if err == nil {
+		err = json.Unmarshal(resp.Body(), &alerts)
+	}
+	return alerts.Alerts, err
+}
@@ -0,0 +1,146 @@
+// Package healthbot is a client for the Healthbot REST API, the configuration it reads and writes is the types package.
+//
+//	client, err := healthbot.NewClient(healthbot.Options{BaseURL: "hb-server:8080", Username: "admin", Password: "changeme"})
+//	if err != nil {
+//		return err
+//	}
+//	devices, err := client.Devices(ctx)
+//
+// Every method returns an error rather than logging or exiting, a request that Healthbot does not accept is an *Error.
+package healthbot
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gopkg.in/resty.v1"
+)
+
+// Options - where Healthbot is and how to connect to it
+type Options struct {
+	// BaseURL of Healthbot e.g. https://hb-server:8080, https is assumed for a host:port
+	BaseURL  string
+	Username string
+	Password string
+	// TLSConfig e.g. to trust a private CA or skip verification, not used when a Transport is given
+	TLSConfig *tls.Config
+	// Timeout of each request, zero for none, a context can also cancel a request
+	Timeout time.Duration
+	// Transport e.g. to record or log the requests, http.DefaultTransport when nil
+	Transport http.RoundTripper
+}
+
+// Client - a Healthbot, safe for concurrent use
+type Client struct {
+	rest    *resty.Client
+	working bool
+}
+
+// NewClient - a client for the Healthbot in the options
+func NewClient(options Options) (*Client, error) {
+	base := options.BaseURL
+	if !strings.Contains(base, "://") {
+		base = "https://" + base
+	}
+	base = strings.TrimSuffix(base, "/")
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Healthbot base url %s: %v", options.BaseURL, err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid Healthbot base url %s: no host", options.BaseURL)
+	}
+	rest := resty.New().SetHostURL(base).SetBasicAuth(options.Username, options.Password).SetTimeout(options.Timeout)
+	switch {
+	case options.Transport != nil:
+		rest.SetTransport(options.Transport)
+	case options.TLSConfig != nil:
+		rest.SetTLSClientConfig(options.TLSConfig)
+	}
+	return &Client{rest: rest}, nil
+}
+
+// Working - a copy of the client that reads the candidate configuration, with the changes that are not committed
+// yet, rather than the committed configuration
+func (c *Client) Working() *Client {
+	return &Client{rest: c.rest, working: true}
+}
+
+// Response - the status and body of a request made with Do
+type Response struct {
+	statusCode int
+	body       []byte
+}
+
+// StatusCode - the HTTP status code
+func (r *Response) StatusCode() int {
+	return r.statusCode
+}
+
+// Body - the body of the response
+func (r *Response) Body() []byte {
+	return r.body
+}
+
+// String - the body of the response as a string
+func (r *Response) String() string {
+	return strings.TrimSpace(string(r.body))
+}
+
+// Do - sends a request for a path, e.g. /api/v1/devices/, with a body that is marshalled as json when not nil.
+// For the parts of the API that have no method, the response is returned whatever its status
+func (c *Client) Do(ctx context.Context, method, path string, body interface{}) (*Response, error) {
+	r := c.rest.R().SetContext(ctx)
+	if body != nil {
+		r.SetBody(body)
+	}
+	resp, err := r.Execute(method, path)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{statusCode: resp.StatusCode(), body: resp.Body()}, nil
+}
+
+// Error - a request that Healthbot did not accept
+type Error struct {
+	Method     string
+	Path       string
+	StatusCode int
+	// Detail is the body of the response, Healthbot describes the problem in it
+	Detail string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %s returned %d: %s", e.Method, e.Path, e.StatusCode, e.Detail)
+}
+
+// IsNotFound - whether the error is Healthbot reporting that an entity does not exist
+func IsNotFound(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.StatusCode == http.StatusNotFound
+}
+
+// expect - the response as an *Error when its status is not one of the expected ones
+func expect(method, path string, resp *Response, statuses ...int) error {
+	for _, status := range statuses {
+		if resp.StatusCode() == status {
+			return nil
+		}
+	}
+	return &Error{Method: method, Path: path, StatusCode: resp.StatusCode(), Detail: resp.String()}
+}
+
+// send - Do, failing when the status is not one of the expected ones
+func (c *Client) send(ctx context.Context, method, path string, body interface{}, statuses ...int) (*Response, error) {
+	resp, err := c.Do(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	return resp, expect(method, path, resp, statuses...)
+}
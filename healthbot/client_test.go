@@ -0,0 +1,319 @@
+package healthbot
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/damianoneill/hb/hbtest"
+	"github.com/damianoneill/hb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var testJobOptions = JobOptions{Timeout: time.Second, Interval: time.Millisecond}
+
+func newTestClient(t *testing.T) (*hbtest.Server, *Client, func()) {
+	server := hbtest.NewServer()
+	ts := server.Start()
+	client, err := NewClient(Options{BaseURL: ts.URL, Username: "admin", Password: "changeme", Transport: ts.Client().Transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, client, ts.Close
+}
+
+func TestNewClient(t *testing.T) {
+	server := hbtest.NewServer()
+	ts := server.Start()
+	defer ts.Close()
+
+	client, err := NewClient(Options{BaseURL: hbtest.Resource(ts) + "/", TLSConfig: &tls.Config{InsecureSkipVerify: true}}) // nolint : gosec
+	assert.Nil(t, err)
+	details, err := client.SystemDetails(context.Background())
+	assert.Nil(t, err, "Expected https to be assumed for a host:port")
+	assert.Equal(t, hbtest.Version, details.Version)
+
+	_, err = NewClient(Options{BaseURL: "https://"})
+	assert.NotNil(t, err, "Expected a base url without a host to be rejected")
+}
+
+func TestDevices(t *testing.T) {
+	server, client, stop := newTestClient(t)
+	defer stop()
+	ctx := context.Background()
+
+	var devices types.Devices
+	assert.Nil(t, devices.Parse([]byte(`{"device":[{"device-id":"mx1","host":"10.0.0.1","maintenance-window":"sunday"},{"device-id":"mx2","host":"10.0.0.2"}]}`)))
+	assert.Nil(t, client.CreateDevices(ctx, devices))
+
+	committed, err := client.Devices(ctx)
+	assert.Nil(t, err)
+	assert.Empty(t, committed.Device, "Expected the Devices to be in the candidate configuration only")
+	working, err := client.Working().Devices(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, working.Device, 2)
+
+	device, err := client.Working().Device(ctx, "mx1")
+	assert.Nil(t, err)
+	assert.Equal(t, "sunday", device.Extra["maintenance-window"], "Expected the fields hb does not model to be kept")
+	device.Host = "10.0.0.11"
+	assert.Nil(t, client.UpdateDevice(ctx, device))
+	device, _ = client.Working().Device(ctx, "mx1")
+	assert.Equal(t, "10.0.0.11", device.Host)
+	assert.Equal(t, "sunday", device.Extra["maintenance-window"], "Expected the update to write back the unmodelled fields")
+
+	assert.Nil(t, client.DeleteDevice(ctx, "mx2"))
+	assert.Equal(t, []string{"mx1"}, server.Names("device"))
+	_, err = client.Working().Device(ctx, "mx2")
+	assert.True(t, IsNotFound(err), "Expected a deleted Device to be not found, got %v", err)
+}
+
+func TestDeviceGroupsAndPlaybookInstances(t *testing.T) {
+	server, client, stop := newTestClient(t)
+	defer stop()
+	ctx := context.Background()
+	assert.Nil(t, server.Seed(&types.Devices{Device: []types.Device{{DeviceID: "mx1", Host: "10.0.0.1"}}}))
+
+	deviceGroups := types.DeviceGroups{DeviceGroup: []types.DeviceGroup{{DeviceGroupName: "core", Devices: &[]string{"mx1"}}}}
+	assert.Nil(t, client.CreateDeviceGroups(ctx, deviceGroups))
+	err := client.DeleteDevice(ctx, "mx1")
+	assert.NotNil(t, err, "Expected a Device in a Device Group to be kept")
+	assert.False(t, IsNotFound(err))
+
+	var playbookInstances types.PlaybookInstances
+	assert.Nil(t, playbookInstances.Parse([]byte(`{"device-group":[{"device-group-name":"core","playbooks":["kpis"],"variable":[{"instance-id":"i1","playbook":"kpis","rule":"r/r"}]}]}`)))
+	assert.Nil(t, client.UpdatePlaybookInstances(ctx, playbookInstances))
+	group, err := client.Working().DeviceGroup(ctx, "core")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"kpis"}, *group.Playbooks)
+	assert.Equal(t, []string{"mx1"}, *group.Devices, "Expected the Playbook Instances to leave the Devices alone")
+
+	instances, err := client.Working().PlaybookInstances(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "i1", instances.DeviceGroup[0].Variable[0].InstanceID)
+
+	description := "Core routers"
+	group.Description = &description
+	assert.Nil(t, client.UpdateDeviceGroup(ctx, group))
+	assert.Nil(t, client.DeleteDeviceGroup(ctx, "core"))
+	assert.Empty(t, server.Names("device-group"))
+}
+
+func TestPlaybooks(t *testing.T) {
+	server, client, stop := newTestClient(t)
+	defer stop()
+	ctx := context.Background()
+
+	var playbooks types.Playbooks
+	assert.Nil(t, playbooks.Parse([]byte(`{"playbooks":[{"playbook-name":"kpis","rules":["a/b"]}]}`)))
+	assert.Nil(t, client.CreatePlaybooks(ctx, playbooks))
+	listed, err := client.Working().Playbooks(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "kpis", listed.Playbooks[0].PlayBookName)
+
+	playbook, err := client.Working().Playbook(ctx, "kpis")
+	assert.Nil(t, err)
+	playbook.Rules = append(playbook.Rules, "c/d")
+	assert.Nil(t, client.UpdatePlaybook(ctx, playbook))
+	playbook, _ = client.Working().Playbook(ctx, "kpis")
+	assert.Equal(t, []string{"a/b", "c/d"}, playbook.Rules)
+	assert.Nil(t, client.DeletePlaybook(ctx, "kpis"))
+	assert.Empty(t, server.Names("playbooks"))
+}
+
+func TestNetworkGroups(t *testing.T) {
+	server, client, stop := newTestClient(t)
+	defer stop()
+	ctx := context.Background()
+
+	var networkGroups types.NetworkGroups
+	assert.Nil(t, networkGroups.Parse([]byte(`{"network-group":[{"network-group-name":"wan","playbooks":["kpis"],"notification":{"major":["slack"]}}]}`)))
+	assert.Nil(t, client.CreateNetworkGroups(ctx, networkGroups))
+	listed, err := client.Working().NetworkGroups(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, listed.NetworkGroup, 1)
+
+	group, err := client.Working().NetworkGroup(ctx, "wan")
+	assert.Nil(t, err)
+	assert.NotNil(t, group.Extra["notification"], "Expected the fields hb does not model to be kept")
+	description := "WAN links"
+	group.Description = &description
+	assert.Nil(t, client.UpdateNetworkGroup(ctx, group))
+	group, _ = client.Working().NetworkGroup(ctx, "wan")
+	assert.Equal(t, "WAN links", *group.Description)
+
+	assert.Nil(t, client.DeleteNetworkGroup(ctx, "wan"))
+	assert.Empty(t, server.Names("network-group"))
+}
+
+func TestConfigurationReads(t *testing.T) {
+	server, client, stop := newTestClient(t)
+	defer stop()
+	ctx := context.Background()
+	assert.Nil(t, server.Seed(&types.RetentionPolicies{RetentionPolicy: []types.RetentionPolicy{{RetentionPolicyName: "keep"}}}))
+	assert.Nil(t, server.Seed(&types.Topics{Topic: []types.Topic{{TopicName: "system"}}}))
+
+	retentionPolicies, err := client.RetentionPolicies(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"keep"}, retentionPolicies.Names())
+	topics, err := client.Topics(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"system"}, topics.Names())
+
+	_, err = client.Schedulers(ctx)
+	assert.Nil(t, err)
+	_, err = client.Destinations(ctx)
+	assert.Nil(t, err)
+	_, err = client.Reports(ctx)
+	assert.Nil(t, err)
+	_, err = client.FrequencyProfiles(ctx)
+	assert.Nil(t, err)
+	_, err = client.Syslog(ctx)
+	assert.Nil(t, err)
+	_, err = client.SnmpNotification(ctx)
+	assert.Nil(t, err)
+}
+
+func TestAlertsAndQuery(t *testing.T) {
+	server, client, stop := newTestClient(t)
+	defer stop()
+	ctx := context.Background()
+	server.AddAlert(map[string]interface{}{"id": "1", "device-id": "mx1", "device-group": "core", "severity": "major", "message": "down", "time": "2019-10-01T10:00:00Z"})
+	server.SetTimeSeries("core:mx1", map[string]interface{}{"results": []interface{}{map[string]interface{}{"series": []Series{
+		{Name: "system/check-cpu", Columns: []string{"time", "cpu"}, Values: [][]interface{}{{"2019-11-01T18:00:00Z", 42}}},
+	}}}})
+
+	alerts, err := client.Alerts(ctx, AlertFilter{Since: time.Now().Add(-time.Hour), DeviceID: "mx1"})
+	assert.Nil(t, err)
+	assert.Equal(t, []Alert{{ID: "1", DeviceID: "mx1", DeviceGroup: "core", Severity: "major", Message: "down", Time: "2019-10-01T10:00:00Z"}}, alerts)
+
+	series, err := client.Query(ctx, "core:mx1", "SELECT * FROM \"system/check-cpu\"")
+	assert.Nil(t, err)
+	assert.Len(t, series, 1)
+	assert.Equal(t, "system/check-cpu", series[0].Name)
+	assert.Equal(t, []string{"SELECT * FROM \"system/check-cpu\""}, server.Queries())
+
+	_, err = client.Query(ctx, "core:missing", "SELECT 1")
+	assert.NotNil(t, err, "Expected the error of a statement to be returned")
+}
+
+func TestHelperFiles(t *testing.T) {
+	server, client, stop := newTestClient(t)
+	defer stop()
+	ctx := context.Background()
+
+	assert.Nil(t, client.UploadHelperFile(ctx, "rules/a.rule", strings.NewReader("rule a")))
+	content, ok := server.HelperFile("rules/a.rule")
+	assert.True(t, ok)
+	assert.Equal(t, "rule a", string(content))
+
+	helperFiles, err := client.HelperFiles(ctx)
+	assert.Nil(t, err)
+	assert.Contains(t, helperFiles.Checksums(), "rules/a.rule")
+
+	content, err = client.HelperFile(ctx, "rules/a.rule")
+	assert.Nil(t, err)
+	assert.Equal(t, "rule a", string(content))
+
+	assert.Nil(t, client.DeleteHelperFile(ctx, "rules/a.rule"))
+	_, err = client.HelperFile(ctx, "rules/a.rule")
+	assert.True(t, IsNotFound(err))
+
+	assert.Nil(t, client.UploadHelperFile(ctx, "lib/my file#1?.py", strings.NewReader("escaped")))
+	content, ok = server.HelperFile("lib/my file#1?.py")
+	assert.True(t, ok, "Expected the name to be escaped")
+	assert.Equal(t, "escaped", string(content))
+	content, err = client.HelperFile(ctx, "lib/my file#1?.py")
+	assert.Nil(t, err)
+	assert.Equal(t, "escaped", string(content))
+	assert.Nil(t, client.DeleteHelperFile(ctx, "lib/my file#1?.py"))
+	_, ok = server.HelperFile("lib/my file#1?.py")
+	assert.False(t, ok)
+}
+
+func TestSystemDetailsAndFacts(t *testing.T) {
+	server, client, stop := newTestClient(t)
+	defer stop()
+	ctx := context.Background()
+	assert.Nil(t, server.Seed(&types.Devices{Device: []types.Device{{DeviceID: "mx1", Host: "10.0.0.1"}}}))
+	server.SetFacts("mx1", map[string]interface{}{"platform": "MX960", "release": "19.4R1", "re-count": 2})
+
+	details, err := client.SystemDetails(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, hbtest.Version, details.Version)
+
+	facts, err := client.DeviceFacts(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, facts, 1)
+	assert.Equal(t, "MX960", facts[0].Facts.Platform)
+	assert.Equal(t, int64(2), facts[0].Facts.Extra["re-count"], "Expected the facts hb does not model to be kept")
+}
+
+func TestCommitAndRollback(t *testing.T) {
+	server, client, stop := newTestClient(t)
+	defer stop()
+	ctx := context.Background()
+	server.SetJobPolls(2)
+
+	assert.Nil(t, client.CreateDevices(ctx, types.Devices{Device: []types.Device{{DeviceID: "mx1", Host: "10.0.0.1"}}}))
+	assert.Nil(t, client.Commit(ctx, testJobOptions))
+	assert.Nil(t, client.CreateDevices(ctx, types.Devices{Device: []types.Device{{DeviceID: "mx2", Host: "10.0.0.2"}}}))
+	assert.Nil(t, client.Commit(ctx, testJobOptions))
+	committed, _ := client.Devices(ctx)
+	assert.Len(t, committed.Device, 2)
+
+	checkpoints, err := client.Checkpoints(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, checkpoints, 2)
+	assert.Nil(t, client.Rollback(ctx, checkpoints[0].Name, testJobOptions))
+	committed, _ = client.Devices(ctx)
+	assert.Len(t, committed.Device, 1)
+
+	assert.Nil(t, client.CreateDevices(ctx, types.Devices{Device: []types.Device{{DeviceID: "mx3", Host: "10.0.0.3"}}}))
+	assert.Nil(t, client.Discard(ctx))
+	assert.False(t, server.Uncommitted())
+
+	server.FailJobs("disk full")
+	err = client.Commit(ctx, testJobOptions)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "disk full")
+}
+
+func TestCommitUnknownJobStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			fmt.Fprint(w, `{"job-id":"job-1"}`)
+			return
+		}
+		fmt.Fprint(w, `{"configuration-job":[{"job-id":"job-1","status":"paused"}]}`)
+	}))
+	defer ts.Close()
+	client, err := NewClient(Options{BaseURL: ts.URL})
+	assert.Nil(t, err)
+
+	err = client.Commit(context.Background(), JobOptions{Timeout: time.Minute, Interval: time.Millisecond})
+	assert.NotNil(t, err, "Expected an unknown status to fail rather than wait for the timeout")
+	assert.Contains(t, err.Error(), "unknown status paused")
+}
+
+func TestErrors(t *testing.T) {
+	server, client, stop := newTestClient(t)
+	defer stop()
+	server.Inject(hbtest.Fault{Method: "GET", Path: "/api/v1/devices/", Status: 500, Body: "simulated failure"})
+
+	_, err := client.Devices(context.Background())
+	e, ok := err.(*Error)
+	assert.True(t, ok, "Expected an *Error, got %v", err)
+	assert.Equal(t, 500, e.StatusCode)
+	assert.Equal(t, "simulated failure", e.Detail)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.SystemDetails(ctx)
+	assert.NotNil(t, err, "Expected a cancelled context to stop the request")
+}
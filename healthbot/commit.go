@@ -0,0 +1,127 @@
+package healthbot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const configurationPath = "/api/v1/configuration/"
+
+// ConfigurationJob - the state of an asynchronous commit or rollback
+type ConfigurationJob struct {
+	JobID   string `json:"job-id"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// JobOptions - how long to wait for a configuration job and how often to poll it
+type JobOptions struct {
+	Timeout  time.Duration
+	Interval time.Duration
+}
+
+// DefaultJobOptions - wait up to five minutes, polling every two seconds
+var DefaultJobOptions = JobOptions{Timeout: 5 * time.Minute, Interval: 2 * time.Second}
+
+// Checkpoint - a committed configuration that can be rolled back to
+type Checkpoint struct {
+	Name      string `json:"name"`
+	Timestamp string `json:"timestamp"`
+}
+
+// Commit - commits the candidate configuration and waits for the commit job to finish
+func (c *Client) Commit(ctx context.Context, options JobOptions) error {
+	resp, err := c.send(ctx, http.MethodPost, configurationPath, nil, http.StatusOK, http.StatusAccepted)
+	if err != nil {
+		return err
+	}
+	return c.waitForJob(ctx, resp.Body(), options)
+}
+
+// Discard - throws away the uncommitted changes in the candidate configuration
+func (c *Client) Discard(ctx context.Context) error {
+	_, err := c.send(ctx, http.MethodDelete, configurationPath, nil, http.StatusOK, http.StatusNoContent)
+	return err
+}
+
+// Checkpoints - the committed configurations, oldest first
+func (c *Client) Checkpoints(ctx context.Context) ([]Checkpoint, error) {
+	resp, err := c.send(ctx, http.MethodGet, configurationPath+"checkpoints/", nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	var checkpoints struct {
+		Checkpoint []Checkpoint `json:"checkpoint"`
+	}
+	err = json.Unmarshal(resp.Body(), &checkpoints)
+	return checkpoints.Checkpoint, err
+}
+
+// Rollback - restores the configuration committed at the checkpoint and waits for the rollback job to finish
+func (c *Client) Rollback(ctx context.Context, checkpoint string, options JobOptions) error {
+	resp, err := c.send(ctx, http.MethodPost, configurationPath+"rollback/?checkpoint="+url.QueryEscape(checkpoint), nil, http.StatusOK, http.StatusAccepted)
+	if err != nil {
+		return err
+	}
+	return c.waitForJob(ctx, resp.Body(), options)
+}
+
+// Job - the state of a commit or rollback job
+func (c *Client) Job(ctx context.Context, jobID string) (ConfigurationJob, error) {
+	resp, err := c.send(ctx, http.MethodGet, configurationPath+"jobs/?job_id="+url.QueryEscape(jobID), nil, http.StatusOK)
+	if err != nil {
+		return ConfigurationJob{}, err
+	}
+	var jobs struct {
+		ConfigurationJob []ConfigurationJob `json:"configuration-job"`
+	}
+	if err := json.Unmarshal(resp.Body(), &jobs); err != nil {
+		return ConfigurationJob{}, err
+	}
+	if len(jobs.ConfigurationJob) == 0 {
+		return ConfigurationJob{}, fmt.Errorf("configuration job %s not found", jobID)
+	}
+	return jobs.ConfigurationJob[0], nil
+}
+
+// waitForJob - polls the job named in the response body until it completes, fails, times out or the context is done,
+// a status that is not known fails straight away; older Healthbot releases commit synchronously and return no job
+func (c *Client) waitForJob(ctx context.Context, body []byte, options JobOptions) error {
+	var started ConfigurationJob
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &started); err != nil {
+			return err
+		}
+	}
+	if started.JobID == "" {
+		return nil
+	}
+	deadline := time.Now().Add(options.Timeout)
+	for {
+		job, err := c.Job(ctx, started.JobID)
+		if err != nil {
+			return err
+		}
+		switch job.Status {
+		case "completed", "success":
+			return nil
+		case "failed", "error":
+			return fmt.Errorf("configuration job %s failed: %s", job.JobID, job.Message)
+		case "in-progress", "pending", "queued", "running":
+		default:
+			return fmt.Errorf("configuration job %s has an unknown status %s: %s", job.JobID, job.Status, job.Message)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("configuration job %s is still %s after %v", job.JobID, job.Status, options.Timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(options.Interval):
+		}
+	}
+}
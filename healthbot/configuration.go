@@ -0,0 +1,252 @@
+package healthbot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/damianoneill/hb/types"
+)
+
+// resource - where a kind of entity is posted and listed, and addressed individually
+type resource struct {
+	kind     string
+	listPath string
+	itemPath string
+}
+
+var (
+	devicesResource           = resource{"Devices", "/api/v1/devices/", "/api/v1/device/"}
+	deviceGroupsResource      = resource{"Device Groups", "/api/v1/device-groups/", "/api/v1/device-group/"}
+	networkGroupsResource     = resource{"Network Groups", "/api/v1/network-groups/", "/api/v1/network-group/"}
+	playbooksResource         = resource{"Playbooks", "/api/v1/playbooks/", "/api/v1/playbook/"}
+	retentionPoliciesResource = resource{"Retention Policies", "/api/v1/retention-policies/", "/api/v1/retention-policy/"}
+	topicsResource            = resource{"Topics", "/api/v1/topics/", "/api/v1/topic/"}
+	schedulersResource        = resource{"Schedulers", "/api/v1/system-settings/schedulers/", "/api/v1/system-settings/scheduler/"}
+	destinationsResource      = resource{"Destinations", "/api/v1/system-settings/report-generation/destinations/", "/api/v1/system-settings/report-generation/destination/"}
+	reportsResource           = resource{"Reports", "/api/v1/system-settings/report-generation/reports/", "/api/v1/system-settings/report-generation/report/"}
+	frequencyProfilesResource = resource{"Frequency Profiles", "/api/v1/ingest/frequency-profiles/", "/api/v1/ingest/frequency-profile/"}
+)
+
+// the configuration that is a single document rather than a list of entities
+const (
+	syslogPath           = "/api/v1/ingest/syslog/"
+	snmpNotificationPath = "/api/v1/ingest/snmp-notification/"
+)
+
+func (r resource) item(id string) string {
+	return r.itemPath + url.PathEscape(id) + "/"
+}
+
+// read - GETs the path into v, from the candidate configuration for a Working client
+func (c *Client) read(ctx context.Context, path string, v interface{}) error {
+	if c.working {
+		path += "?working=true"
+	}
+	resp, err := c.send(ctx, http.MethodGet, path, nil, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(resp.Body(), v)
+}
+
+// create - POSTs the entities, adding them or replacing those with the same id
+func (c *Client) create(ctx context.Context, r resource, body interface{}) error {
+	_, err := c.send(ctx, http.MethodPost, r.listPath, body, http.StatusOK)
+	return err
+}
+
+func (c *Client) update(ctx context.Context, r resource, id string, body interface{}) error {
+	_, err := c.send(ctx, http.MethodPut, r.item(id), body, http.StatusOK)
+	return err
+}
+
+func (c *Client) remove(ctx context.Context, r resource, id string) error {
+	_, err := c.send(ctx, http.MethodDelete, r.item(id), nil, http.StatusOK, http.StatusNoContent)
+	return err
+}
+
+// Devices - every Device
+func (c *Client) Devices(ctx context.Context) (types.Devices, error) {
+	var devices types.Devices
+	err := c.read(ctx, devicesResource.listPath, &devices)
+	return devices, err
+}
+
+// Device - the Device with the id
+func (c *Client) Device(ctx context.Context, id string) (types.Device, error) {
+	var device types.Device
+	err := c.read(ctx, devicesResource.item(id), &device)
+	return device, err
+}
+
+// CreateDevices - adds the Devices, or replaces those that exist, in the candidate configuration
+func (c *Client) CreateDevices(ctx context.Context, devices types.Devices) error {
+	return c.create(ctx, devicesResource, devices)
+}
+
+// UpdateDevice - replaces the Device with the same device-id in the candidate configuration
+func (c *Client) UpdateDevice(ctx context.Context, device types.Device) error {
+	return c.update(ctx, devicesResource, device.DeviceID, device)
+}
+
+// DeleteDevice - removes the Device from the candidate configuration, it can not be in a Device Group
+func (c *Client) DeleteDevice(ctx context.Context, id string) error {
+	return c.remove(ctx, devicesResource, id)
+}
+
+// DeviceGroups - every Device Group
+func (c *Client) DeviceGroups(ctx context.Context) (types.DeviceGroups, error) {
+	var deviceGroups types.DeviceGroups
+	err := c.read(ctx, deviceGroupsResource.listPath, &deviceGroups)
+	return deviceGroups, err
+}
+
+// DeviceGroup - the Device Group with the name
+func (c *Client) DeviceGroup(ctx context.Context, name string) (types.DeviceGroup, error) {
+	var deviceGroup types.DeviceGroup
+	err := c.read(ctx, deviceGroupsResource.item(name), &deviceGroup)
+	return deviceGroup, err
+}
+
+// CreateDeviceGroups - adds the Device Groups, or replaces those that exist, in the candidate configuration
+func (c *Client) CreateDeviceGroups(ctx context.Context, deviceGroups types.DeviceGroups) error {
+	return c.create(ctx, deviceGroupsResource, deviceGroups)
+}
+
+// UpdateDeviceGroup - replaces the Device Group with the same device-group-name in the candidate configuration
+func (c *Client) UpdateDeviceGroup(ctx context.Context, deviceGroup types.DeviceGroup) error {
+	return c.update(ctx, deviceGroupsResource, deviceGroup.DeviceGroupName, deviceGroup)
+}
+
+// DeleteDeviceGroup - removes the Device Group from the candidate configuration
+func (c *Client) DeleteDeviceGroup(ctx context.Context, name string) error {
+	return c.remove(ctx, deviceGroupsResource, name)
+}
+
+// Playbooks - every Playbook
+func (c *Client) Playbooks(ctx context.Context) (types.Playbooks, error) {
+	var playbooks types.Playbooks
+	err := c.read(ctx, playbooksResource.listPath, &playbooks)
+	return playbooks, err
+}
+
+// CreatePlaybooks - adds the Playbooks, or replaces those that exist, in the candidate configuration
+func (c *Client) CreatePlaybooks(ctx context.Context, playbooks types.Playbooks) error {
+	return c.create(ctx, playbooksResource, playbooks)
+}
+
+// Playbook - the Playbook with the name
+func (c *Client) Playbook(ctx context.Context, name string) (types.Playbook, error) {
+	var playbook types.Playbook
+	err := c.read(ctx, playbooksResource.item(name), &playbook)
+	return playbook, err
+}
+
+// UpdatePlaybook - replaces the Playbook with the same playbook-name in the candidate configuration
+func (c *Client) UpdatePlaybook(ctx context.Context, playbook types.Playbook) error {
+	return c.update(ctx, playbooksResource, playbook.PlayBookName, playbook)
+}
+
+// DeletePlaybook - removes the Playbook from the candidate configuration
+func (c *Client) DeletePlaybook(ctx context.Context, name string) error {
+	return c.remove(ctx, playbooksResource, name)
+}
+
+// PlaybookInstances - the Playbooks and Rule variables of every Device Group
+func (c *Client) PlaybookInstances(ctx context.Context) (types.PlaybookInstances, error) {
+	var playbookInstances types.PlaybookInstances
+	err := c.read(ctx, deviceGroupsResource.listPath, &playbookInstances)
+	return playbookInstances, err
+}
+
+// UpdatePlaybookInstances - sets the Playbooks and Rule variables of the Device Groups in the candidate configuration
+func (c *Client) UpdatePlaybookInstances(ctx context.Context, playbookInstances types.PlaybookInstances) error {
+	return c.create(ctx, deviceGroupsResource, playbookInstances)
+}
+
+// NetworkGroups - every Network Group
+func (c *Client) NetworkGroups(ctx context.Context) (types.NetworkGroups, error) {
+	var networkGroups types.NetworkGroups
+	err := c.read(ctx, networkGroupsResource.listPath, &networkGroups)
+	return networkGroups, err
+}
+
+// NetworkGroup - the Network Group with the name
+func (c *Client) NetworkGroup(ctx context.Context, name string) (types.NetworkGroup, error) {
+	var networkGroup types.NetworkGroup
+	err := c.read(ctx, networkGroupsResource.item(name), &networkGroup)
+	return networkGroup, err
+}
+
+// CreateNetworkGroups - adds the Network Groups, or replaces those that exist, in the candidate configuration
+func (c *Client) CreateNetworkGroups(ctx context.Context, networkGroups types.NetworkGroups) error {
+	return c.create(ctx, networkGroupsResource, networkGroups)
+}
+
+// UpdateNetworkGroup - replaces the Network Group with the same network-group-name in the candidate configuration
+func (c *Client) UpdateNetworkGroup(ctx context.Context, networkGroup types.NetworkGroup) error {
+	return c.update(ctx, networkGroupsResource, networkGroup.NetworkGroupName, networkGroup)
+}
+
+// DeleteNetworkGroup - removes the Network Group from the candidate configuration
+func (c *Client) DeleteNetworkGroup(ctx context.Context, name string) error {
+	return c.remove(ctx, networkGroupsResource, name)
+}
+
+// RetentionPolicies - every Retention Policy
+func (c *Client) RetentionPolicies(ctx context.Context) (types.RetentionPolicies, error) {
+	var retentionPolicies types.RetentionPolicies
+	err := c.read(ctx, retentionPoliciesResource.listPath, &retentionPolicies)
+	return retentionPolicies, err
+}
+
+// Topics - every Topic with its Rules
+func (c *Client) Topics(ctx context.Context) (types.Topics, error) {
+	var topics types.Topics
+	err := c.read(ctx, topicsResource.listPath, &topics)
+	return topics, err
+}
+
+// Schedulers - every Scheduler
+func (c *Client) Schedulers(ctx context.Context) (types.Schedulers, error) {
+	var schedulers types.Schedulers
+	err := c.read(ctx, schedulersResource.listPath, &schedulers)
+	return schedulers, err
+}
+
+// Destinations - every Destination of the generated Reports
+func (c *Client) Destinations(ctx context.Context) (types.Destinations, error) {
+	var destinations types.Destinations
+	err := c.read(ctx, destinationsResource.listPath, &destinations)
+	return destinations, err
+}
+
+// Reports - every Report
+func (c *Client) Reports(ctx context.Context) (types.Reports, error) {
+	var reports types.Reports
+	err := c.read(ctx, reportsResource.listPath, &reports)
+	return reports, err
+}
+
+// FrequencyProfiles - every Frequency Profile
+func (c *Client) FrequencyProfiles(ctx context.Context) (types.FrequencyProfiles, error) {
+	var frequencyProfiles types.FrequencyProfiles
+	err := c.read(ctx, frequencyProfilesResource.listPath, &frequencyProfiles)
+	return frequencyProfiles, err
+}
+
+// Syslog - the syslog ingest patterns and pattern sets
+func (c *Client) Syslog(ctx context.Context) (types.Syslog, error) {
+	var syslog types.Syslog
+	err := c.read(ctx, syslogPath, &syslog)
+	return syslog, err
+}
+
+// SnmpNotification - the SNMP notification ingest settings
+func (c *Client) SnmpNotification(ctx context.Context) (types.SnmpNotification, error) {
+	var snmpNotification types.SnmpNotification
+	err := c.read(ctx, snmpNotificationPath, &snmpNotification)
+	return snmpNotification, err
+}
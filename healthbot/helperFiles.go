@@ -0,0 +1,60 @@
+package healthbot
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/damianoneill/hb/types"
+)
+
+const helperFilesPath = "/api/v1/files/helper-files/"
+
+// helperFilePath - the path of the Helper File, each directory in the name is escaped separately
+func helperFilePath(name string) string {
+	segments := strings.Split(name, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return helperFilesPath + strings.Join(segments, "/") + "/"
+}
+
+// HelperFiles - the name and SHA-256 digest of every Helper File
+func (c *Client) HelperFiles(ctx context.Context) (types.HelperFiles, error) {
+	var helperFiles types.HelperFiles
+	resp, err := c.send(ctx, http.MethodGet, helperFilesPath, nil, http.StatusOK)
+	if err == nil {
+		err = json.Unmarshal(resp.Body(), &helperFiles)
+	}
+	return helperFiles, err
+}
+
+// HelperFile - the content of the Helper File, the name is relative to the helper-files directory e.g. rules/a.rule
+func (c *Client) HelperFile(ctx context.Context, name string) ([]byte, error) {
+	resp, err := c.send(ctx, http.MethodGet, helperFilePath(name), nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body(), nil
+}
+
+// UploadHelperFile - stores the content as the Helper File, replacing it if it exists; Helper Files are not part of
+// the candidate configuration
+func (c *Client) UploadHelperFile(ctx context.Context, name string, content io.Reader) error {
+	p := helperFilePath(name)
+	resp, err := c.rest.R().SetContext(ctx).SetFileReader("up_file", path.Base(name), content).Post(p)
+	if err != nil {
+		return err
+	}
+	return expect(http.MethodPost, p, &Response{statusCode: resp.StatusCode(), body: resp.Body()}, http.StatusOK)
+}
+
+// DeleteHelperFile - removes the Helper File
+func (c *Client) DeleteHelperFile(ctx context.Context, name string) error {
+	_, err := c.send(ctx, http.MethodDelete, helperFilePath(name), nil, http.StatusOK, http.StatusNoContent)
+	return err
+}
@@ -0,0 +1,79 @@
+package healthbot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/damianoneill/hb/types"
+)
+
+// SystemDetails - the version and time of the Healthbot server
+type SystemDetails struct {
+	ServerTime string `json:"server-time"`
+	Version    string `json:"version"`
+}
+
+// DeviceFacts - the facts Healthbot has collected for each Device
+type DeviceFacts []DeviceFact
+
+// DeviceFact - the facts of a Device, those that are not modelled are kept in Extra
+type DeviceFact struct {
+	DeviceID string      `json:"device-id"`
+	Facts    Facts       `json:"facts,omitempty"`
+	Extra    types.Extra `json:"-"`
+}
+
+// Facts - what Healthbot knows of a Device, e.g. its platform and release
+type Facts struct {
+	Hostname  string `json:"hostname"`
+	JunosInfo []struct {
+		LastRebootReason string `json:"last-reboot-reason"`
+		MastershipState  string `json:"mastership-state"`
+		Model            string `json:"model"`
+		Name             string `json:"name"`
+		Status           string `json:"status"`
+		UpTime           string `json:"up-time"`
+	} `json:"junos-info"`
+	Platform     string `json:"platform"`
+	PlatformInfo []struct {
+		Name     string `json:"name"`
+		Platform string `json:"platform"`
+	} `json:"platform-info"`
+	Product      string      `json:"product"`
+	Release      string      `json:"release"`
+	SerialNumber string      `json:"serial-number"`
+	Extra        types.Extra `json:"-"`
+}
+
+// UnmarshalJSON - keeps the facts that are not modelled
+func (f *DeviceFacts) UnmarshalJSON(data []byte) error {
+	type deviceFacts DeviceFacts
+	return types.UnmarshalExtra(data, (*deviceFacts)(f))
+}
+
+// MarshalJSON - writes back the facts that are not modelled
+func (f DeviceFacts) MarshalJSON() ([]byte, error) {
+	type deviceFacts DeviceFacts
+	return types.MarshalExtra(deviceFacts(f))
+}
+
+// SystemDetails - the version and time of the server
+func (c *Client) SystemDetails(ctx context.Context) (SystemDetails, error) {
+	var systemDetails SystemDetails
+	resp, err := c.send(ctx, http.MethodGet, "/api/v1/system-details/", nil, http.StatusOK)
+	if err == nil {
+		err = json.Unmarshal(resp.Body(), &systemDetails)
+	}
+	return systemDetails, err
+}
+
+// DeviceFacts - the facts of every Device
+func (c *Client) DeviceFacts(ctx context.Context) (DeviceFacts, error) {
+	var deviceFacts DeviceFacts
+	resp, err := c.send(ctx, http.MethodGet, "/api/v1/devices/facts/", nil, http.StatusOK)
+	if err == nil {
+		err = json.Unmarshal(resp.Body(), &deviceFacts)
+	}
+	return deviceFacts, err
+}
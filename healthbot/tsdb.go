@@ -0,0 +1,45 @@
+package healthbot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// Series - the rows returned for a measurement, the first column is the time
+type Series struct {
+	Name    string          `json:"name"`
+	Columns []string        `json:"columns"`
+	Values  [][]interface{} `json:"values"`
+}
+
+// Query - runs the InfluxQL query against a time series database, named <device-group>:<device-id>,
+// returning the series of every statement; a statement that fails is returned as an error
+func (c *Client) Query(ctx context.Context, database, query string) ([]Series, error) {
+	params := url.Values{}
+	params.Set("db", database)
+	params.Set("q", query)
+	resp, err := c.send(ctx, http.MethodGet, "/api/v1/tsdb/query/?"+params.Encode(), nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	var results struct {
+		Results []struct {
+			Series []Series `json:"series"`
+			Error  string   `json:"error,omitempty"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(resp.Body(), &results); err != nil {
+		return nil, err
+	}
+	var series []Series
+	for _, r := range results.Results {
+		if r.Error != "" {
+			return nil, errors.New(r.Error)
+		}
+		series = append(series, r.Series...)
+	}
+	return series, nil
+}
@@ -0,0 +1,213 @@
+// Package logging writes leveled diagnostics to stderr, as text or JSON, so that stdout only carries
+// the data hb was asked for and can be piped into other tools.
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/damianoneill/hb/redact"
+)
+
+// Level - the severity of a log entry, a Logger writes the entries at or below its Level
+type Level int
+
+// Levels in increasing verbosity
+const (
+	ErrorLevel Level = iota
+	WarnLevel
+	InfoLevel
+	DebugLevel
+	TraceLevel
+)
+
+var levelNames = []string{"error", "warn", "info", "debug", "trace"}
+
+func (l Level) String() string {
+	if l < ErrorLevel || l > TraceLevel {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel - the Level for error, warn, info, debug or trace
+func ParseLevel(name string) (Level, error) {
+	for i, n := range levelNames {
+		if strings.EqualFold(n, name) {
+			return Level(i), nil
+		}
+	}
+	return InfoLevel, fmt.Errorf("unknown log level %s, expected one of %s", name, strings.Join(levelNames, ", "))
+}
+
+// Formats supported by New
+const (
+	Text = "text"
+	JSON = "json"
+)
+
+// Fields - the structured context of a log entry, sensitive fields are masked
+type Fields map[string]interface{}
+
+// Logger - writes log entries as lines of text or JSON
+type Logger struct {
+	mu    sync.Mutex
+	out   io.Writer
+	level Level
+	json  bool
+	now   func() time.Time
+}
+
+// New - a Logger writing the entries at or below the level to out, in the text or json format
+func New(out io.Writer, level Level, format string) (*Logger, error) {
+	if format != Text && format != JSON {
+		return nil, fmt.Errorf("unknown log format %s, expected text or json", format)
+	}
+	return &Logger{out: out, level: level, json: format == JSON, now: time.Now}, nil
+}
+
+// Enabled - entries at the level would be written
+func (l *Logger) Enabled(level Level) bool {
+	return level <= l.level
+}
+
+// Log - writes the message and fields if the level is enabled
+func (l *Logger) Log(level Level, msg string, fields Fields) {
+	if !l.Enabled(level) {
+		return
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var line []byte
+	if l.json {
+		entry := map[string]interface{}{}
+		for _, k := range keys {
+			entry[k] = fieldValue(k, fields[k])
+		}
+		entry["time"] = l.now().UTC().Format(time.RFC3339Nano)
+		entry["level"] = level.String()
+		entry["msg"] = msg
+		line, _ = json.Marshal(entry)
+	} else {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%-5s %s", strings.ToUpper(level.String()), msg)
+		for _, k := range keys {
+			fmt.Fprintf(&b, " %s=%s", k, textValue(fieldValue(k, fields[k])))
+		}
+		line = []byte(b.String())
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.out.Write(append(line, '\n'))
+}
+
+// fieldValue - the value with credentials masked, a sensitive field is masked whatever its value
+func fieldValue(key string, value interface{}) interface{} {
+	if redact.IsSensitive(key) {
+		return redact.Mask
+	}
+	if err, ok := value.(error); ok {
+		return err.Error()
+	}
+	return value
+}
+
+func textValue(value interface{}) string {
+	s := fmt.Sprintf("%v", value)
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
+
+// Errorf - logs at ErrorLevel
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.Log(ErrorLevel, fmt.Sprintf(format, args...), nil)
+}
+
+// Warnf - logs at WarnLevel
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.Log(WarnLevel, fmt.Sprintf(format, args...), nil)
+}
+
+// Infof - logs at InfoLevel
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.Log(InfoLevel, fmt.Sprintf(format, args...), nil)
+}
+
+// Debugf - logs at DebugLevel
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.Log(DebugLevel, fmt.Sprintf(format, args...), nil)
+}
+
+// Tracef - logs at TraceLevel
+func (l *Logger) Tracef(format string, args ...interface{}) {
+	l.Log(TraceLevel, fmt.Sprintf(format, args...), nil)
+}
+
+var (
+	stdMu sync.RWMutex
+	std   = &Logger{out: os.Stderr, level: InfoLevel, now: time.Now}
+)
+
+// Default - the Logger used by the package functions, info and above as text to stderr until replaced
+func Default() *Logger {
+	stdMu.RLock()
+	defer stdMu.RUnlock()
+	return std
+}
+
+// SetDefault - replaces the Logger used by the package functions, returning the previous one
+func SetDefault(l *Logger) *Logger {
+	stdMu.Lock()
+	defer stdMu.Unlock()
+	previous := std
+	std = l
+	return previous
+}
+
+// Log - logs to the Default Logger
+func Log(level Level, msg string, fields Fields) {
+	Default().Log(level, msg, fields)
+}
+
+// Errorf - logs to the Default Logger at ErrorLevel
+func Errorf(format string, args ...interface{}) {
+	Default().Errorf(format, args...)
+}
+
+// Warnf - logs to the Default Logger at WarnLevel
+func Warnf(format string, args ...interface{}) {
+	Default().Warnf(format, args...)
+}
+
+// Infof - logs to the Default Logger at InfoLevel
+func Infof(format string, args ...interface{}) {
+	Default().Infof(format, args...)
+}
+
+// Debugf - logs to the Default Logger at DebugLevel
+func Debugf(format string, args ...interface{}) {
+	Default().Debugf(format, args...)
+}
+
+// Tracef - logs to the Default Logger at TraceLevel
+func Tracef(format string, args ...interface{}) {
+	Default().Tracef(format, args...)
+}
+
+// Fatal - logs the error to the Default Logger and exits with status 1
+func Fatal(err error) {
+	Default().Errorf("%v", err)
+	os.Exit(1)
+}
@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/resty.v1"
+)
+
+func TestLevels(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(&out, WarnLevel, Text)
+	assert.Nil(t, err)
+	logger.Errorf("failed %d", 1)
+	logger.Warnf("careful")
+	logger.Infof("hidden")
+	assert.Equal(t, "ERROR failed 1\nWARN  careful\n", out.String())
+
+	level, err := ParseLevel("TRACE")
+	assert.Nil(t, err)
+	assert.Equal(t, TraceLevel, level)
+	_, err = ParseLevel("verbose")
+	assert.NotNil(t, err)
+	_, err = New(&out, InfoLevel, "xml")
+	assert.NotNil(t, err)
+}
+
+func TestFormats(t *testing.T) {
+	var out bytes.Buffer
+	logger, _ := New(&out, InfoLevel, Text)
+	logger.Log(InfoLevel, "created", Fields{"device-id": "mx1", "password": "secret", "note": "two words"})
+	assert.Equal(t, "INFO  created device-id=mx1 note=\"two words\" password=****\n", out.String())
+
+	out.Reset()
+	logger, _ = New(&out, InfoLevel, JSON)
+	logger.now = func() time.Time { return time.Date(2019, 11, 1, 18, 0, 0, 0, time.UTC) }
+	logger.Log(WarnLevel, "created", Fields{"device-id": "mx1", "token": "abc"})
+	var entry map[string]interface{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, map[string]interface{}{"time": "2019-11-01T18:00:00Z", "level": "warn", "msg": "created", "device-id": "mx1", "token": "****"}, entry)
+}
+
+func TestTransport(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			body, _ := ioutil.ReadAll(r.Body)
+			assert.Contains(t, string(body), "device-secret", "Expected the request body to reach the server")
+		}
+		_, _ = w.Write([]byte(`{"snmp":{"v2":{"community":"public"}}}`))
+	}))
+	defer ts.Close()
+
+	var out bytes.Buffer
+	logger, _ := New(&out, TraceLevel, JSON)
+	client := resty.New().SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true}) // nolint : gosec
+	client.SetTransport(NewTransport(client.GetClient().Transport, logger))
+	resp, err := client.R().
+		SetBasicAuth("admin", "changeme").
+		SetBody(map[string]string{"password": "device-secret"}).
+		Post(ts.URL + "/api/v1/devices/?token=abc")
+	assert.Nil(t, err)
+	assert.Contains(t, resp.String(), "public", "Expected the response body to reach the client")
+
+	logged := out.String()
+	assert.Contains(t, logged, `"status":200`)
+	for _, secret := range []string{"device-secret", "public", "token=abc", "Basic "} {
+		assert.False(t, strings.Contains(logged, secret), "Expected %s to be redacted from %s", secret, logged)
+	}
+
+	out.Reset()
+	logger.level = InfoLevel
+	_, _ = client.R().Get(ts.URL)
+	assert.Empty(t, out.String(), "Expected requests to be logged at debug")
+}
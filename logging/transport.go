@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/damianoneill/hb/redact"
+)
+
+// Transport - a RoundTripper that logs each request to the Next RoundTripper, the method, URL,
+// status and duration at DebugLevel and the redacted headers and bodies at TraceLevel
+type Transport struct {
+	Next   http.RoundTripper
+	Logger *Logger
+}
+
+// NewTransport - logs to the Default Logger when logger is nil
+func NewTransport(next http.RoundTripper, logger *Logger) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Transport{Next: next, Logger: logger}
+}
+
+func (t *Transport) logger() *Logger {
+	if t.Logger != nil {
+		return t.Logger
+	}
+	return Default()
+}
+
+// RoundTrip - implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	logger := t.logger()
+	if !logger.Enabled(DebugLevel) {
+		return t.Next.RoundTrip(req)
+	}
+	trace := logger.Enabled(TraceLevel)
+	fields := Fields{"method": req.Method, "url": redact.URL(req.URL)}
+	if trace {
+		fields["request-header"] = redact.Header(req.Header)
+		if req.Body != nil {
+			body, err := ioutil.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+			fields["request-body"] = redact.Body(body)
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.Next.RoundTrip(req)
+	fields["duration"] = time.Since(start).Round(time.Millisecond).String()
+	if err != nil {
+		fields["error"] = err
+		logger.Log(DebugLevel, "request failed", fields)
+		return nil, err
+	}
+	fields["status"] = resp.StatusCode
+	if trace {
+		fields["response-header"] = redact.Header(resp.Header)
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		fields["response-body"] = redact.Body(body)
+	}
+	logger.Log(DebugLevel, "request", fields)
+	return resp, nil
+}
@@ -0,0 +1,107 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Recorder - a RoundTripper that records each interaction with the Next RoundTripper to a session file
+type Recorder struct {
+	Next     http.RoundTripper
+	Filename string
+
+	mu           sync.Mutex
+	interactions int
+}
+
+// sessionEnd - how a session file written by Save ends, an interaction is appended in front of it
+const sessionEnd = "\n  ]\n}"
+
+// NewRecorder - records to the file, each interaction is appended as it happens so nothing is lost on exit
+func NewRecorder(filename string, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{Next: next, Filename: filename}
+}
+
+// RoundTrip - implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		if requestBody, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	resp, err := r.Next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	interaction := Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: RedactHeader(req.Header),
+			Body:   RedactBody(requestBody),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     RedactHeader(resp.Header),
+			Body:       RedactBody(responseBody),
+		},
+	}
+	if err := r.append(interaction); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// append - writes the interaction to the end of the session file, the caller holds the lock
+func (r *Recorder) append(interaction Interaction) error {
+	if r.interactions == 0 {
+		session := Session{Interactions: []Interaction{interaction}}
+		if err := session.Save(r.Filename); err != nil {
+			return err
+		}
+		r.interactions++
+		return nil
+	}
+	data, err := json.MarshalIndent(interaction, "    ", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.Filename, os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err == nil {
+		_, err = f.WriteAt([]byte(",\n    "+string(data)+sessionEnd), info.Size()-int64(len(sessionEnd)))
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	r.interactions++
+	return nil
+}
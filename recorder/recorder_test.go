@@ -0,0 +1,81 @@
+package recorder
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/damianoneill/hb/hbtest"
+	"github.com/damianoneill/hb/types"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/resty.v1"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	ts := hbtest.NewServer().Start()
+	defer ts.Close()
+	dir, err := ioutil.TempDir("", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "session.json")
+
+	transport := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}} // nolint : gosec
+	client := resty.New().SetTransport(NewRecorder(filename, transport)).SetBasicAuth("admin", "secret")
+
+	password := "device-secret"
+	devices := types.Devices{Device: []types.Device{{DeviceID: "mx1", Host: "10.0.0.1", Authentication: &types.Authentication{}}}}
+	devices.Device[0].Authentication.Password.Password = &password
+	resp, err := client.R().SetBody(devices).Post(ts.URL + "/api/v1/devices/")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, resp.StatusCode())
+	_, err = client.R().Get(ts.URL + "/api/v1/devices/?working=true")
+	assert.Nil(t, err)
+
+	data, err := ioutil.ReadFile(filename)
+	assert.Nil(t, err, "Expected the session to be saved")
+	assert.NotContains(t, string(data), "device-secret", "Expected the Device password to be redacted")
+	assert.NotContains(t, string(data), "Basic ", "Expected the basic auth header to be redacted")
+
+	session, err := Load(filename)
+	assert.Nil(t, err, "Expected each interaction to be appended to a valid session")
+	assert.Len(t, session.Interactions, 2)
+	expected := filepath.Join(dir, "expected.json")
+	assert.Nil(t, session.Save(expected))
+	saved, _ := ioutil.ReadFile(expected)
+	assert.Equal(t, string(saved), string(data), "Expected the appended session to be written as Save does")
+
+	replayer, err := NewReplayer(filename)
+	assert.Nil(t, err)
+	ts.Close()
+	resp, err = resty.New().SetTransport(replayer).R().Get("https://elsewhere:8080/api/v1/devices/?working=true")
+	assert.Nil(t, err, "Expected the replay to not use the network")
+	assert.Equal(t, 200, resp.StatusCode())
+	assert.True(t, strings.Contains(resp.String(), `"mx1"`))
+
+	_, err = resty.New().SetTransport(replayer).R().Get("https://elsewhere:8080/api/v1/playbooks/")
+	assert.NotNil(t, err, "Expected an unrecorded request to fail")
+}
+
+func TestRecordSaveFailure(t *testing.T) {
+	ts := hbtest.NewServer().Start()
+	defer ts.Close()
+	transport := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}} // nolint : gosec
+	recorder := NewRecorder(filepath.Join("no", "such", "directory", "session.json"), transport)
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/api/v1/devices/", nil)
+	assert.Nil(t, err)
+	resp, err := recorder.RoundTrip(req)
+	assert.NotNil(t, err, "Expected the session that can not be saved to fail the request")
+	assert.Nil(t, resp, "Expected no response with the error")
+}
+
+func TestRedactBody(t *testing.T) {
+	assert.Equal(t, `{"snmp":{"v2":{"community":"****"}}}`, RedactBody([]byte(`{"snmp":{"v2":{"community":"public"}}}`)))
+	assert.Equal(t, "not json password", RedactBody([]byte("not json password")))
+}
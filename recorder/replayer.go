@@ -0,0 +1,81 @@
+package recorder
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// Replayer - a RoundTripper that answers requests from a recorded session without using the network.
+// Requests are matched on method, path and query, regardless of host, and repeated requests get the
+// recorded responses in order, the last response is reused once they run out.
+type Replayer struct {
+	mu        sync.Mutex
+	responses map[string][]Response
+	served    map[string]int
+}
+
+// NewReplayer - replays the session file
+func NewReplayer(filename string) (*Replayer, error) {
+	session, err := Load(filename)
+	if err != nil {
+		return nil, err
+	}
+	return NewSessionReplayer(session), nil
+}
+
+// NewSessionReplayer - replays a session
+func NewSessionReplayer(session *Session) *Replayer {
+	r := &Replayer{responses: map[string][]Response{}, served: map[string]int{}}
+	for _, i := range session.Interactions {
+		u, err := url.Parse(i.Request.URL)
+		if err != nil {
+			continue
+		}
+		k := key(i.Request.Method, u)
+		r.responses[k] = append(r.responses[k], i.Response)
+	}
+	return r
+}
+
+func key(method string, u *url.URL) string {
+	return method + " " + u.EscapedPath() + "?" + u.RawQuery
+}
+
+// RoundTrip - implements http.RoundTripper
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := key(req.Method, req.URL)
+	responses := r.responses[k]
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL.Path)
+	}
+	i := r.served[k]
+	if i >= len(responses) {
+		i = len(responses) - 1
+	}
+	r.served[k]++
+	recorded := responses[i]
+	header := recorded.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader([]byte(recorded.Body))),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,78 @@
+// Package recorder captures the HTTP traffic between hb and Healthbot to a session file, and replays
+// a session file in place of a Healthbot so problems can be reproduced without a network.
+package recorder
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/damianoneill/hb/redact"
+)
+
+// Redacted - replaces credentials in a recorded session
+const Redacted = redact.Mask
+
+// SensitiveHeaders - headers that are never written to a session
+var SensitiveHeaders = redact.Headers
+
+// SensitiveFields - JSON fields whose values are never written to a session
+var SensitiveFields = redact.Fields
+
+// Request - the recorded part of an HTTP request
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response - the recorded part of an HTTP response
+type Response struct {
+	StatusCode int         `json:"status-code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Interaction - a request and the response to it
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Session - the interactions in the order they happened
+type Session struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Load - reads a session file
+func Load(filename string) (*Session, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Save - writes the session file
+func (s *Session) Save(filename string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0600)
+}
+
+// RedactHeader - a copy of the header with the SensitiveHeaders replaced
+func RedactHeader(header http.Header) http.Header {
+	return redact.Header(header)
+}
+
+// RedactBody - replaces the values of SensitiveFields in a JSON body, other bodies are returned as is
+func RedactBody(body []byte) string {
+	return redact.Body(body)
+}
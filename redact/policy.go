@@ -0,0 +1,153 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/damianoneill/hb/types"
+)
+
+// Mode - how a secret is redacted from an exported configuration
+type Mode string
+
+// Modes of redaction
+const (
+	// MaskMode - the value is replaced with the Mask
+	MaskMode Mode = "mask"
+	// DropMode - the field is removed
+	DropMode Mode = "drop"
+	// ReferenceMode - the value is replaced with a reference to an environment variable, resolved when provisioning
+	ReferenceMode Mode = "reference"
+)
+
+// ReferencePrefix - the prefix of the environment variables that references name
+const ReferencePrefix = "HB_SECRET_"
+
+// Policy - the Mode for each secret field, fields without a Mode use the Default
+type Policy struct {
+	Default Mode
+	Fields  map[string]Mode
+}
+
+// Redaction - a secret that was redacted, Reference is the environment variable for ReferenceMode
+type Redaction struct {
+	Path      string
+	Field     string
+	Mode      Mode
+	Reference string
+}
+
+// DefaultPolicy - masks every secret
+var DefaultPolicy = Policy{Default: MaskMode}
+
+func parseMode(name string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(name)); mode {
+	case MaskMode, DropMode, ReferenceMode:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown redaction mode %s, expected mask, drop or reference", name)
+}
+
+// ParsePolicy - a Policy from the default mode and field=mode overrides e.g. username=drop
+func ParsePolicy(defaultMode string, fields []string) (Policy, error) {
+	mode, err := parseMode(defaultMode)
+	if err != nil {
+		return Policy{}, err
+	}
+	policy := Policy{Default: mode, Fields: map[string]Mode{}}
+	for _, field := range fields {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return Policy{}, fmt.Errorf("expected field=mode, not %s", field)
+		}
+		if policy.Fields[parts[0]], err = parseMode(parts[1]); err != nil {
+			return Policy{}, err
+		}
+	}
+	return policy, nil
+}
+
+// ModeFor - the Mode used for the field
+func (p Policy) ModeFor(field string) Mode {
+	if mode, ok := p.Fields[field]; ok {
+		return mode
+	}
+	if p.Default == "" {
+		return MaskMode
+	}
+	return p.Default
+}
+
+// Apply - redacts the secrets of the configuration in place, returning what was redacted; when the paths
+// of two secrets give the same reference, e.g. mx960-1 and mx960.1, the later one is suffixed _2, _3, ...
+func (p Policy) Apply(configuration types.SecretHolder) []Redaction {
+	var redactions []Redaction
+	references := map[string]bool{}
+	for _, secret := range configuration.Secrets() {
+		redaction := Redaction{Path: secret.Path, Field: secret.Field, Mode: p.ModeFor(secret.Field)}
+		switch redaction.Mode {
+		case DropMode:
+			secret.Set(nil)
+		case ReferenceMode:
+			redaction.Reference = ReferenceName(secret.Path)
+			for n := 2; references[redaction.Reference]; n++ {
+				redaction.Reference = fmt.Sprintf("%s_%d", ReferenceName(secret.Path), n)
+			}
+			references[redaction.Reference] = true
+			reference := "${" + redaction.Reference + "}"
+			secret.Set(&reference)
+		default:
+			mask := Mask
+			secret.Set(&mask)
+		}
+		redactions = append(redactions, redaction)
+	}
+	return append(redactions, Unmodelled(configuration)...)
+}
+
+// Unmodelled - masks the Fields in the fields of the configuration that the types do not model, in place,
+// returning what was masked. They are always masked as they can not be written back as a reference
+func Unmodelled(configuration interface{}) []Redaction {
+	var redactions []Redaction
+	for _, extra := range types.Extras(configuration) {
+		mask(map[string]interface{}(extra), func(field string) {
+			redactions = append(redactions, Redaction{Path: "unmodelled/" + field, Field: field, Mode: MaskMode})
+		})
+	}
+	return redactions
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// ReferenceName - the environment variable for a secret path e.g. HB_SECRET_DEVICE_MX960_1_PASSWORD
+func ReferenceName(path string) string {
+	return ReferencePrefix + strings.Trim(nonAlphanumeric.ReplaceAllString(strings.ToUpper(path), "_"), "_")
+}
+
+// a reference, which may already be quoted in the YAML or JSON document
+var reference = regexp.MustCompile(`"\$\{(` + ReferencePrefix + `[A-Z0-9_]+)\}"|'\$\{(` + ReferencePrefix + `[A-Z0-9_]+)\}'|\$\{(` + ReferencePrefix + `[A-Z0-9_]+)\}`)
+
+// Resolve - replaces the references in a YAML or JSON document with the values from lookup,
+// e.g. os.LookupEnv, the values are quoted so they can hold any character
+func Resolve(document []byte, lookup func(string) (string, bool)) ([]byte, error) {
+	var missing []string
+	resolved := reference.ReplaceAllFunc(document, func(match []byte) []byte {
+		groups := reference.FindSubmatch(match)
+		name := string(groups[1]) + string(groups[2]) + string(groups[3])
+		value, ok := lookup(name)
+		if !ok {
+			missing = append(missing, name)
+			return match
+		}
+		quoted, _ := json.Marshal(value)
+		return quoted
+	})
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("secret references are not set in the environment: %s", strings.Join(missing, ", "))
+	}
+	return resolved, nil
+}
@@ -0,0 +1,96 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/damianoneill/hb/types"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestPolicy(t *testing.T) {
+	policy, err := ParsePolicy("mask", []string{"username=drop", "community=reference"})
+	assert.Nil(t, err)
+	_, err = ParsePolicy("hide", nil)
+	assert.NotNil(t, err)
+	_, err = ParsePolicy("mask", []string{"username"})
+	assert.NotNil(t, err)
+
+	username, password := "root", "secret"
+	devices := types.Devices{Device: []types.Device{{DeviceID: "mx960-1", Snmp: &types.Snmp{V2: &types.V2{Community: "public"}}}}}
+	devices.Device[0].Authentication = &types.Authentication{}
+	devices.Device[0].Authentication.Password.Username = &username
+	devices.Device[0].Authentication.Password.Password = &password
+
+	redactions := policy.Apply(&devices)
+	assert.Equal(t, []Redaction{
+		{Path: "device/mx960-1/username", Field: "username", Mode: DropMode},
+		{Path: "device/mx960-1/password", Field: "password", Mode: MaskMode},
+		{Path: "device/mx960-1/community", Field: "community", Mode: ReferenceMode, Reference: "HB_SECRET_DEVICE_MX960_1_COMMUNITY"},
+	}, redactions)
+	assert.Nil(t, devices.Device[0].Authentication.Password.Username)
+	assert.Equal(t, Mask, *devices.Device[0].Authentication.Password.Password)
+	assert.Equal(t, "${HB_SECRET_DEVICE_MX960_1_COMMUNITY}", devices.Device[0].Snmp.V2.Community)
+	assert.Equal(t, "root", username, "Expected the secret to be replaced, not overwritten")
+
+	drop := Policy{Default: DropMode}
+	drop.Apply(&devices)
+	assert.Nil(t, devices.Device[0].Authentication, "Expected authentication without credentials to be removed")
+	assert.Nil(t, devices.Device[0].Snmp, "Expected snmp without a community or port to be removed")
+}
+
+func TestPolicyReferenceCollisions(t *testing.T) {
+	devices := types.Devices{Device: []types.Device{
+		{DeviceID: "mx960-1", Snmp: &types.Snmp{V2: &types.V2{Community: "one"}}},
+		{DeviceID: "mx960.1", Snmp: &types.Snmp{V2: &types.V2{Community: "two"}}},
+		{DeviceID: "mx960_1", Snmp: &types.Snmp{V2: &types.V2{Community: "three"}}},
+	}}
+	redactions := Policy{Default: ReferenceMode}.Apply(&devices)
+	var references []string
+	for _, r := range redactions {
+		references = append(references, r.Reference)
+	}
+	assert.Equal(t, []string{"HB_SECRET_DEVICE_MX960_1_COMMUNITY", "HB_SECRET_DEVICE_MX960_1_COMMUNITY_2", "HB_SECRET_DEVICE_MX960_1_COMMUNITY_3"}, references)
+	assert.Equal(t, "${HB_SECRET_DEVICE_MX960_1_COMMUNITY_2}", devices.Device[1].Snmp.V2.Community)
+}
+
+func TestUnmodelled(t *testing.T) {
+	var deviceGroups types.DeviceGroups
+	assert.Nil(t, deviceGroups.Parse([]byte(`
+device-group:
+  - device-group-name: core
+    kerberos:
+      password: secret
+      realm: EXAMPLE.COM
+    engine:
+      - token: abc
+`)))
+	redactions := DefaultPolicy.Apply(&deviceGroups)
+	assert.ElementsMatch(t, []Redaction{
+		{Path: "unmodelled/password", Field: "password", Mode: MaskMode},
+		{Path: "unmodelled/token", Field: "token", Mode: MaskMode},
+	}, redactions)
+	data, err := yaml.Marshal(deviceGroups)
+	assert.Nil(t, err)
+	dump := string(data)
+	assert.NotContains(t, dump, "secret")
+	assert.NotContains(t, dump, "abc")
+	assert.Contains(t, dump, "EXAMPLE.COM", "Expected the fields that are not secrets to be kept")
+}
+
+func TestResolve(t *testing.T) {
+	env := map[string]string{"HB_SECRET_DEVICE_MX1_PASSWORD": `pa"ss: #1`}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	resolved, err := Resolve([]byte("password: ${HB_SECRET_DEVICE_MX1_PASSWORD}\nrule: $var\n"), lookup)
+	assert.Nil(t, err)
+	assert.Equal(t, "password: \"pa\\\"ss: #1\"\nrule: $var\n", string(resolved))
+	resolved, err = Resolve([]byte(`{"password": "${HB_SECRET_DEVICE_MX1_PASSWORD}"}`), lookup)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"password": "pa\"ss: #1"}`, string(resolved))
+
+	_, err = Resolve([]byte("community: ${HB_SECRET_DEVICE_MX1_COMMUNITY}"), lookup)
+	assert.EqualError(t, err, "secret references are not set in the environment: HB_SECRET_DEVICE_MX1_COMMUNITY")
+}
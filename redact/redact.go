@@ -0,0 +1,117 @@
+// Package redact removes credentials from the HTTP traffic between hb and Healthbot before it is
+// written anywhere, e.g. a recorded session or the debug log.
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Mask - replaces a credential
+const Mask = "****"
+
+// Headers - headers whose values are always masked
+var Headers = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// Fields - JSON fields and query parameters whose values are always masked
+var Fields = []string{"password", "community", "authentication-password", "privacy-password", "token"}
+
+// Header - a copy of the header with the Headers masked
+func Header(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	redacted := http.Header{}
+	for k, v := range header {
+		redacted[k] = v
+		for _, sensitive := range Headers {
+			if strings.EqualFold(k, sensitive) {
+				redacted[k] = []string{Mask}
+			}
+		}
+	}
+	return redacted
+}
+
+// Body - masks the values of Fields in a JSON body, other bodies are returned as is
+func Body(body []byte) string {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return string(body)
+	}
+	if !Value(value) {
+		return string(body)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return string(body)
+	}
+	return string(data)
+}
+
+// Value - masks the Fields in decoded JSON or YAML in place, returning true if anything was masked
+func Value(value interface{}) bool {
+	redacted := false
+	mask(value, func(string) { redacted = true })
+	return redacted
+}
+
+// mask - masks the Fields in the value in place, calling masked with the name of each one
+func mask(value interface{}, masked func(field string)) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if _, ok := child.(string); ok && IsSensitive(k) {
+				v[k] = Mask
+				masked(k)
+				continue
+			}
+			mask(child, masked)
+		}
+	case map[interface{}]interface{}:
+		for k, child := range v {
+			if _, ok := child.(string); ok && IsSensitive(fmt.Sprintf("%v", k)) {
+				v[k] = Mask
+				masked(fmt.Sprintf("%v", k))
+				continue
+			}
+			mask(child, masked)
+		}
+	case []interface{}:
+		for _, child := range v {
+			mask(child, masked)
+		}
+	}
+}
+
+// URL - the URL without any user password and with the Fields in the query masked
+func URL(u *url.URL) string {
+	redacted := *u
+	if _, ok := u.User.Password(); ok {
+		redacted.User = url.User(u.User.Username())
+	}
+	if u.RawQuery != "" {
+		params := strings.Split(u.RawQuery, "&")
+		for i, param := range params {
+			name := strings.SplitN(param, "=", 2)[0]
+			if unescaped, err := url.QueryUnescape(name); err == nil && IsSensitive(unescaped) {
+				params[i] = name + "=" + Mask
+			}
+		}
+		redacted.RawQuery = strings.Join(params, "&")
+	}
+	return redacted.String()
+}
+
+// IsSensitive - the name is one of the Fields
+func IsSensitive(name string) bool {
+	for _, sensitive := range Fields {
+		if strings.EqualFold(name, sensitive) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package redact
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBody(t *testing.T) {
+	assert.Equal(t, `{"device":[{"authentication":{"password":{"password":"****"}},"device-id":"mx1"}]}`,
+		Body([]byte(`{"device":[{"device-id":"mx1","authentication":{"password":{"password":"secret"}}}]}`)))
+	assert.Equal(t, `{"device-id":"mx1"}`, Body([]byte(`{"device-id":"mx1"}`)), "Expected a body without credentials to be unchanged")
+	assert.Equal(t, "not json password", Body([]byte("not json password")))
+}
+
+func TestHeaderAndURL(t *testing.T) {
+	header := http.Header{"Authorization": {"Basic YWRtaW46c2VjcmV0"}, "Content-Type": {"application/json"}}
+	redacted := Header(header)
+	assert.Equal(t, Mask, redacted.Get("Authorization"))
+	assert.Equal(t, "application/json", redacted.Get("Content-Type"))
+	assert.Equal(t, "Basic YWRtaW46c2VjcmV0", header.Get("Authorization"), "Expected the header to be copied")
+
+	u, _ := url.Parse("https://admin:secret@hb:8080/api/v1/alerts/?device-id=mx1&token=abc")
+	assert.Equal(t, "https://admin@hb:8080/api/v1/alerts/?device-id=mx1&token=****", URL(u))
+}
@@ -0,0 +1,56 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+)
+
+// ErrInterrupted - ctrl-c was pressed while reading a password
+var ErrInterrupted = errors.New("interrupted")
+
+// ReadPassword - reads a line from the terminal without echoing it, in must be a terminal
+func ReadPassword(in *os.File) (string, error) {
+	restore, err := makeRaw(int(in.Fd()))
+	if err != nil {
+		return "", fmt.Errorf("a terminal is required: %v", err)
+	}
+	defer restore() // nolint : errcheck
+	return readPassword(in)
+}
+
+// readPassword - the line up to enter from a raw mode terminal, handling backspace, ctrl-c and ctrl-d
+func readPassword(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n == 1 {
+			switch b[0] {
+			case '\r', '\n':
+				return string(line), nil
+			case 127, '\b':
+				if len(line) > 0 {
+					_, size := utf8.DecodeLastRune(line)
+					line = line[:len(line)-size]
+				}
+			case 3:
+				return "", ErrInterrupted
+			case 4:
+				if len(line) == 0 {
+					return "", io.EOF
+				}
+			default:
+				line = append(line, b[0])
+			}
+		}
+		if err == io.EOF && len(line) > 0 {
+			return string(line), nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package terminal
+
+import (
+	"errors"
+	"runtime"
+)
+
+func makeRaw(fd int) (func() error, error) {
+	return nil, errors.New("raw mode is not supported on " + runtime.GOOS)
+}
+
+func size(fd int) (width, height int, err error) {
+	return 0, 0, errors.New("terminal size is not supported on " + runtime.GOOS)
+}
@@ -0,0 +1,203 @@
+// Package terminal provides what a full screen terminal interface needs: raw mode, the screen size,
+// key input and drawing, using ANSI escape sequences.
+package terminal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Key - a key press
+type Key int
+
+// The keys that are recognised, any other printable key is a KeyRune
+const (
+	KeyRune Key = iota
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyPageUp
+	KeyPageDown
+	KeyHome
+	KeyEnd
+	KeyEnter
+	KeyEscape
+	KeyBackspace
+	KeyTab
+	KeyCtrlC
+)
+
+// Event - a key press, Rune is set for KeyRune
+type Event struct {
+	Key  Key
+	Rune rune
+}
+
+// ANSI escape sequences
+const (
+	enterAlternateScreen = "\033[?1049h"
+	exitAlternateScreen  = "\033[?1049l"
+	hideCursor           = "\033[?25l"
+	showCursor           = "\033[?25h"
+	home                 = "\033[H"
+	clearLine            = "\033[K"
+	clearToEnd           = "\033[J"
+
+	// Reverse - highlights text, e.g. the selected row
+	Reverse = "\033[7m"
+	// Bold - emphasises text, e.g. headings
+	Bold = "\033[1m"
+	// Reset - ends Reverse, Bold or a colour
+	Reset = "\033[0m"
+)
+
+// Terminal - the controlling terminal in raw mode, showing the alternate screen
+type Terminal struct {
+	in      *os.File
+	out     *bufio.Writer
+	restore func() error
+}
+
+// Open - switches stdin to raw mode and stdout to the alternate screen, Close must be called to undo this
+func Open() (*Terminal, error) {
+	restore, err := makeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return nil, fmt.Errorf("a terminal is required: %v", err)
+	}
+	t := &Terminal{in: os.Stdin, out: bufio.NewWriter(os.Stdout), restore: restore}
+	t.out.WriteString(enterAlternateScreen + hideCursor)
+	return t, t.out.Flush()
+}
+
+// Close - restores the screen and the terminal mode
+func (t *Terminal) Close() error {
+	t.out.WriteString(showCursor + exitAlternateScreen)
+	_ = t.out.Flush()
+	return t.restore()
+}
+
+// Size - the width and height of the screen, 80x24 if it cannot be found
+func (t *Terminal) Size() (width, height int) {
+	width, height, err := size(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// Events - the key presses read from the terminal, the channel is closed when input ends
+func (t *Terminal) Events() <-chan Event {
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		buf := make([]byte, 64)
+		for {
+			n, err := t.in.Read(buf)
+			for _, e := range ParseKeys(buf[:n]) {
+				events <- e
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// Draw - replaces the screen with the lines, which may contain escape sequences
+func (t *Terminal) Draw(lines []string) error {
+	_, height := t.Size()
+	return Render(t.out, lines, height)
+}
+
+// Render - writes the lines from the top of the screen, at most height of them, clearing what was there before
+func Render(w io.Writer, lines []string, height int) error {
+	var b strings.Builder
+	b.WriteString(home)
+	for i, line := range lines {
+		if i >= height {
+			break
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(line + Reset + clearLine)
+	}
+	b.WriteString(clearToEnd)
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return err
+	}
+	if f, ok := w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Truncate - cuts s to fit within width columns, s must not contain escape sequences
+func Truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	return runewidth.Truncate(s, width, "")
+}
+
+// Pad - truncates or pads s with spaces to exactly width columns
+func Pad(s string, width int) string {
+	s = Truncate(s, width)
+	return s + strings.Repeat(" ", width-runewidth.StringWidth(s))
+}
+
+var escapes = map[string]Key{
+	"\033[A": KeyUp, "\033OA": KeyUp,
+	"\033[B": KeyDown, "\033OB": KeyDown,
+	"\033[C": KeyRight, "\033OC": KeyRight,
+	"\033[D": KeyLeft, "\033OD": KeyLeft,
+	"\033[5~": KeyPageUp, "\033[6~": KeyPageDown,
+	"\033[H": KeyHome, "\033[1~": KeyHome, "\033OH": KeyHome,
+	"\033[F": KeyEnd, "\033[4~": KeyEnd, "\033OF": KeyEnd,
+}
+
+// ParseKeys - the key presses in a read from a raw mode terminal
+func ParseKeys(b []byte) []Event {
+	var events []Event
+	s := string(b)
+	for len(s) > 0 {
+		if s[0] == '\033' {
+			matched := false
+			for seq, key := range escapes {
+				if strings.HasPrefix(s, seq) {
+					events = append(events, Event{Key: key})
+					s = s[len(seq):]
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				events = append(events, Event{Key: KeyEscape})
+				s = s[1:]
+			}
+			continue
+		}
+		r := []rune(s)[0]
+		s = s[len(string(r)):]
+		switch r {
+		case '\r', '\n':
+			events = append(events, Event{Key: KeyEnter})
+		case 127, '\b':
+			events = append(events, Event{Key: KeyBackspace})
+		case '\t':
+			events = append(events, Event{Key: KeyTab})
+		case 3:
+			events = append(events, Event{Key: KeyCtrlC})
+		default:
+			events = append(events, Event{Key: KeyRune, Rune: r})
+		}
+	}
+	return events
+}
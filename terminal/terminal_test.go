@@ -0,0 +1,38 @@
+package terminal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeys(t *testing.T) {
+	assert.Equal(t, []Event{{Key: KeyUp}, {Key: KeyDown}, {Key: KeyEnter}, {Key: KeyRune, Rune: 'q'}, {Key: KeyEscape}, {Key: KeyCtrlC}},
+		ParseKeys([]byte("\033[A\033OB\rq\033\x03")))
+	assert.Equal(t, []Event{{Key: KeyPageDown}, {Key: KeyRune, Rune: 'é'}, {Key: KeyBackspace}}, ParseKeys([]byte("\033[6~é\x7f")))
+	assert.Empty(t, ParseKeys(nil))
+}
+
+func TestPad(t *testing.T) {
+	assert.Equal(t, "mx96", Pad("mx960-1", 4))
+	assert.Equal(t, "mx   ", Pad("mx", 5))
+	assert.Equal(t, "", Truncate("mx", 0))
+}
+
+func TestRender(t *testing.T) {
+	var out bytes.Buffer
+	assert.Nil(t, Render(&out, []string{"one", "two", "three"}, 2))
+	assert.Equal(t, home+"one"+Reset+clearLine+"\ntwo"+Reset+clearLine+clearToEnd, out.String())
+}
+
+func TestReadPassword(t *testing.T) {
+	password, err := readPassword(bytes.NewBufferString("abé\x7fc\rignored"))
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", password, "Expected backspace to remove whole characters")
+	password, err = readPassword(bytes.NewBufferString("no newline"))
+	assert.Nil(t, err)
+	assert.Equal(t, "no newline", password)
+	_, err = readPassword(bytes.NewBufferString("abc\x03"))
+	assert.Equal(t, ErrInterrupted, err)
+}
@@ -0,0 +1,68 @@
+package types
+
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Destinations - collection of Report Destinations
+type Destinations struct {
+	Destination []Destination `json:"destination" yaml:"destination"`
+	Extra       Extra         `json:"-" yaml:",inline"`
+}
+
+// Disk - keep Reports on the Healthbot server
+type Disk struct {
+	MaxReports *int  `json:"max-reports,omitempty" yaml:"max-reports,omitempty"`
+	Extra      Extra `json:"-" yaml:",inline"`
+}
+
+// Email - send Reports to an email address
+type Email struct {
+	ID    string `json:"id"`
+	Extra Extra  `json:"-" yaml:",inline"`
+}
+
+// Destination - where a generated Report is delivered
+type Destination struct {
+	Name  string `json:"name"`
+	Disk  *Disk  `json:"disk,omitempty" yaml:"disk,omitempty"`
+	Email *Email `json:"email,omitempty" yaml:"email,omitempty"`
+	Extra Extra  `json:"-" yaml:",inline"`
+}
+
+// Parse - tries to parse yaml first, then json into the Destinations struct
+func (c *Destinations) Parse(data []byte) error {
+	if err := yaml.Unmarshal(data, c); err != nil {
+		if err := json.Unmarshal(data, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON - keeps the fields that the Destinations types do not model
+func (c *Destinations) UnmarshalJSON(data []byte) error {
+	type destinations Destinations
+	return unmarshalJSON(data, (*destinations)(c))
+}
+
+// MarshalJSON - writes back the fields that the Destinations types do not model
+func (c Destinations) MarshalJSON() ([]byte, error) {
+	type destinations Destinations
+	return marshalJSON(destinations(c))
+}
+
+// Dump - outputs Destinations struct in either 'yaml' or 'json' format
+func (c *Destinations) Dump(format string) string {
+	return DumpYAMLOrJSON(format, c)
+}
+
+// Names - the names of the Destinations
+func (c *Destinations) Names() (names []string) {
+	for _, d := range c.Destination {
+		names = append(names, d.Name)
+	}
+	return
+}
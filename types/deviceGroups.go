@@ -2,6 +2,7 @@ package types
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"gopkg.in/yaml.v2"
 )
@@ -9,6 +10,7 @@ import (
 // DeviceGroups - collection of Device Groups
 type DeviceGroups struct {
 	DeviceGroup []DeviceGroup `json:"device-group" yaml:"device-group"`
+	Extra       Extra         `json:"-" yaml:",inline"`
 }
 
 // DGAuthentication - Option to Override the individual Device Username/Passwords
@@ -16,12 +18,35 @@ type DGAuthentication struct {
 	Password struct {
 		Password *string `json:"password"`
 		Username *string `json:"username"`
+		Extra    Extra   `json:"-" yaml:",inline"`
 	} `json:"password,omitempty" yaml:"password,omitempty"`
+	Extra Extra `json:"-" yaml:",inline"`
 }
 
 // NativeGpb - Override the default JTI Port(s)
 type NativeGpb struct {
 	Ports []int `json:"ports"`
+	Extra Extra `json:"-" yaml:",inline"`
+}
+
+// IngestPorts - Override the default ports a Device Group receives syslog or sFlow on
+type IngestPorts struct {
+	Ports []int `json:"ports"`
+	Extra Extra `json:"-" yaml:",inline"`
+}
+
+// Notification - the Notifications sent for the alarms of each severity
+type Notification struct {
+	Major  *[]string `json:"major,omitempty" yaml:"major,omitempty"`
+	Minor  *[]string `json:"minor,omitempty" yaml:"minor,omitempty"`
+	Normal *[]string `json:"normal,omitempty" yaml:"normal,omitempty"`
+	Extra  Extra     `json:"-" yaml:",inline"`
+}
+
+// Logging - the log level of the Healthbot services for the Device Group
+type Logging struct {
+	LogLevel *string `json:"log-level,omitempty" yaml:"log-level,omitempty"`
+	Extra    Extra   `json:"-" yaml:",inline"`
 }
 
 // DeviceGroup - info needed to Register a DeviceGroup in Healthbot
@@ -32,8 +57,20 @@ type DeviceGroup struct {
 	Playbooks       *[]string         `json:"playbooks,omitempty" yaml:"playbooks,omitempty"`
 	Authentication  *DGAuthentication `json:"authentication,omitempty" yaml:"authentication,omitempty"`
 	NativeGpb       *NativeGpb        `json:"native-gpb,omitempty" yaml:"native-gpb,omitempty"`
+	Syslog          *IngestPorts      `json:"syslog,omitempty" yaml:"syslog,omitempty"`
+	Sflow           *IngestPorts      `json:"sflow,omitempty" yaml:"sflow,omitempty"`
+	Notification    *Notification     `json:"notification,omitempty" yaml:"notification,omitempty"`
+	Logging         *Logging          `json:"logging,omitempty" yaml:"logging,omitempty"`
+	RetentionPolicy *string           `json:"retention-policy,omitempty" yaml:"retention-policy,omitempty"`
+	Reports         *[]string         `json:"reports,omitempty" yaml:"reports,omitempty"`
+	IngestFrequency *[]string         `json:"ingest-frequency,omitempty" yaml:"ingest-frequency,omitempty"`
+	Variable        []Variable        `json:"variable,omitempty" yaml:"variable,omitempty"`
+	Extra           Extra             `json:"-" yaml:",inline"`
 }
 
+// logLevels - the log levels Healthbot supports
+var logLevels = map[string]bool{"debug": true, "info": true, "warn": true, "error": true, "critical": true}
+
 // Parse - tries to parse yaml first, then json into the Devices struct
 func (c *DeviceGroups) Parse(data []byte) error {
 	if err := yaml.Unmarshal(data, c); err != nil {
@@ -44,7 +81,86 @@ func (c *DeviceGroups) Parse(data []byte) error {
 	return nil
 }
 
+// UnmarshalJSON - keeps the fields that the DeviceGroups types do not model
+func (c *DeviceGroups) UnmarshalJSON(data []byte) error {
+	type deviceGroups DeviceGroups
+	return unmarshalJSON(data, (*deviceGroups)(c))
+}
+
+// MarshalJSON - writes back the fields that the DeviceGroups types do not model
+func (c DeviceGroups) MarshalJSON() ([]byte, error) {
+	type deviceGroups DeviceGroups
+	return marshalJSON(deviceGroups(c))
+}
+
+// UnmarshalJSON - keeps the fields that the DeviceGroup types do not model, when a single DeviceGroup is read
+func (c *DeviceGroup) UnmarshalJSON(data []byte) error {
+	type deviceGroup DeviceGroup
+	return unmarshalJSON(data, (*deviceGroup)(c))
+}
+
+// MarshalJSON - writes back the fields that the DeviceGroup types do not model
+func (c DeviceGroup) MarshalJSON() ([]byte, error) {
+	type deviceGroup DeviceGroup
+	return marshalJSON(deviceGroup(c))
+}
+
 // Dump - outputs DeviceGroups struct in either 'yaml' or 'json' format
 func (c *DeviceGroups) Dump(format string) string {
 	return DumpYAMLOrJSON(format, c)
 }
+
+// Validate - checks the Device Groups are named uniquely and their ports, notifications, log level and
+// variables are valid
+func (c *DeviceGroups) Validate() error {
+	seen := map[string]bool{}
+	for _, dg := range c.DeviceGroup {
+		if dg.DeviceGroupName == "" {
+			return fmt.Errorf("device group is missing a device-group-name")
+		}
+		if seen[dg.DeviceGroupName] {
+			return fmt.Errorf("device group %s is defined more than once", dg.DeviceGroupName)
+		}
+		seen[dg.DeviceGroupName] = true
+		if err := dg.validate(); err != nil {
+			return fmt.Errorf("device group %s %v", dg.DeviceGroupName, err)
+		}
+	}
+	return nil
+}
+
+func (dg *DeviceGroup) validate() error {
+	ports := map[string][]int{}
+	if dg.NativeGpb != nil {
+		ports["native-gpb"] = dg.NativeGpb.Ports
+	}
+	if dg.Syslog != nil {
+		ports["syslog"] = dg.Syslog.Ports
+	}
+	if dg.Sflow != nil {
+		ports["sflow"] = dg.Sflow.Ports
+	}
+	for name, list := range ports {
+		for _, port := range list {
+			if port < 1 || port > 65535 {
+				return fmt.Errorf("has an invalid %s port %v", name, port)
+			}
+		}
+	}
+	if dg.Notification != nil {
+		for severity, names := range map[string]*[]string{"major": dg.Notification.Major, "minor": dg.Notification.Minor, "normal": dg.Notification.Normal} {
+			if names == nil {
+				continue
+			}
+			for _, name := range *names {
+				if name == "" {
+					return fmt.Errorf("has an unnamed %s notification", severity)
+				}
+			}
+		}
+	}
+	if dg.Logging != nil && dg.Logging.LogLevel != nil && !logLevels[*dg.Logging.LogLevel] {
+		return fmt.Errorf("has an invalid log-level %s", *dg.Logging.LogLevel)
+	}
+	return validateVariables(dg.Variable)
+}
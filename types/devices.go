@@ -2,6 +2,10 @@ package types
 
 import (
 	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -9,6 +13,7 @@ import (
 // Devices - collection of Device
 type Devices struct {
 	Device []Device `json:"device"`
+	Extra  Extra    `json:"-" yaml:",inline"`
 }
 
 // Authentication - Collection type for Auth options
@@ -16,44 +21,114 @@ type Authentication struct {
 	Password struct {
 		Password *string `json:"password"`
 		Username *string `json:"username"`
+		Extra    Extra   `json:"-" yaml:",inline"`
 	} `json:"password,omitempty" yaml:"password,omitempty"`
+	SSH   *SSH  `json:"ssh,omitempty" yaml:"ssh,omitempty"`
+	SSL   *SSL  `json:"ssl,omitempty" yaml:"ssl,omitempty"`
+	Extra Extra `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON - leaves out the password when the Device only authenticates with ssh keys or certificates
+func (a Authentication) MarshalJSON() ([]byte, error) {
+	type authentication Authentication
+	data, err := json.Marshal(authentication(a))
+	if err != nil || a.Password.Username != nil || a.Password.Password != nil || len(a.Password.Extra) > 0 {
+		return data, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "password")
+	return json.Marshal(fields)
+}
+
+// SSH - authenticate with an SSH key profile defined in Healthbot
+type SSH struct {
+	SSHKeyProfile string  `json:"ssh-key-profile" yaml:"ssh-key-profile"`
+	Username      *string `json:"username,omitempty" yaml:"username,omitempty"`
+	Extra         Extra   `json:"-" yaml:",inline"`
+}
+
+// SSL - the TLS settings used for gNMI / OpenConfig, the profiles are defined in Healthbot
+type SSL struct {
+	ServerCommonName *string `json:"server-common-name,omitempty" yaml:"server-common-name,omitempty"`
+	CaProfile        *string `json:"ca-profile,omitempty" yaml:"ca-profile,omitempty"`
+	LocalCertificate *string `json:"local-certificate,omitempty" yaml:"local-certificate,omitempty"`
+	Extra            Extra   `json:"-" yaml:",inline"`
 }
 
 // IAgent - configure the NETCONF port
 type IAgent struct {
-	Port int `json:"port"`
+	Port  int   `json:"port"`
+	Extra Extra `json:"-" yaml:",inline"`
 }
 
 // OpenConfig - configure the Open Config port
 type OpenConfig struct {
-	Port int `json:"port"`
+	Port  int   `json:"port"`
+	Extra Extra `json:"-" yaml:",inline"`
 }
 
 // V2 - configure the SNMP community string
 type V2 struct {
 	Community string `json:"community"`
+	Extra     Extra  `json:"-" yaml:",inline"`
 }
 
-// Snmp - configure the SNMP port or Community String
+// Usm - the SNMP v3 user Healthbot polls the Device as
+type Usm struct {
+	Username               string  `json:"username"`
+	AuthenticationProtocol *string `json:"authentication-protocol,omitempty" yaml:"authentication-protocol,omitempty"`
+	AuthenticationPassword *string `json:"authentication-password,omitempty" yaml:"authentication-password,omitempty"`
+	PrivacyProtocol        *string `json:"privacy-protocol,omitempty" yaml:"privacy-protocol,omitempty"`
+	PrivacyPassword        *string `json:"privacy-password,omitempty" yaml:"privacy-password,omitempty"`
+	Extra                  Extra   `json:"-" yaml:",inline"`
+}
+
+// V3 - configure the SNMP v3 user
+type V3 struct {
+	Usm   Usm   `json:"usm"`
+	Extra Extra `json:"-" yaml:",inline"`
+}
+
+// Snmp - configure the SNMP port, Community String or v3 user
 type Snmp struct {
-	Port int `json:"port,omitempty" yaml:"port,omitempty"`
-	V2   *V2 `json:"v2,omitempty" yaml:"v2,omitempty"`
+	Port  int   `json:"port,omitempty" yaml:"port,omitempty"`
+	V2    *V2   `json:"v2,omitempty" yaml:"v2,omitempty"`
+	V3    *V3   `json:"v3,omitempty" yaml:"v3,omitempty"`
+	Extra Extra `json:"-" yaml:",inline"`
+}
+
+// OutboundSSH - the Device connects to Healthbot over outbound SSH rather than being polled
+type OutboundSSH struct {
+	Port  int   `json:"port,omitempty" yaml:"port,omitempty"`
+	Extra Extra `json:"-" yaml:",inline"`
+}
+
+// Flow - the addresses the Device exports flow records from
+type Flow struct {
+	SourceIP []string `json:"source-ip,omitempty" yaml:"source-ip,omitempty"`
+	Extra    Extra    `json:"-" yaml:",inline"`
 }
 
 // Juniper - option to define the Operating system
 type Juniper struct {
 	OperatingSystem string `json:"operating-system" yaml:"operating-system"`
+	Extra           Extra  `json:"-" yaml:",inline"`
 }
 
 // Cisco - option to define the Operating system
 type Cisco struct {
 	OperatingSystem string `json:"operating-system" yaml:"operating-system"`
+	Extra           Extra  `json:"-" yaml:",inline"`
 }
 
 // Vendor - Configure the Vendor information
 type Vendor struct {
 	Juniper *Juniper `json:"juniper,omitempty" yaml:"juniper,omitempty"`
 	Cisco   *Cisco   `json:"cisco,omitempty" yaml:"cisco,omitempty"`
+	Extra   Extra    `json:"-" yaml:",inline"`
 }
 
 // Device - info needed to Register a Device in Healthbot
@@ -61,13 +136,28 @@ type Device struct {
 	DeviceID       string          `json:"device-id" yaml:"device-id"`
 	Host           string          `json:"host"`
 	SystemID       string          `json:"system-id,omitempty" yaml:"system-id,omitempty"`
+	Description    *string         `json:"description,omitempty" yaml:"description,omitempty"`
+	Timezone       *string         `json:"timezone,omitempty" yaml:"timezone,omitempty"`
 	Authentication *Authentication `json:"authentication,omitempty" yaml:"authentication,omitempty"`
 	IAgent         *IAgent         `json:"iAgent,omitempty" yaml:"iAgent,omitempty"`
 	OpenConfig     *OpenConfig     `json:"open-config,omitempty" yaml:"open-config,omitempty"`
 	Snmp           *Snmp           `json:"snmp,omitempty" yaml:"snmp,omitempty"`
+	OutboundSSH    *OutboundSSH    `json:"outbound-ssh,omitempty" yaml:"outbound-ssh,omitempty"`
+	Flow           *Flow           `json:"flow,omitempty" yaml:"flow,omitempty"`
 	Vendor         *Vendor         `json:"vendor,omitempty" yaml:"vendor,omitempty"`
+	Variable       []Variable      `json:"variable,omitempty" yaml:"variable,omitempty"`
+	Extra          Extra           `json:"-" yaml:",inline"`
 }
 
+// the SNMP v3 protocols Healthbot supports
+var (
+	authenticationProtocols = map[string]bool{"none": true, "md5": true, "sha": true, "sha224": true, "sha256": true, "sha384": true, "sha512": true}
+	privacyProtocols        = map[string]bool{"none": true, "des": true, "3des": true, "aes128": true, "aes192": true, "aes256": true}
+)
+
+// timezonePattern - an offset from UTC e.g. +05:30
+var timezonePattern = regexp.MustCompile(`^[+-](0[0-9]|1[0-4]):[0-5][0-9]$`)
+
 // Parse - tries to parse yaml first, then json into the Devices struct
 func (c *Devices) Parse(data []byte) error {
 	if err := yaml.Unmarshal(data, c); err != nil {
@@ -78,7 +168,127 @@ func (c *Devices) Parse(data []byte) error {
 	return nil
 }
 
+// UnmarshalJSON - keeps the fields that the Devices types do not model
+func (c *Devices) UnmarshalJSON(data []byte) error {
+	type devices Devices
+	return unmarshalJSON(data, (*devices)(c))
+}
+
+// MarshalJSON - writes back the fields that the Devices types do not model
+func (c Devices) MarshalJSON() ([]byte, error) {
+	type devices Devices
+	return marshalJSON(devices(c))
+}
+
+// UnmarshalJSON - keeps the fields that the Device types do not model, when a single Device is read
+func (c *Device) UnmarshalJSON(data []byte) error {
+	type device Device
+	return unmarshalJSON(data, (*device)(c))
+}
+
+// MarshalJSON - writes back the fields that the Device types do not model
+func (c Device) MarshalJSON() ([]byte, error) {
+	type device Device
+	return marshalJSON(device(c))
+}
+
 // Dump - outputs Devices struct in either 'yaml' or 'json' format
 func (c *Devices) Dump(format string) string {
 	return DumpYAMLOrJSON(format, c)
 }
+
+// Validate - checks the Devices are identified uniquely and their ports, timezone, SNMP v3 user, flow
+// addresses and variables are valid
+func (c *Devices) Validate() error {
+	seen := map[string]bool{}
+	for _, d := range c.Device {
+		if d.DeviceID == "" {
+			return fmt.Errorf("device is missing a device-id")
+		}
+		if seen[d.DeviceID] {
+			return fmt.Errorf("device %s is defined more than once", d.DeviceID)
+		}
+		seen[d.DeviceID] = true
+		if err := d.validate(); err != nil {
+			return fmt.Errorf("device %s %v", d.DeviceID, err)
+		}
+	}
+	return nil
+}
+
+func (d *Device) validate() error {
+	if d.Host == "" {
+		return fmt.Errorf("is missing a host")
+	}
+	ports := map[string]int{}
+	if d.IAgent != nil {
+		ports["iAgent"] = d.IAgent.Port
+	}
+	if d.OpenConfig != nil {
+		ports["open-config"] = d.OpenConfig.Port
+	}
+	if d.Snmp != nil {
+		ports["snmp"] = d.Snmp.Port
+	}
+	if d.OutboundSSH != nil {
+		ports["outbound-ssh"] = d.OutboundSSH.Port
+	}
+	for name, port := range ports {
+		if port < 0 || port > 65535 {
+			return fmt.Errorf("has an invalid %s port %v", name, port)
+		}
+	}
+	if d.Timezone != nil && !validTimezone(*d.Timezone) {
+		return fmt.Errorf("has an invalid timezone %s", *d.Timezone)
+	}
+	if d.Authentication != nil && d.Authentication.SSH != nil && d.Authentication.SSH.SSHKeyProfile == "" {
+		return fmt.Errorf("is missing an ssh-key-profile")
+	}
+	if d.Snmp != nil && d.Snmp.V3 != nil {
+		if err := d.Snmp.V3.Usm.validate(); err != nil {
+			return err
+		}
+	}
+	if d.Flow != nil {
+		for _, ip := range d.Flow.SourceIP {
+			if net.ParseIP(ip) == nil {
+				return fmt.Errorf("has an invalid flow source-ip %s", ip)
+			}
+		}
+	}
+	return validateVariables(d.Variable)
+}
+
+// validate - an SNMP v3 user needs a password for each protocol it uses, and privacy needs authentication
+func (u *Usm) validate() error {
+	if u.Username == "" {
+		return fmt.Errorf("is missing an snmp v3 username")
+	}
+	authentication := u.AuthenticationProtocol != nil && *u.AuthenticationProtocol != "none"
+	privacy := u.PrivacyProtocol != nil && *u.PrivacyProtocol != "none"
+	if u.AuthenticationProtocol != nil && !authenticationProtocols[*u.AuthenticationProtocol] {
+		return fmt.Errorf("has an invalid snmp v3 authentication-protocol %s", *u.AuthenticationProtocol)
+	}
+	if u.PrivacyProtocol != nil && !privacyProtocols[*u.PrivacyProtocol] {
+		return fmt.Errorf("has an invalid snmp v3 privacy-protocol %s", *u.PrivacyProtocol)
+	}
+	if authentication && u.AuthenticationPassword == nil {
+		return fmt.Errorf("is missing an snmp v3 authentication-password")
+	}
+	if privacy && !authentication {
+		return fmt.Errorf("has an snmp v3 privacy-protocol without an authentication-protocol")
+	}
+	if privacy && u.PrivacyPassword == nil {
+		return fmt.Errorf("is missing an snmp v3 privacy-password")
+	}
+	return nil
+}
+
+// validTimezone - an offset from UTC or a name from the time zone database e.g. Europe/Dublin
+func validTimezone(timezone string) bool {
+	if timezonePattern.MatchString(timezone) {
+		return true
+	}
+	_, err := time.LoadLocation(timezone)
+	return err == nil && timezone != "" && timezone != "Local"
+}
@@ -0,0 +1,257 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Extra - the fields of a payload that the types do not model, kept when a configuration is unmarshalled
+// and written back when it is marshalled, so that nothing is lost on newer Healthbot versions.
+//
+// Every struct in a configuration has an Extra field tagged `json:"-" yaml:",inline"`, yaml keeps the
+// unknown fields itself and the configurations use unmarshalJSON and marshalJSON for json.
+type Extra map[string]interface{}
+
+var extraType = reflect.TypeOf(Extra(nil))
+
+// jsonName - the name of a struct field in json, empty when it is not marshalled
+func jsonName(f reflect.StructField) string {
+	if f.PkgPath != "" {
+		return ""
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name
+	}
+	return f.Name
+}
+
+// unmarshalJSON - unmarshals the data into v, keeping the fields that v does not model in its Extra fields
+func unmarshalJSON(data []byte, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return err
+	}
+	keepExtra(reflect.ValueOf(v), generic)
+	return nil
+}
+
+func keepExtra(v reflect.Value, generic interface{}) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			keepExtra(v.Elem(), generic)
+		}
+	case reflect.Slice, reflect.Array:
+		items, _ := generic.([]interface{})
+		for i := 0; i < v.Len() && i < len(items); i++ {
+			keepExtra(v.Index(i), items[i])
+		}
+	case reflect.Struct:
+		fields, ok := generic.(map[string]interface{})
+		if !ok {
+			return
+		}
+		known := map[string]bool{}
+		var extra reflect.Value
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.Type == extraType {
+				extra = v.Field(i)
+				continue
+			}
+			if name := jsonName(f); name != "" {
+				known[name] = true
+				keepExtra(v.Field(i), fields[name])
+			}
+		}
+		if !extra.IsValid() {
+			return
+		}
+		unknown := Extra{}
+		for name, value := range fields {
+			if !known[name] {
+				unknown[name] = plainValue(value)
+			}
+		}
+		if len(unknown) == 0 {
+			unknown = nil
+		}
+		extra.Set(reflect.ValueOf(unknown))
+	}
+}
+
+// marshalJSON - marshals v with the fields kept in its Extra fields
+func marshalJSON(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || !hasExtra(reflect.ValueOf(v)) {
+		return data, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	addExtra(reflect.ValueOf(v), generic)
+	return json.Marshal(generic)
+}
+
+func hasExtra(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr:
+		return !v.IsNil() && hasExtra(v.Elem())
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if hasExtra(v.Index(i)) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.Type == extraType && v.Field(i).Len() > 0 {
+				return true
+			}
+			if jsonName(f) != "" && hasExtra(v.Field(i)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// UnmarshalExtra - unmarshals the json into v keeping the fields it does not model in its Extra fields, for
+// types outside this package e.g. in the UnmarshalJSON of a type with an alias that has no methods
+func UnmarshalExtra(data []byte, v interface{}) error {
+	return unmarshalJSON(data, v)
+}
+
+// MarshalExtra - marshals v with the fields kept in its Extra fields, the counterpart of UnmarshalExtra
+func MarshalExtra(v interface{}) ([]byte, error) {
+	return marshalJSON(v)
+}
+
+// Extras - the Extra fields of a configuration that hold something, e.g. to redact the secrets in them
+func Extras(configuration interface{}) []Extra {
+	var extras []Extra
+	collectExtras(reflect.ValueOf(configuration), &extras)
+	return extras
+}
+
+func collectExtras(v reflect.Value, extras *[]Extra) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			collectExtras(v.Elem(), extras)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			collectExtras(v.Index(i), extras)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.Type == extraType {
+				if v.Field(i).Len() > 0 {
+					*extras = append(*extras, v.Field(i).Interface().(Extra))
+				}
+				continue
+			}
+			if jsonName(f) != "" {
+				collectExtras(v.Field(i), extras)
+			}
+		}
+	}
+}
+
+func addExtra(v reflect.Value, generic interface{}) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			addExtra(v.Elem(), generic)
+		}
+	case reflect.Slice, reflect.Array:
+		items, _ := generic.([]interface{})
+		for i := 0; i < v.Len() && i < len(items); i++ {
+			addExtra(v.Index(i), items[i])
+		}
+	case reflect.Struct:
+		fields, ok := generic.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.Type == extraType {
+				// a modelled field always wins over an unknown one of the same name
+				for name, value := range v.Field(i).Interface().(Extra) {
+					if _, exists := fields[name]; !exists {
+						fields[name] = jsonValue(value)
+					}
+				}
+				continue
+			}
+			if name := jsonName(f); name != "" {
+				addExtra(v.Field(i), fields[name])
+			}
+		}
+	}
+}
+
+// jsonValue - the value with the map[interface{}]interface{} that yaml unmarshals to made into json objects
+func jsonValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		object := map[string]interface{}{}
+		for k, v := range value {
+			object[fmt.Sprintf("%v", k)] = jsonValue(v)
+		}
+		return object
+	case map[string]interface{}:
+		object := map[string]interface{}{}
+		for k, v := range value {
+			object[k] = jsonValue(v)
+		}
+		return object
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		for i, v := range value {
+			list[i] = jsonValue(v)
+		}
+		return list
+	}
+	return value
+}
+
+// plainValue - the value with its json numbers made into int64 or float64, which yaml writes as numbers
+func plainValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		f, _ := value.Float64()
+		return f
+	case map[string]interface{}:
+		for k, v := range value {
+			value[k] = plainValue(v)
+		}
+	case []interface{}:
+		for i, v := range value {
+			value[i] = plainValue(v)
+		}
+	}
+	return value
+}
@@ -0,0 +1,70 @@
+package types
+
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v2"
+)
+
+// FrequencyProfiles - collection of Frequency Profiles
+type FrequencyProfiles struct {
+	FrequencyProfile []FrequencyProfile `json:"frequency-profile" yaml:"frequency-profile"`
+	Extra            Extra              `json:"-" yaml:",inline"`
+}
+
+// SensorFrequency - override the ingest frequency for a sensor
+type SensorFrequency struct {
+	SensorName string `json:"sensor-name" yaml:"sensor-name"`
+	Frequency  string `json:"frequency"`
+	Extra      Extra  `json:"-" yaml:",inline"`
+}
+
+// RuleFrequency - override the frequency of a Rule that doesn't use a sensor
+type RuleFrequency struct {
+	Name      string `json:"name"`
+	Frequency string `json:"frequency"`
+	Extra     Extra  `json:"-" yaml:",inline"`
+}
+
+// FrequencyProfile - a named set of ingest frequency overrides
+type FrequencyProfile struct {
+	Name           string             `json:"name"`
+	Sensor         *[]SensorFrequency `json:"sensor,omitempty" yaml:"sensor,omitempty"`
+	NonSensorRules *[]RuleFrequency   `json:"non-sensor-rules,omitempty" yaml:"non-sensor-rules,omitempty"`
+	Extra          Extra              `json:"-" yaml:",inline"`
+}
+
+// Parse - tries to parse yaml first, then json into the FrequencyProfiles struct
+func (c *FrequencyProfiles) Parse(data []byte) error {
+	if err := yaml.Unmarshal(data, c); err != nil {
+		if err := json.Unmarshal(data, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON - keeps the fields that the FrequencyProfiles types do not model
+func (c *FrequencyProfiles) UnmarshalJSON(data []byte) error {
+	type frequencyProfiles FrequencyProfiles
+	return unmarshalJSON(data, (*frequencyProfiles)(c))
+}
+
+// MarshalJSON - writes back the fields that the FrequencyProfiles types do not model
+func (c FrequencyProfiles) MarshalJSON() ([]byte, error) {
+	type frequencyProfiles FrequencyProfiles
+	return marshalJSON(frequencyProfiles(c))
+}
+
+// Dump - outputs FrequencyProfiles struct in either 'yaml' or 'json' format
+func (c *FrequencyProfiles) Dump(format string) string {
+	return DumpYAMLOrJSON(format, c)
+}
+
+// Names - the names of the Frequency Profiles
+func (c *FrequencyProfiles) Names() (names []string) {
+	for _, fp := range c.FrequencyProfile {
+		names = append(names, fp.Name)
+	}
+	return
+}
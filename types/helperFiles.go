@@ -0,0 +1,56 @@
+package types
+
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v2"
+)
+
+// HelperFiles - listing of the Helper Files stored in Healthbot
+type HelperFiles struct {
+	HelperFile []HelperFile `json:"helper-files" yaml:"helper-files"`
+	Extra      Extra        `json:"-" yaml:",inline"`
+}
+
+// HelperFile - name of a Helper File relative to the helper-files directory and the SHA-256 digest of its content
+type HelperFile struct {
+	FileName string `json:"file-name" yaml:"file-name"`
+	Checksum string `json:"checksum"`
+	Extra    Extra  `json:"-" yaml:",inline"`
+}
+
+// Parse - tries to parse yaml first, then json into the HelperFiles struct
+func (c *HelperFiles) Parse(data []byte) error {
+	if err := yaml.Unmarshal(data, c); err != nil {
+		if err := json.Unmarshal(data, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON - keeps the fields that the HelperFiles types do not model
+func (c *HelperFiles) UnmarshalJSON(data []byte) error {
+	type helperFiles HelperFiles
+	return unmarshalJSON(data, (*helperFiles)(c))
+}
+
+// MarshalJSON - writes back the fields that the HelperFiles types do not model
+func (c HelperFiles) MarshalJSON() ([]byte, error) {
+	type helperFiles HelperFiles
+	return marshalJSON(helperFiles(c))
+}
+
+// Dump - outputs HelperFiles struct in either 'yaml' or 'json' format
+func (c *HelperFiles) Dump(format string) string {
+	return DumpYAMLOrJSON(format, c)
+}
+
+// Checksums - map of file name to checksum
+func (c *HelperFiles) Checksums() map[string]string {
+	checksums := map[string]string{}
+	for _, hf := range c.HelperFile {
+		checksums[hf.FileName] = hf.Checksum
+	}
+	return checksums
+}
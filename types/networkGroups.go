@@ -0,0 +1,63 @@
+package types
+
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v2"
+)
+
+// NetworkGroups - collection of Network Groups
+type NetworkGroups struct {
+	NetworkGroup []NetworkGroup `json:"network-group" yaml:"network-group"`
+	Extra        Extra          `json:"-" yaml:",inline"`
+}
+
+// NetworkGroup - info needed to Register a NetworkGroup in Healthbot, Variable holds the network Playbook instances
+type NetworkGroup struct {
+	NetworkGroupName string      `json:"network-group-name" yaml:"network-group-name"`
+	Description      *string     `json:"description,omitempty" yaml:"description,omitempty"`
+	Playbooks        *[]string   `json:"playbooks,omitempty" yaml:"playbooks,omitempty"`
+	Variable         *[]Variable `json:"variable,omitempty" yaml:"variable,omitempty"`
+	RetentionPolicy  *string     `json:"retention-policy,omitempty" yaml:"retention-policy,omitempty"`
+	Reports          *[]string   `json:"reports,omitempty" yaml:"reports,omitempty"`
+	Extra            Extra       `json:"-" yaml:",inline"`
+}
+
+// Parse - tries to parse yaml first, then json into the NetworkGroups struct
+func (c *NetworkGroups) Parse(data []byte) error {
+	if err := yaml.Unmarshal(data, c); err != nil {
+		if err := json.Unmarshal(data, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON - keeps the fields that the NetworkGroups types do not model
+func (c *NetworkGroups) UnmarshalJSON(data []byte) error {
+	type networkGroups NetworkGroups
+	return unmarshalJSON(data, (*networkGroups)(c))
+}
+
+// MarshalJSON - writes back the fields that the NetworkGroups types do not model
+func (c NetworkGroups) MarshalJSON() ([]byte, error) {
+	type networkGroups NetworkGroups
+	return marshalJSON(networkGroups(c))
+}
+
+// UnmarshalJSON - keeps the fields that the NetworkGroup types do not model, when a single Network Group is read
+func (c *NetworkGroup) UnmarshalJSON(data []byte) error {
+	type networkGroup NetworkGroup
+	return unmarshalJSON(data, (*networkGroup)(c))
+}
+
+// MarshalJSON - writes back the fields that the NetworkGroup types do not model
+func (c NetworkGroup) MarshalJSON() ([]byte, error) {
+	type networkGroup NetworkGroup
+	return marshalJSON(networkGroup(c))
+}
+
+// Dump - outputs NetworkGroups struct in either 'yaml' or 'json' format
+func (c *NetworkGroups) Dump(format string) string {
+	return DumpYAMLOrJSON(format, c)
+}
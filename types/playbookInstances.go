@@ -2,26 +2,37 @@ package types
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"gopkg.in/yaml.v2"
 )
 
-// PlaybookInstances - wrapper type for Device Groups, with only the Playbook relevant information described
+// VariableValue - override for a single Rule variable
+type VariableValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Extra Extra  `json:"-" yaml:",inline"`
+}
+
+// Variable - the Rule variables for a Playbook instance
+type Variable struct {
+	InstanceID    string          `json:"instance-id" yaml:"instance-id"`
+	Playbook      string          `json:"playbook"`
+	Rule          string          `json:"rule"`
+	VariableValue []VariableValue `json:"variable-value,omitempty" yaml:"variable-value,omitempty"`
+	Extra         Extra           `json:"-" yaml:",inline"`
+}
+
+// PlaybookInstances - wrapper type for Device Groups, with only the Playbook relevant information described,
+// so a Device Group keeps no Extra fields here and the rest of its configuration is left out
 type PlaybookInstances struct {
 	DeviceGroup []struct {
-		DeviceGroupName string    `json:"device-group-name" yaml:"device-group-name"`
-		Devices         *[]string `json:"devices,omitempty" yaml:"devices,omitempty"`
-		Playbooks       []string  `json:"playbooks,omitempty" yaml:"playbooks,omitempty"`
-		Variable        []struct {
-			InstanceID    string `json:"instance-id" yaml:"instance-id"`
-			Playbook      string `json:"playbook"`
-			Rule          string `json:"rule"`
-			VariableValue []struct {
-				Name  string `json:"name"`
-				Value string `json:"value"`
-			} `json:"variable-value,omitempty" yaml:"variable-value,omitempty"`
-		} `json:"variable"`
+		DeviceGroupName string     `json:"device-group-name" yaml:"device-group-name"`
+		Devices         *[]string  `json:"devices,omitempty" yaml:"devices,omitempty"`
+		Playbooks       []string   `json:"playbooks,omitempty" yaml:"playbooks,omitempty"`
+		Variable        []Variable `json:"variable"`
 	} `json:"device-group" yaml:"device-group"`
+	Extra Extra `json:"-" yaml:",inline"`
 }
 
 // Parse - tries to parse yaml first, then json into the PlaybookInstances struct
@@ -34,7 +45,34 @@ func (c *PlaybookInstances) Parse(data []byte) error {
 	return nil
 }
 
+// UnmarshalJSON - keeps the fields that the PlaybookInstances types do not model
+func (c *PlaybookInstances) UnmarshalJSON(data []byte) error {
+	type playbookInstances PlaybookInstances
+	return unmarshalJSON(data, (*playbookInstances)(c))
+}
+
+// MarshalJSON - writes back the fields that the PlaybookInstances types do not model
+func (c PlaybookInstances) MarshalJSON() ([]byte, error) {
+	type playbookInstances PlaybookInstances
+	return marshalJSON(playbookInstances(c))
+}
+
 // Dump - outputs PlaybookInstances struct in either 'yaml' or 'json' format
 func (c *PlaybookInstances) Dump(format string) string {
 	return DumpYAMLOrJSON(format, c)
 }
+
+// validateVariables - each Variable identifies its Playbook instance and names each of its values
+func validateVariables(variables []Variable) error {
+	for _, v := range variables {
+		if v.Playbook == "" || v.Rule == "" || v.InstanceID == "" {
+			return fmt.Errorf("has a variable without a playbook, rule and instance-id")
+		}
+		for _, value := range v.VariableValue {
+			if value.Name == "" {
+				return fmt.Errorf("has a variable value without a name in playbook %s", v.Playbook)
+			}
+		}
+	}
+	return nil
+}
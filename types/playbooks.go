@@ -8,12 +8,17 @@ import (
 
 // Playbooks - Playbook information
 type Playbooks struct {
-	Playbooks []struct {
-		PlayBookName string   `json:"playbook-name" yaml:"playbook-name"`
-		Description  string   `json:"description" yaml:"description"`
-		Rules        []string `json:"rules"`
-		Synopsis     string   `json:"synopsis" yaml:"synopsis"`
-	} `json:"playbooks" yaml:"playbooks"`
+	Playbooks []Playbook `json:"playbooks" yaml:"playbooks"`
+	Extra     Extra      `json:"-" yaml:",inline"`
+}
+
+// Playbook - a named set of Rules
+type Playbook struct {
+	PlayBookName string   `json:"playbook-name" yaml:"playbook-name"`
+	Description  string   `json:"description" yaml:"description"`
+	Rules        []string `json:"rules"`
+	Synopsis     string   `json:"synopsis" yaml:"synopsis"`
+	Extra        Extra    `json:"-" yaml:",inline"`
 }
 
 // Parse - tries to parse yaml first, then json into the Playbooks struct
@@ -26,6 +31,30 @@ func (c *Playbooks) Parse(data []byte) error {
 	return nil
 }
 
+// UnmarshalJSON - keeps the fields that the Playbooks types do not model
+func (c *Playbooks) UnmarshalJSON(data []byte) error {
+	type playbooks Playbooks
+	return unmarshalJSON(data, (*playbooks)(c))
+}
+
+// MarshalJSON - writes back the fields that the Playbooks types do not model
+func (c Playbooks) MarshalJSON() ([]byte, error) {
+	type playbooks Playbooks
+	return marshalJSON(playbooks(c))
+}
+
+// UnmarshalJSON - keeps the fields that the Playbook type does not model, when a single Playbook is read
+func (c *Playbook) UnmarshalJSON(data []byte) error {
+	type playbook Playbook
+	return unmarshalJSON(data, (*playbook)(c))
+}
+
+// MarshalJSON - writes back the fields that the Playbook type does not model
+func (c Playbook) MarshalJSON() ([]byte, error) {
+	type playbook Playbook
+	return marshalJSON(playbook(c))
+}
+
 // Dump - outputs Playbooks struct in either 'yaml' or 'json' format
 func (c *Playbooks) Dump(format string) string {
 	return DumpYAMLOrJSON(format, c)
@@ -0,0 +1,57 @@
+package types
+
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Reports - collection of scheduled Reports
+type Reports struct {
+	Report []Report `json:"report" yaml:"report"`
+	Extra  Extra    `json:"-" yaml:",inline"`
+}
+
+// Report - a Report generated on a Schedule and sent to Destinations
+type Report struct {
+	Name        string    `json:"name"`
+	Format      *string   `json:"format,omitempty" yaml:"format,omitempty"`
+	Destination *[]string `json:"destination,omitempty" yaml:"destination,omitempty"`
+	Schedule    *[]string `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	Extra       Extra     `json:"-" yaml:",inline"`
+}
+
+// Parse - tries to parse yaml first, then json into the Reports struct
+func (c *Reports) Parse(data []byte) error {
+	if err := yaml.Unmarshal(data, c); err != nil {
+		if err := json.Unmarshal(data, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON - keeps the fields that the Reports types do not model
+func (c *Reports) UnmarshalJSON(data []byte) error {
+	type reports Reports
+	return unmarshalJSON(data, (*reports)(c))
+}
+
+// MarshalJSON - writes back the fields that the Reports types do not model
+func (c Reports) MarshalJSON() ([]byte, error) {
+	type reports Reports
+	return marshalJSON(reports(c))
+}
+
+// Dump - outputs Reports struct in either 'yaml' or 'json' format
+func (c *Reports) Dump(format string) string {
+	return DumpYAMLOrJSON(format, c)
+}
+
+// Names - the names of the Reports
+func (c *Reports) Names() (names []string) {
+	for _, r := range c.Report {
+		names = append(names, r.Name)
+	}
+	return
+}
@@ -0,0 +1,82 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v2"
+)
+
+// RetentionPolicies - collection of Retention Policies
+type RetentionPolicies struct {
+	RetentionPolicy []RetentionPolicy `json:"retention-policy" yaml:"retention-policy"`
+	Extra           Extra             `json:"-" yaml:",inline"`
+}
+
+// RetentionPolicy - how long time series data is kept in the TSDB
+type RetentionPolicy struct {
+	RetentionPolicyName string  `json:"retention-policy-name" yaml:"retention-policy-name"`
+	Duration            *string `json:"duration,omitempty" yaml:"duration,omitempty"`
+	Replication         *int    `json:"replication,omitempty" yaml:"replication,omitempty"`
+	Extra               Extra   `json:"-" yaml:",inline"`
+}
+
+// durations are InfluxDB style e.g. 1h, 7d, 52w or INF
+var durationPattern = regexp.MustCompile(`^([0-9]+[mhdw]|INF)$`)
+
+// Parse - tries to parse yaml first, then json into the RetentionPolicies struct
+func (c *RetentionPolicies) Parse(data []byte) error {
+	if err := yaml.Unmarshal(data, c); err != nil {
+		if err := json.Unmarshal(data, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON - keeps the fields that the RetentionPolicies types do not model
+func (c *RetentionPolicies) UnmarshalJSON(data []byte) error {
+	type retentionPolicies RetentionPolicies
+	return unmarshalJSON(data, (*retentionPolicies)(c))
+}
+
+// MarshalJSON - writes back the fields that the RetentionPolicies types do not model
+func (c RetentionPolicies) MarshalJSON() ([]byte, error) {
+	type retentionPolicies RetentionPolicies
+	return marshalJSON(retentionPolicies(c))
+}
+
+// Dump - outputs RetentionPolicies struct in either 'yaml' or 'json' format
+func (c *RetentionPolicies) Dump(format string) string {
+	return DumpYAMLOrJSON(format, c)
+}
+
+// Validate - checks the Retention Policies are named uniquely and have a valid duration
+func (c *RetentionPolicies) Validate() error {
+	seen := map[string]bool{}
+	for _, rp := range c.RetentionPolicy {
+		if rp.RetentionPolicyName == "" {
+			return fmt.Errorf("retention policy is missing a retention-policy-name")
+		}
+		if seen[rp.RetentionPolicyName] {
+			return fmt.Errorf("retention policy %s is defined more than once", rp.RetentionPolicyName)
+		}
+		seen[rp.RetentionPolicyName] = true
+		if rp.Duration != nil && !durationPattern.MatchString(*rp.Duration) {
+			return fmt.Errorf("retention policy %s has an invalid duration %s", rp.RetentionPolicyName, *rp.Duration)
+		}
+		if rp.Replication != nil && *rp.Replication < 1 {
+			return fmt.Errorf("retention policy %s has an invalid replication %v", rp.RetentionPolicyName, *rp.Replication)
+		}
+	}
+	return nil
+}
+
+// Names - the names of the Retention Policies
+func (c *RetentionPolicies) Names() (names []string) {
+	for _, rp := range c.RetentionPolicy {
+		names = append(names, rp.RetentionPolicyName)
+	}
+	return
+}
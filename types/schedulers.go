@@ -0,0 +1,66 @@
+package types
+
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Schedulers - collection of Schedulers
+type Schedulers struct {
+	Scheduler []Scheduler `json:"scheduler" yaml:"scheduler"`
+	Extra     Extra       `json:"-" yaml:",inline"`
+}
+
+// Repeat - how often a Scheduler fires
+type Repeat struct {
+	Every    *string `json:"every,omitempty" yaml:"every,omitempty"`
+	Interval *string `json:"interval,omitempty" yaml:"interval,omitempty"`
+	Extra    Extra   `json:"-" yaml:",inline"`
+}
+
+// Scheduler - a time window used by e.g. Reports
+type Scheduler struct {
+	Name      string  `json:"name"`
+	Type      *string `json:"type,omitempty" yaml:"type,omitempty"`
+	StartTime *string `json:"start-time,omitempty" yaml:"start-time,omitempty"`
+	EndTime   *string `json:"end-time,omitempty" yaml:"end-time,omitempty"`
+	RunFor    *string `json:"run-for,omitempty" yaml:"run-for,omitempty"`
+	Repeat    *Repeat `json:"repeat,omitempty" yaml:"repeat,omitempty"`
+	Extra     Extra   `json:"-" yaml:",inline"`
+}
+
+// Parse - tries to parse yaml first, then json into the Schedulers struct
+func (c *Schedulers) Parse(data []byte) error {
+	if err := yaml.Unmarshal(data, c); err != nil {
+		if err := json.Unmarshal(data, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON - keeps the fields that the Schedulers types do not model
+func (c *Schedulers) UnmarshalJSON(data []byte) error {
+	type schedulers Schedulers
+	return unmarshalJSON(data, (*schedulers)(c))
+}
+
+// MarshalJSON - writes back the fields that the Schedulers types do not model
+func (c Schedulers) MarshalJSON() ([]byte, error) {
+	type schedulers Schedulers
+	return marshalJSON(schedulers(c))
+}
+
+// Dump - outputs Schedulers struct in either 'yaml' or 'json' format
+func (c *Schedulers) Dump(format string) string {
+	return DumpYAMLOrJSON(format, c)
+}
+
+// Names - the names of the Schedulers
+func (c *Schedulers) Names() (names []string) {
+	for _, s := range c.Scheduler {
+		names = append(names, s.Name)
+	}
+	return
+}
@@ -0,0 +1,117 @@
+package types
+
+import "strings"
+
+// Secret fields held by the configuration types
+const (
+	UsernameField               = "username"
+	PasswordField               = "password"
+	CommunityField              = "community"
+	AuthenticationPasswordField = "authentication-password"
+	PrivacyPasswordField        = "privacy-password"
+)
+
+// Secret - a credential held by a configuration, Path locates it e.g. device/mx960-1/password
+type Secret struct {
+	Field string
+	Path  string
+	Value string
+	set   func(value *string)
+}
+
+// Set - replaces the value of the Secret in its configuration, nil removes the field
+func (s Secret) Set(value *string) {
+	s.set(value)
+}
+
+// SecretHolder - configurations that hold credentials, only the Secrets that are set are returned
+type SecretHolder interface {
+	Secrets() []Secret
+}
+
+func secretPath(kind, name, field string) string {
+	return strings.Join([]string{kind, name, field}, "/")
+}
+
+// pointerSecret - a Secret held in an optional field, removed by setting it to nil
+func pointerSecret(secrets []Secret, field, path string, value **string, removed func()) []Secret {
+	if *value == nil {
+		return secrets
+	}
+	return append(secrets, Secret{Field: field, Path: path, Value: **value, set: func(v *string) {
+		*value = v
+		if v == nil && removed != nil {
+			removed()
+		}
+	}})
+}
+
+// Secrets - the usernames, passwords, SNMP communities and SNMP v3 passwords of the Devices
+func (c *Devices) Secrets() (secrets []Secret) {
+	for i := range c.Device {
+		d := &c.Device[i]
+		if d.Authentication != nil {
+			password := &d.Authentication.Password
+			removed := func() {
+				authentication := d.Authentication
+				if password.Username == nil && password.Password == nil && len(password.Extra) == 0 &&
+					authentication.SSH == nil && authentication.SSL == nil && len(authentication.Extra) == 0 {
+					d.Authentication = nil
+				}
+			}
+			secrets = pointerSecret(secrets, UsernameField, secretPath("device", d.DeviceID, UsernameField), &password.Username, removed)
+			secrets = pointerSecret(secrets, PasswordField, secretPath("device", d.DeviceID, PasswordField), &password.Password, removed)
+		}
+		if d.Snmp != nil && d.Snmp.V2 != nil {
+			snmp := d.Snmp
+			secrets = append(secrets, Secret{Field: CommunityField, Path: secretPath("device", d.DeviceID, CommunityField), Value: snmp.V2.Community, set: func(v *string) {
+				if v != nil {
+					snmp.V2.Community = *v
+					return
+				}
+				snmp.V2 = nil
+				if snmp.Port == 0 && snmp.V3 == nil && len(snmp.Extra) == 0 {
+					d.Snmp = nil
+				}
+			}})
+		}
+		if d.Snmp != nil && d.Snmp.V3 != nil {
+			usm := &d.Snmp.V3.Usm
+			secrets = pointerSecret(secrets, AuthenticationPasswordField, secretPath("device", d.DeviceID, AuthenticationPasswordField), &usm.AuthenticationPassword, nil)
+			secrets = pointerSecret(secrets, PrivacyPasswordField, secretPath("device", d.DeviceID, PrivacyPasswordField), &usm.PrivacyPassword, nil)
+		}
+	}
+	return
+}
+
+// Secrets - the usernames and passwords that override those of the Devices in the Device Groups
+func (c *DeviceGroups) Secrets() (secrets []Secret) {
+	for i := range c.DeviceGroup {
+		dg := &c.DeviceGroup[i]
+		if dg.Authentication == nil {
+			continue
+		}
+		password := &dg.Authentication.Password
+		removed := func() {
+			if password.Username == nil && password.Password == nil && len(password.Extra) == 0 && len(dg.Authentication.Extra) == 0 {
+				dg.Authentication = nil
+			}
+		}
+		secrets = pointerSecret(secrets, UsernameField, secretPath("device-group", dg.DeviceGroupName, UsernameField), &password.Username, removed)
+		secrets = pointerSecret(secrets, PasswordField, secretPath("device-group", dg.DeviceGroupName, PasswordField), &password.Password, removed)
+	}
+	return
+}
+
+// Secrets - the authentication and privacy passwords of the SNMP v3 users
+func (c *SnmpNotification) Secrets() (secrets []Secret) {
+	if c.V3 == nil {
+		return
+	}
+	for i := range c.V3.Usm.Users {
+		u := &c.V3.Usm.Users[i]
+		secrets = pointerSecret(secrets, AuthenticationPasswordField, secretPath("snmp-user", u.Name, AuthenticationPasswordField), &u.AuthenticationPassword, nil)
+		secrets = pointerSecret(secrets, PrivacyPasswordField, secretPath("snmp-user", u.Name, PrivacyPasswordField), &u.PrivacyPassword, nil)
+	}
+	return
+}
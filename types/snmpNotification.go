@@ -0,0 +1,60 @@
+package types
+
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SnmpNotificationUser - USM user allowed to send SNMP v3 traps
+type SnmpNotificationUser struct {
+	Name                   string  `json:"name"`
+	AuthenticationProtocol *string `json:"authentication-protocol,omitempty" yaml:"authentication-protocol,omitempty"`
+	AuthenticationPassword *string `json:"authentication-password,omitempty" yaml:"authentication-password,omitempty"`
+	PrivacyProtocol        *string `json:"privacy-protocol,omitempty" yaml:"privacy-protocol,omitempty"`
+	PrivacyPassword        *string `json:"privacy-password,omitempty" yaml:"privacy-password,omitempty"`
+	Extra                  Extra   `json:"-" yaml:",inline"`
+}
+
+// SnmpNotificationV3 - SNMP v3 trap settings
+type SnmpNotificationV3 struct {
+	Usm struct {
+		Users []SnmpNotificationUser `json:"users"`
+		Extra Extra                  `json:"-" yaml:",inline"`
+	} `json:"usm"`
+	Extra Extra `json:"-" yaml:",inline"`
+}
+
+// SnmpNotification - the SNMP trap ingest settings
+type SnmpNotification struct {
+	Port  *int                `json:"port,omitempty" yaml:"port,omitempty"`
+	V3    *SnmpNotificationV3 `json:"v3,omitempty" yaml:"v3,omitempty"`
+	Extra Extra               `json:"-" yaml:",inline"`
+}
+
+// Parse - tries to parse yaml first, then json into the SnmpNotification struct
+func (c *SnmpNotification) Parse(data []byte) error {
+	if err := yaml.Unmarshal(data, c); err != nil {
+		if err := json.Unmarshal(data, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON - keeps the fields that the SnmpNotification types do not model
+func (c *SnmpNotification) UnmarshalJSON(data []byte) error {
+	type snmpNotification SnmpNotification
+	return unmarshalJSON(data, (*snmpNotification)(c))
+}
+
+// MarshalJSON - writes back the fields that the SnmpNotification types do not model
+func (c SnmpNotification) MarshalJSON() ([]byte, error) {
+	type snmpNotification SnmpNotification
+	return marshalJSON(snmpNotification(c))
+}
+
+// Dump - outputs SnmpNotification struct in either 'yaml' or 'json' format
+func (c *SnmpNotification) Dump(format string) string {
+	return DumpYAMLOrJSON(format, c)
+}
@@ -0,0 +1,90 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Syslog - the syslog ingest settings, Patterns and the Pattern Sets that group them
+type Syslog struct {
+	Pattern    []SyslogPattern    `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	PatternSet []SyslogPatternSet `json:"pattern-set,omitempty" yaml:"pattern-set,omitempty"`
+	Extra      Extra              `json:"-" yaml:",inline"`
+}
+
+// SyslogField - a field extracted from a matching syslog message
+type SyslogField struct {
+	Name  string  `json:"name"`
+	Type  *string `json:"type,omitempty" yaml:"type,omitempty"`
+	Extra Extra   `json:"-" yaml:",inline"`
+}
+
+// SyslogPattern - matches a syslog message by its event id
+type SyslogPattern struct {
+	Name        string         `json:"name"`
+	EventID     string         `json:"event-id" yaml:"event-id"`
+	Description *string        `json:"description,omitempty" yaml:"description,omitempty"`
+	Field       *[]SyslogField `json:"field,omitempty" yaml:"field,omitempty"`
+	Extra       Extra          `json:"-" yaml:",inline"`
+}
+
+// SyslogPatternSet - named group of Patterns that Rules can subscribe to
+type SyslogPatternSet struct {
+	Name        string   `json:"name"`
+	Description *string  `json:"description,omitempty" yaml:"description,omitempty"`
+	Pattern     []string `json:"pattern"`
+	Extra       Extra    `json:"-" yaml:",inline"`
+}
+
+// Parse - tries to parse yaml first, then json into the Syslog struct
+func (c *Syslog) Parse(data []byte) error {
+	if err := yaml.Unmarshal(data, c); err != nil {
+		if err := json.Unmarshal(data, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON - keeps the fields that the Syslog types do not model
+func (c *Syslog) UnmarshalJSON(data []byte) error {
+	type syslog Syslog
+	return unmarshalJSON(data, (*syslog)(c))
+}
+
+// MarshalJSON - writes back the fields that the Syslog types do not model
+func (c Syslog) MarshalJSON() ([]byte, error) {
+	type syslog Syslog
+	return marshalJSON(syslog(c))
+}
+
+// Dump - outputs Syslog struct in either 'yaml' or 'json' format
+func (c *Syslog) Dump(format string) string {
+	return DumpYAMLOrJSON(format, c)
+}
+
+// PatternNames - the names of the Patterns
+func (c *Syslog) PatternNames() (names []string) {
+	for _, p := range c.Pattern {
+		names = append(names, p.Name)
+	}
+	return
+}
+
+// Validate - checks each Pattern Set only uses the known Patterns
+func (c *Syslog) Validate(known []string) error {
+	patterns := map[string]bool{}
+	for _, name := range append(c.PatternNames(), known...) {
+		patterns[name] = true
+	}
+	for _, ps := range c.PatternSet {
+		for _, name := range ps.Pattern {
+			if !patterns[name] {
+				return fmt.Errorf("pattern set %s uses undefined pattern %s", ps.Name, name)
+			}
+		}
+	}
+	return nil
+}
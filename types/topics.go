@@ -0,0 +1,102 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Topics - collection of Topics and their Rules
+type Topics struct {
+	Topic []Topic `json:"topic" yaml:"topic"`
+	Extra Extra   `json:"-" yaml:",inline"`
+}
+
+// Topic - a group of Rules e.g. interface.statistics
+type Topic struct {
+	TopicName   string  `json:"topic-name" yaml:"topic-name"`
+	Description *string `json:"description,omitempty" yaml:"description,omitempty"`
+	Rule        []Rule  `json:"rule,omitempty" yaml:"rule,omitempty"`
+	Extra       Extra   `json:"-" yaml:",inline"`
+}
+
+// Rule - only the name and Retention Policy are described, the sensors, fields and triggers are kept as Extra
+type Rule struct {
+	RuleName        string  `json:"rule-name" yaml:"rule-name"`
+	Description     *string `json:"description,omitempty" yaml:"description,omitempty"`
+	RetentionPolicy *string `json:"retention-policy,omitempty" yaml:"retention-policy,omitempty"`
+	Extra           Extra   `json:"-" yaml:",inline"`
+}
+
+// Parse - tries to parse yaml first, then json into the Topics struct
+func (c *Topics) Parse(data []byte) error {
+	if err := yaml.Unmarshal(data, c); err != nil {
+		if err := json.Unmarshal(data, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON - keeps the fields that the Topics types do not model
+func (c *Topics) UnmarshalJSON(data []byte) error {
+	type topics Topics
+	return unmarshalJSON(data, (*topics)(c))
+}
+
+// MarshalJSON - writes back the fields that the Topics types do not model
+func (c Topics) MarshalJSON() ([]byte, error) {
+	type topics Topics
+	return marshalJSON(topics(c))
+}
+
+// Dump - outputs Topics struct in either 'yaml' or 'json' format
+func (c *Topics) Dump(format string) string {
+	return DumpYAMLOrJSON(format, c)
+}
+
+// Validate - checks the Topics and the Rules within each are named uniquely
+func (c *Topics) Validate() error {
+	seen := map[string]bool{}
+	for _, t := range c.Topic {
+		if t.TopicName == "" {
+			return fmt.Errorf("topic is missing a topic-name")
+		}
+		if seen[t.TopicName] {
+			return fmt.Errorf("topic %s is defined more than once", t.TopicName)
+		}
+		seen[t.TopicName] = true
+		rules := map[string]bool{}
+		for _, r := range t.Rule {
+			if r.RuleName == "" {
+				return fmt.Errorf("topic %s has a rule without a rule-name", t.TopicName)
+			}
+			if rules[r.RuleName] {
+				return fmt.Errorf("topic %s has rule %s more than once", t.TopicName, r.RuleName)
+			}
+			rules[r.RuleName] = true
+		}
+	}
+	return nil
+}
+
+// Names - the names of the Topics
+func (c *Topics) Names() (names []string) {
+	for _, t := range c.Topic {
+		names = append(names, t.TopicName)
+	}
+	return
+}
+
+// RetentionPolicies - the names of the Retention Policies used by the Rules
+func (c *Topics) RetentionPolicies() (names []string) {
+	for _, t := range c.Topic {
+		for _, r := range t.Rule {
+			if r.RetentionPolicy != nil {
+				names = append(names, *r.RetentionPolicy)
+			}
+		}
+	}
+	return
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -92,3 +94,365 @@ func TestPlaybooksYamlParsing(t *testing.T) {
 	assert.Len(t, playbooks.Playbooks, 1, "Expected to parse 1 playbook")
 	assert.EqualValues(t, "interface-status-test", playbooks.Playbooks[0].PlayBookName, "Yaml type with a hyphen, didn't decode correctly")
 }
+
+func TestRetentionPoliciesYamlParsing(t *testing.T) {
+	var retentionPolicies RetentionPolicies
+	err := retentionPolicies.Parse(HelperLoadBytes(t, "./retention-policies/retention-policies.yml"))
+	assert.Nil(t, err, "Failed to parse yaml representation of Retention Policies")
+	assert.Len(t, retentionPolicies.RetentionPolicy, 3, "Expected to parse 3 Retention Policies")
+	assert.EqualValues(t, "one-week", retentionPolicies.RetentionPolicy[0].RetentionPolicyName, "Yaml type with a hyphen, didn't decode correctly")
+	assert.Nil(t, retentionPolicies.Validate(), "Expected Retention Policies to be valid")
+	assert.Equal(t, []string{"one-week", "one-year", "forever"}, retentionPolicies.Names())
+}
+
+func TestRetentionPoliciesValidate(t *testing.T) {
+	duration := "7 days"
+	retentionPolicies := RetentionPolicies{RetentionPolicy: []RetentionPolicy{{RetentionPolicyName: "bad", Duration: &duration}}}
+	assert.NotNil(t, retentionPolicies.Validate(), "Expected invalid duration to be rejected")
+
+	retentionPolicies = RetentionPolicies{RetentionPolicy: []RetentionPolicy{{RetentionPolicyName: "dup"}, {RetentionPolicyName: "dup"}}}
+	assert.NotNil(t, retentionPolicies.Validate(), "Expected duplicate names to be rejected")
+
+	retentionPolicies = RetentionPolicies{RetentionPolicy: []RetentionPolicy{{}}}
+	assert.NotNil(t, retentionPolicies.Validate(), "Expected missing name to be rejected")
+}
+
+func TestTopicsYamlParsing(t *testing.T) {
+	var topics Topics
+	err := topics.Parse(HelperLoadBytes(t, "./topics/topics.yml"))
+	assert.Nil(t, err, "Failed to parse yaml representation of Topics")
+	assert.Equal(t, []string{"system.cpu"}, topics.Names())
+	assert.Len(t, topics.Topic[0].Rule, 2, "Expected to parse 2 Rules")
+	assert.Contains(t, topics.Topic[0].Rule[0].Extra, "sensor", "Expected the sensors of a Rule to be kept")
+	assert.Equal(t, []string{"one-week"}, topics.RetentionPolicies(), "Expected the Retention Policy used by the Rule")
+	assert.Nil(t, topics.Validate(), "Expected Topics to be valid")
+
+	topics.Topic[0].Rule[1].RuleName = "check-cpu"
+	assert.NotNil(t, topics.Validate(), "Expected duplicate Rules to be rejected")
+}
+
+func TestDeviceGroupRetentionPolicy(t *testing.T) {
+	var deviceGroups DeviceGroups
+	_ = deviceGroups.Parse(HelperLoadBytes(t, "./device-groups/deviceGroups.yml"))
+	assert.NotNil(t, deviceGroups.DeviceGroup[0].RetentionPolicy, "Expected retention policy reference to be parsed")
+	assert.EqualValues(t, "one-week", *deviceGroups.DeviceGroup[0].RetentionPolicy)
+	minDevice, _ := json.Marshal(deviceGroups.DeviceGroup[1])
+	assert.NotContains(t, string(minDevice), "retention-policy", "Optional Retention Policy type was not ignored")
+}
+
+func TestSchedulersYamlParsing(t *testing.T) {
+	var schedulers Schedulers
+	err := schedulers.Parse(HelperLoadBytes(t, "./schedulers/schedulers.yml"))
+	assert.Nil(t, err, "Failed to parse yaml representation of Schedulers")
+	assert.Len(t, schedulers.Scheduler, 2, "Expected to parse 2 Schedulers")
+	assert.EqualValues(t, "week", *schedulers.Scheduler[0].Repeat.Every, "Yaml type with a hyphen, didn't decode correctly")
+	assert.EqualValues(t, "8h", *schedulers.Scheduler[1].RunFor, "Yaml type with a hyphen, didn't decode correctly")
+	assert.Equal(t, []string{"weekly", "business-hours"}, schedulers.Names())
+}
+
+func TestDestinationsYamlParsing(t *testing.T) {
+	var destinations Destinations
+	err := destinations.Parse(HelperLoadBytes(t, "./destinations/destinations.yml"))
+	assert.Nil(t, err, "Failed to parse yaml representation of Destinations")
+	assert.Len(t, destinations.Destination, 2, "Expected to parse 2 Destinations")
+	assert.EqualValues(t, 10, *destinations.Destination[1].Disk.MaxReports, "Yaml type with a hyphen, didn't decode correctly")
+	email, _ := json.Marshal(destinations.Destination[0])
+	assert.NotContains(t, string(email), "disk", "Optional Disk type was not ignored")
+}
+
+func TestReportsYamlParsing(t *testing.T) {
+	var reports Reports
+	err := reports.Parse(HelperLoadBytes(t, "./reports/reports.yml"))
+	assert.Nil(t, err, "Failed to parse yaml representation of Reports")
+	assert.Len(t, reports.Report, 1, "Expected to parse 1 Report")
+	assert.Equal(t, []string{"management", "archive"}, *reports.Report[0].Destination)
+	assert.Equal(t, []string{"weekly"}, *reports.Report[0].Schedule)
+}
+
+func TestNetworkGroupYamlParsing(t *testing.T) {
+	var networkGroups NetworkGroups
+	err := networkGroups.Parse(HelperLoadBytes(t, "./network-groups/networkGroups.yml"))
+	assert.Nil(t, err, "Failed to parse yaml representation of NetworkGroups")
+	assert.Len(t, networkGroups.NetworkGroup, 2, "Expected to parse 2 NetworkGroups")
+	assert.EqualValues(t, "core-network", networkGroups.NetworkGroup[0].NetworkGroupName, "Yaml type with a hyphen, didn't decode correctly")
+	variables := *networkGroups.NetworkGroup[0].Variable
+	assert.Len(t, variables, 1, "Expected to parse 1 network Playbook instance")
+	assert.EqualValues(t, "rtt-threshold", variables[0].VariableValue[1].Name)
+}
+
+func TestNetworkGroupOmit(t *testing.T) {
+	var networkGroups NetworkGroups
+	_ = networkGroups.Parse(HelperLoadBytes(t, "./network-groups/networkGroups.yml"))
+	minGroup, err := json.Marshal(networkGroups.NetworkGroup[1])
+	if err != nil {
+		assert.Nil(t, err, "Failed to marshal networkgroups to json")
+	}
+	assert.NotContains(t, string(minGroup), "description", "Optional Description type was not ignored")
+	assert.NotContains(t, string(minGroup), "playbooks", "Optional Playbooks type was not ignored")
+	assert.NotContains(t, string(minGroup), "variable", "Optional Variable type was not ignored")
+	assert.NotContains(t, string(minGroup), "retention-policy", "Optional Retention Policy type was not ignored")
+}
+
+func TestSyslogYamlParsing(t *testing.T) {
+	var syslog Syslog
+	err := syslog.Parse(HelperLoadBytes(t, "./syslog/syslog.yml"))
+	assert.Nil(t, err, "Failed to parse yaml representation of Syslog")
+	assert.Len(t, syslog.Pattern, 2, "Expected to parse 2 Patterns")
+	assert.Len(t, syslog.PatternSet, 1, "Expected to parse 1 Pattern Set")
+	assert.EqualValues(t, "SNMP_TRAP_LINK_DOWN", syslog.Pattern[0].EventID, "Yaml type with a hyphen, didn't decode correctly")
+	assert.Nil(t, syslog.Validate(nil), "Expected Pattern Set to only use defined Patterns")
+}
+
+func TestSyslogValidate(t *testing.T) {
+	syslog := Syslog{PatternSet: []SyslogPatternSet{{Name: "set", Pattern: []string{"remote"}}}}
+	assert.NotNil(t, syslog.Validate(nil), "Expected undefined Pattern to be rejected")
+	assert.Nil(t, syslog.Validate([]string{"remote"}), "Expected Pattern known to Healthbot to be accepted")
+}
+
+func TestSnmpNotificationYamlParsing(t *testing.T) {
+	var snmpNotification SnmpNotification
+	err := snmpNotification.Parse(HelperLoadBytes(t, "./snmp-notification/snmp-notification.yml"))
+	assert.Nil(t, err, "Failed to parse yaml representation of SNMP Notification")
+	assert.EqualValues(t, 162, *snmpNotification.Port)
+	assert.Len(t, snmpNotification.V3.Usm.Users, 1, "Expected to parse 1 USM User")
+	assert.EqualValues(t, "aes128", *snmpNotification.V3.Usm.Users[0].PrivacyProtocol, "Yaml type with a hyphen, didn't decode correctly")
+}
+
+func TestFrequencyProfilesYamlParsing(t *testing.T) {
+	var frequencyProfiles FrequencyProfiles
+	err := frequencyProfiles.Parse(HelperLoadBytes(t, "./frequency-profiles/frequency-profiles.yml"))
+	assert.Nil(t, err, "Failed to parse yaml representation of Frequency Profiles")
+	assert.Len(t, frequencyProfiles.FrequencyProfile, 2, "Expected to parse 2 Frequency Profiles")
+	assert.EqualValues(t, "/interfaces/", (*frequencyProfiles.FrequencyProfile[0].Sensor)[0].SensorName, "Yaml type with a hyphen, didn't decode correctly")
+	minProfile, _ := json.Marshal(frequencyProfiles.FrequencyProfile[1])
+	assert.NotContains(t, string(minProfile), "sensor", "Optional Sensor type was not ignored")
+}
+
+func TestHelperFilesJsonParsing(t *testing.T) {
+	var helperFiles HelperFiles
+	err := helperFiles.Parse(HelperLoadBytes(t, "./helper-files/helper-files.json"))
+	assert.Nil(t, err, "Failed to parse json representation of Helper Files")
+	assert.Len(t, helperFiles.HelperFile, 2, "Expected to parse 2 Helper Files")
+	checksums := helperFiles.Checksums()
+	assert.EqualValues(t, "fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9", checksums["lib/util.py"], "Json type with a hyphen, didn't decode correctly")
+}
+
+func TestFindConfigurationFiles(t *testing.T) {
+	directory := filepath.Join("testdata", "discovery")
+	filenames, err := FindConfigurationFiles(directory, false)
+	assert.Nil(t, err, "Failed to find configuration files")
+	assert.Equal(t, []string{filepath.Join(directory, "a.json"), filepath.Join(directory, "devices.yml")}, filenames, "Expected only visible yaml and json files, sorted")
+
+	filenames, err = FindConfigurationFiles(directory, true)
+	assert.Nil(t, err, "Failed to find configuration files recursively")
+	assert.Equal(t, []string{filepath.Join(directory, "a.json"), filepath.Join(directory, "devices.yml"), filepath.Join(directory, "nested", "b.yaml")}, filenames, "Expected hidden directories to be skipped")
+
+	filenames, err = FindConfigurationFiles(filepath.Join(directory, "README.md"), false)
+	assert.Nil(t, err, "Failed to find an explicit configuration file")
+	assert.Len(t, filenames, 1, "Expected an explicit file to be used regardless of extension")
+
+	filenames, _ = FindConfigurationFiles(Stdin, false)
+	assert.Equal(t, []string{Stdin}, filenames)
+
+	_, err = FindConfigurationFiles(filepath.Join(directory, "missing"), false)
+	assert.NotNil(t, err, "Expected a missing location to be an error")
+}
+
+func TestLoadDocuments(t *testing.T) {
+	documents, err := LoadDocuments(filepath.Join("testdata", "discovery", "devices.yml"))
+	assert.Nil(t, err, "Failed to load yaml stream")
+	assert.Len(t, documents, 2, "Expected 2 documents in the yaml stream")
+	var devices Devices
+	assert.Nil(t, devices.Parse(documents[1]))
+	assert.Len(t, devices.Device, 2, "Expected the second document to hold 2 Devices")
+
+	documents, err = LoadDocuments(filepath.Join("testdata", "discovery", "a.json"))
+	assert.Nil(t, err, "Failed to load json")
+	assert.Len(t, documents, 1, "Expected json to be a single document")
+}
+
+func TestReadConfigurationFromStdin(t *testing.T) {
+	data, err := ReadConfiguration(Stdin, strings.NewReader("device: []"))
+	assert.Nil(t, err)
+	assert.Equal(t, "device: []", string(data))
+}
+
+const unknownFields = `{"device":[{"device-id":"mx1","host":"10.0.0.1","maintenance-window":"sunday",
+"authentication":{"password":{"username":"root","password":"secret","kind":"plain"},"kerberos":{"realm":"LAB"}},
+"snmp":{"port":161,"engine":{"id":"80001f88"}},"future-ingest":{"port":2200}}],"revision":7}`
+
+func TestUnknownFieldsJSONRoundTrip(t *testing.T) {
+	var devices Devices
+	assert.Nil(t, json.Unmarshal([]byte(unknownFields), &devices))
+	assert.EqualValues(t, 7, devices.Extra["revision"], "Expected numbers to stay numbers")
+	assert.Contains(t, devices.Device[0].Authentication.Extra, "kerberos")
+	assert.Contains(t, devices.Device[0].Authentication.Password.Extra, "kind")
+
+	data, err := json.Marshal(devices)
+	assert.Nil(t, err)
+	assert.JSONEq(t, unknownFields, string(data), "Expected the unknown fields to be written back")
+}
+
+func TestUnknownFieldsYAMLRoundTrip(t *testing.T) {
+	var devices Devices
+	assert.Nil(t, json.Unmarshal([]byte(unknownFields), &devices))
+	document, err := yaml.Marshal(devices)
+	assert.Nil(t, err)
+	assert.Contains(t, string(document), "future-ingest")
+
+	var parsed Devices
+	assert.Nil(t, parsed.Parse(document))
+	data, err := json.Marshal(parsed)
+	assert.Nil(t, err)
+	assert.JSONEq(t, unknownFields, string(data), "Expected the unknown fields to survive yaml")
+}
+
+func TestWithoutUnknownFields(t *testing.T) {
+	var devices Devices
+	_ = devices.Parse(HelperLoadBytes(t, "./devices/devices.yml"))
+	type plain Devices
+	expected, _ := json.Marshal(plain(devices))
+	data, err := json.Marshal(devices)
+	assert.Nil(t, err)
+	assert.Equal(t, string(expected), string(data), "Expected the same json when there are no unknown fields")
+}
+
+// yamlName - the name of a field in yaml, the lower case field name when there is no tag
+func yamlName(f reflect.StructField) string {
+	if name := strings.Split(f.Tag.Get("yaml"), ",")[0]; name != "" {
+		return name
+	}
+	return strings.ToLower(f.Name)
+}
+
+func checkFieldNames(t *testing.T, typ reflect.Type, path string) {
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		checkFieldNames(t, typ.Elem(), path)
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if f.Type == extraType || jsonName(f) == "" {
+				continue
+			}
+			assert.Equal(t, jsonName(f), yamlName(f), "json and yaml names differ for %s.%s", path, f.Name)
+			checkFieldNames(t, f.Type, path+"."+f.Name)
+		}
+	}
+}
+
+func TestFieldNamesMatch(t *testing.T) {
+	for _, v := range []interface{}{Destinations{}, DeviceGroups{}, Devices{}, FrequencyProfiles{}, HelperFiles{},
+		NetworkGroups{}, PlaybookInstances{}, Playbooks{}, Reports{}, RetentionPolicies{}, Schedulers{},
+		SnmpNotification{}, Syslog{}} {
+		typ := reflect.TypeOf(v)
+		checkFieldNames(t, typ, typ.Name())
+	}
+}
+
+func TestFullDeviceYamlParsing(t *testing.T) {
+	var devices Devices
+	assert.Nil(t, devices.Parse(HelperLoadBytes(t, "./devices/full.yml")), "Failed to parse yaml representation of Devices")
+	assert.Nil(t, devices.Validate())
+	d := devices.Device[0]
+	assert.Equal(t, "+05:30", *d.Timezone)
+	assert.Equal(t, "lab-keys", d.Authentication.SSH.SSHKeyProfile)
+	assert.Equal(t, "lab-ca", *d.Authentication.SSL.CaProfile)
+	assert.Equal(t, "hbpoll", d.Snmp.V3.Usm.Username)
+	assert.Equal(t, 2200, d.OutboundSSH.Port)
+	assert.Len(t, d.Flow.SourceIP, 2)
+	assert.Equal(t, "flap-threshold", d.Variable[0].VariableValue[0].Name)
+	assert.Empty(t, d.Extra, "Expected every field of the device to be modelled")
+
+	data, err := json.Marshal(devices)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), `"password":{`, "Expected no password when only ssh and ssl are used")
+	assert.Contains(t, string(data), `"outbound-ssh":{"port":2200}`)
+
+	var fields []string
+	for _, secret := range devices.Secrets() {
+		fields = append(fields, secret.Field)
+	}
+	assert.Equal(t, []string{AuthenticationPasswordField, PrivacyPasswordField}, fields)
+}
+
+func TestDevicesValidate(t *testing.T) {
+	valid := func() Devices {
+		var devices Devices
+		_ = devices.Parse(HelperLoadBytes(t, "./devices/full.yml"))
+		return devices
+	}
+	none, sha, utc, bad := "none", "sha", "UTC", "+25:00"
+	for name, change := range map[string]func(d *Device){
+		"missing host":               func(d *Device) { d.Host = "" },
+		"invalid port":               func(d *Device) { d.OutboundSSH.Port = 70000 },
+		"invalid timezone":           func(d *Device) { d.Timezone = &bad },
+		"unknown protocol":           func(d *Device) { d.Snmp.V3.Usm.AuthenticationProtocol = &bad },
+		"privacy without auth":       func(d *Device) { d.Snmp.V3.Usm.AuthenticationProtocol = &none },
+		"missing auth password":      func(d *Device) { d.Snmp.V3.Usm.AuthenticationPassword = nil },
+		"missing privacy password":   func(d *Device) { d.Snmp.V3.Usm.PrivacyPassword = nil },
+		"missing ssh key profile":    func(d *Device) { d.Authentication.SSH.SSHKeyProfile = "" },
+		"invalid flow source":        func(d *Device) { d.Flow.SourceIP = []string{"10.0.0"} },
+		"incomplete variable":        func(d *Device) { d.Variable[0].InstanceID = "" },
+		"variable value has no name": func(d *Device) { d.Variable[0].VariableValue[0].Name = "" },
+	} {
+		devices := valid()
+		change(&devices.Device[0])
+		assert.NotNil(t, devices.Validate(), "Expected %s to be invalid", name)
+	}
+
+	devices := valid()
+	devices.Device[0].Timezone = &utc
+	devices.Device[0].Snmp.V3.Usm.AuthenticationProtocol = &sha
+	devices.Device[0].Snmp.V3.Usm.PrivacyProtocol = &none
+	devices.Device[0].Snmp.V3.Usm.PrivacyPassword = nil
+	assert.Nil(t, devices.Validate(), "Expected authentication without privacy to be valid")
+
+	devices.Device = append(devices.Device, devices.Device[0])
+	assert.NotNil(t, devices.Validate(), "Expected a duplicate device-id to be invalid")
+}
+
+func TestFullDeviceGroupYamlParsing(t *testing.T) {
+	var deviceGroups DeviceGroups
+	assert.Nil(t, deviceGroups.Parse(HelperLoadBytes(t, "./device-groups/full.yml")), "Failed to parse yaml representation of DeviceGroups")
+	assert.Nil(t, deviceGroups.Validate())
+	dg := deviceGroups.DeviceGroup[0]
+	assert.Equal(t, []int{1514}, dg.Syslog.Ports)
+	assert.Equal(t, []int{6343}, dg.Sflow.Ports)
+	assert.Equal(t, []string{"noc-slack"}, *dg.Notification.Major)
+	assert.Nil(t, dg.Notification.Normal)
+	assert.Equal(t, "warn", *dg.Logging.LogLevel)
+	assert.Equal(t, []string{"fast-interfaces"}, *dg.IngestFrequency)
+	assert.Equal(t, "core", dg.Variable[0].InstanceID)
+	assert.Empty(t, dg.Extra, "Expected every field of the device group to be modelled")
+
+	data, err := json.Marshal(deviceGroups)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"notification":{"major":["noc-slack"],"minor":["noc-email"]}`)
+	assert.Contains(t, string(data), `"ingest-frequency":["fast-interfaces"]`)
+}
+
+func TestDeviceGroupsValidate(t *testing.T) {
+	valid := func() DeviceGroups {
+		var deviceGroups DeviceGroups
+		_ = deviceGroups.Parse(HelperLoadBytes(t, "./device-groups/full.yml"))
+		return deviceGroups
+	}
+	verbose, unnamed := "verbose", []string{""}
+	for name, change := range map[string]func(dg *DeviceGroup){
+		"missing name":         func(dg *DeviceGroup) { dg.DeviceGroupName = "" },
+		"invalid syslog port":  func(dg *DeviceGroup) { dg.Syslog.Ports = []int{0} },
+		"invalid sflow port":   func(dg *DeviceGroup) { dg.Sflow.Ports = []int{65536} },
+		"unnamed notification": func(dg *DeviceGroup) { dg.Notification.Normal = &unnamed },
+		"invalid log level":    func(dg *DeviceGroup) { dg.Logging.LogLevel = &verbose },
+		"incomplete variable":  func(dg *DeviceGroup) { dg.Variable[0].Rule = "" },
+	} {
+		deviceGroups := valid()
+		change(&deviceGroups.DeviceGroup[0])
+		assert.NotNil(t, deviceGroups.Validate(), "Expected %s to be invalid", name)
+	}
+
+	deviceGroups := valid()
+	deviceGroups.DeviceGroup = append(deviceGroups.DeviceGroup, deviceGroups.DeviceGroup[0])
+	assert.NotNil(t, deviceGroups.Validate(), "Expected a duplicate device-group-name to be invalid")
+}
@@ -3,11 +3,26 @@ package types
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 )
 
+// Stdin - file location that reads the configuration from standard input
+const Stdin = "-"
+
+// ConfigurationExtensions - the file extensions considered to be configuration
+var ConfigurationExtensions = []string{".yml", ".yaml", ".json"}
+
+// a line containing only the yaml document marker, optionally followed by a comment
+var documentSeparator = regexp.MustCompile(`(?m)^---[ \t]*(#.*)?$`)
+
 // Configuration - structures that get loaded from files
 type Configuration interface {
 	Parse(data []byte) error
@@ -26,6 +41,91 @@ func LoadConfiguration(filelocation string, configuration Configuration) error {
 	return nil
 }
 
+// FindConfigurationFiles - returns the sorted configuration files for a location, which can be a file,
+// a directory (optionally walked recursively) or Stdin. Hidden files and directories are skipped.
+func FindConfigurationFiles(location string, recursive bool) ([]string, error) {
+	if location == Stdin {
+		return []string{Stdin}, nil
+	}
+	info, err := os.Stat(location)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{location}, nil
+	}
+	var filenames []string
+	err = filepath.Walk(location, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == location {
+			return nil
+		}
+		if info.IsDir() {
+			if !recursive || strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isConfigurationFile(info.Name()) {
+			filenames = append(filenames, path)
+		}
+		return nil
+	})
+	sort.Strings(filenames)
+	return filenames, err
+}
+
+func isConfigurationFile(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range ConfigurationExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadConfiguration - reads a configuration file, or standard input when the location is Stdin
+func ReadConfiguration(filelocation string, stdin io.Reader) ([]byte, error) {
+	if filelocation == Stdin {
+		return ioutil.ReadAll(stdin)
+	}
+	return ioutil.ReadFile(filelocation)
+}
+
+// LoadDocuments - returns each document in a configuration file, yaml streams can hold more than one document
+func LoadDocuments(filelocation string) ([][]byte, error) {
+	return ReadDocuments(filelocation, os.Stdin)
+}
+
+// ReadDocuments - as LoadDocuments, with standard input read from stdin when the location is Stdin
+func ReadDocuments(filelocation string, stdin io.Reader) ([][]byte, error) {
+	data, err := ReadConfiguration(filelocation, stdin)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(filepath.Ext(filelocation)) == ".json" {
+		return [][]byte{data}, nil
+	}
+	return SplitDocuments(data), nil
+}
+
+// SplitDocuments - splits a yaml stream into its documents, empty documents are dropped
+func SplitDocuments(data []byte) (documents [][]byte) {
+	for _, document := range documentSeparator.Split(string(data), -1) {
+		if strings.TrimSpace(document) == "" {
+			continue
+		}
+		documents = append(documents, []byte(document))
+	}
+	return
+}
+
 // DumpYAMLOrJSON - For a configuration, output json or yaml
 func DumpYAMLOrJSON(format string, configuration Configuration) string {
 	var data []byte